@@ -3,8 +3,10 @@ module github.com/pankajredekar/goosegorm
 go 1.25
 
 require (
+	github.com/go-sql-driver/mysql v1.7.0
 	github.com/spf13/cobra v1.8.0
 	gopkg.in/yaml.v3 v3.0.1
+	gorm.io/driver/mysql v1.5.2
 	gorm.io/driver/postgres v1.5.4
 	gorm.io/driver/sqlite v1.5.4
 	gorm.io/gorm v1.25.5
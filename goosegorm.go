@@ -1,8 +1,15 @@
 package goosegorm
 
 import (
+	"context"
+	"time"
+	"unsafe"
+
+	"github.com/pankajredekar/goosegorm/internal/config"
+	"github.com/pankajredekar/goosegorm/internal/lock"
 	"github.com/pankajredekar/goosegorm/internal/runner"
 	"github.com/pankajredekar/goosegorm/internal/schema"
+	"github.com/pankajredekar/goosegorm/internal/tenant"
 	"github.com/pankajredekar/goosegorm/internal/versioner"
 	"gorm.io/gorm"
 )
@@ -10,6 +17,30 @@ import (
 // Migration interface that all migrations must implement
 type Migration = runner.Migration
 
+// NonTransactionalMigration can be implemented by migrations that must run outside a transaction
+type NonTransactionalMigration = runner.NonTransactionalMigration
+
+// DependentMigration can be implemented by migrations that declare the migrations they depend on
+type DependentMigration = runner.DependentMigration
+
+// IrreversibleMigration can be implemented by migrations that cannot be rolled back
+type IrreversibleMigration = runner.IrreversibleMigration
+
+// ErrIrreversible is returned by the Down method of a migration that cannot be undone
+var ErrIrreversible = runner.ErrIrreversible
+
+// ErrDirty is matched by errors.Is when migrations are refused because one failed part way through
+var ErrDirty = runner.ErrDirty
+
+// ReplacingMigration can be implemented by squashed migrations to declare the migrations they replace
+type ReplacingMigration = runner.ReplacingMigration
+
+// ContextMigration can be implemented by migrations whose Up and Down accept a context
+type ContextMigration = runner.ContextMigration
+
+// TimeoutMigration can be implemented by migrations that declare their own timeout
+type TimeoutMigration = runner.TimeoutMigration
+
 // SchemaBuilder is exported for use in migrations
 type SchemaBuilder = schema.SchemaBuilder
 
@@ -46,11 +77,10 @@ func SetGlobalRegistry(reg *runner.Registry) {
 	globalRegistry = reg
 }
 
-// Helper function to check if db is a SchemaBuilder (for migrations)
+// IsSchemaBuilder reports whether a migration is being simulated, returning the SchemaBuilder
+// that was passed in place of the *gorm.DB
 func IsSchemaBuilder(db *gorm.DB) (*schema.SchemaBuilder, bool) {
-	// This is a type assertion helper
-	// Migrations will need to use interface{} and check manually
-	return nil, false
+	return schema.SimulationFor(unsafe.Pointer(db))
 }
 
 // Helper to convert interface{} to SchemaBuilder
@@ -61,17 +91,165 @@ func AsSchemaBuilder(db interface{}) (*schema.SchemaBuilder, bool) {
 	return nil, false
 }
 
+// DataOperation is a data change made by a migration, skipped during simulation
+type DataOperation = runner.DataOperation
+
+// DataFunc is the Go code of a data migration
+type DataFunc = runner.DataFunc
+
+// RunGo creates a data operation from Go functions (down may be nil)
+func RunGo(up, down DataFunc) *DataOperation {
+	return runner.RunGo(up, down)
+}
+
+// RunSQL creates a data operation from SQL statements (downSQL may be empty)
+func RunSQL(upSQL, downSQL string) *DataOperation {
+	return runner.RunSQL(upSQL, downSQL)
+}
+
 // Export versioner and runner types for migrator
 type Versioner = versioner.Versioner
 type Runner = runner.Runner
 type Registry = runner.Registry
+type Plan = runner.Plan
+type ChecksumIssue = runner.ChecksumIssue
+type OutOfOrderError = runner.OutOfOrderError
+type MissingDependencyError = runner.MissingDependencyError
+type CycleError = runner.CycleError
+type IrreversibleError = runner.IrreversibleError
+type PartialSquashError = runner.PartialSquashError
+type DirtyError = runner.DirtyError
+type Direction = runner.Direction
+
+// Plan directions and the target that unapplies every migration
+const (
+	DirectionUp   = runner.DirectionUp
+	DirectionDown = runner.DirectionDown
+	TargetZero    = runner.TargetZero
+)
+
+// Checksum problems reported by Runner.Verify
+const (
+	ChecksumChanged = runner.ChecksumChanged
+	ChecksumMissing = runner.ChecksumMissing
+)
+
+// Runner lifecycle events and hooks
+type EventType = runner.EventType
+type Event = runner.Event
+type Hook = runner.Hook
+
+// Events fired by the runner, see runner.Event
+const (
+	EventBeforeAll       = runner.EventBeforeAll
+	EventBeforeMigration = runner.EventBeforeMigration
+	EventAfterMigration  = runner.EventAfterMigration
+	EventMigrationFailed = runner.EventMigrationFailed
+	EventAfterAll        = runner.EventAfterAll
+)
+
+// globalHooks are hooks registered by the project (usually from an init() in the
+// migrations package) and attached to the runner of the migrator binary
+var globalHooks []func(*Runner)
+
+// RegisterHook registers a hook for one event type on migrator runners
+func RegisterHook(eventType EventType, hook Hook) {
+	globalHooks = append(globalHooks, func(r *Runner) { r.On(eventType, hook) })
+}
+
+// RegisterListener registers a hook that receives every event on migrator runners
+func RegisterListener(hook Hook) {
+	globalHooks = append(globalHooks, func(r *Runner) { r.AddListener(hook) })
+}
+
+// AttachRegisteredHooks adds the hooks registered with RegisterHook and RegisterListener to a runner
+func AttachRegisteredHooks(r *Runner) {
+	for _, attach := range globalHooks {
+		attach(r)
+	}
+}
+
+// MigrationRecord is the history record of an applied migration
+type MigrationRecord = versioner.MigrationRecord
+
+// ExecutionInfo is recorded with every applied migration
+type ExecutionInfo = versioner.ExecutionInfo
+
+// CurrentExecutionInfo returns the host, OS user and goosegorm version of this process with a build label
+func CurrentExecutionInfo(buildLabel string) ExecutionInfo {
+	return versioner.CurrentExecutionInfo(GetVersion(), buildLabel)
+}
 
 // NewVersioner creates a new versioner (exported for migrator)
+// Applied migrations are recorded with CurrentExecutionInfo, without a build label.
 func NewVersioner(db *gorm.DB, tableName string) *Versioner {
-	return versioner.NewVersioner(db, tableName)
+	ver := versioner.NewVersioner(db, tableName)
+	ver.SetExecutionInfo(CurrentExecutionInfo(""))
+	return ver
 }
 
 // NewRunner creates a new runner (exported for migrator)
 func NewRunner(db *gorm.DB, registry *Registry, ver *Versioner) *Runner {
 	return runner.NewRunner(db, registry, ver)
 }
+
+// Locker serializes migration runs across processes
+type Locker = lock.Locker
+
+// LockHeldError is returned when the migration lock is held by another process
+type LockHeldError = lock.HeldError
+
+// DefaultLockTimeout is how long to wait for the migration lock by default
+const DefaultLockTimeout = lock.DefaultTimeout
+
+// NewLocker creates a migration lock for the database (exported for migrator)
+func NewLocker(db *gorm.DB, migrationTable string) Locker {
+	return lock.NewLocker(db, migrationTable)
+}
+
+// ParseLockTimeout parses a lock timeout duration, falling back to DefaultLockTimeout when empty
+func ParseLockTimeout(value string) (time.Duration, error) {
+	if value == "" {
+		return DefaultLockTimeout, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// TenantResult is the outcome of running the migrations of one tenant schema
+type TenantResult = tenant.Result
+
+// DefaultTenantConcurrency is how many tenant schemas are migrated at once by default
+const DefaultTenantConcurrency = tenant.DefaultConcurrency
+
+// TenantSchemas returns the tenant schemas: the static list, or the rows the query returns (exported for migrator)
+func TenantSchemas(db *gorm.DB, static []string, query string) ([]string, error) {
+	return tenant.Schemas(db, static, query)
+}
+
+// TenantURL returns the PostgreSQL database URL whose connections use the tenant schema as their search_path
+func TenantURL(databaseURL, schema string) (string, error) {
+	return tenant.URL(databaseURL, schema)
+}
+
+// EnsureTenantSchema creates the schema of a tenant if it doesn't exist yet
+func EnsureTenantSchema(db *gorm.DB, schema string) error {
+	return tenant.EnsureSchema(db, schema)
+}
+
+// RunTenants calls fn for each tenant schema, with at most concurrency calls at once (exported for migrator)
+func RunTenants(ctx context.Context, schemas []string, concurrency int, continueOnError bool, fn func(ctx context.Context, schema string) (int, error)) []TenantResult {
+	return tenant.Run(ctx, schemas, concurrency, continueOnError, fn)
+}
+
+// Config is the configuration read from goosegorm.yml
+type Config = config.Config
+
+// LoadConfig loads goosegorm.yml as the CLI does, for an environment ("" for none) and a database
+// ("" for the default one), with environment variables interpolated (exported for migrator)
+func LoadConfig(configPath, env, database string) (*Config, error) {
+	cfg, err := config.LoadConfigForEnv(configPath, env)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.ForDatabase(database)
+}
@@ -6,8 +6,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/pankajredekar/goosegorm/internal/config"
+	"github.com/pankajredekar/goosegorm/internal/generator"
+	"github.com/pankajredekar/goosegorm/internal/loader"
 	"github.com/pankajredekar/goosegorm/internal/utils"
 	"github.com/spf13/cobra"
 )
@@ -33,6 +36,7 @@ var buildCmd = &cobra.Command{
 			utils.PrintError("Invalid config: %v", err)
 			os.Exit(1)
 		}
+		cfg = selectDatabase(cfg)
 
 		if cfg.BuildPath == "" {
 			utils.PrintError("build_path not set in goosegorm.yml. Please set build_path to save the migrator binary")
@@ -101,189 +105,22 @@ var buildCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		// Create main.go for temporary migrator
-		mainFile := filepath.Join(tempMigratorDir, "main.go")
-		mainContent := fmt.Sprintf(`package main
-
-import (
-	"fmt"
-	"log"
-	"os"
-	"strconv"
-	"strings"
-
-	"github.com/pankajredekar/goosegorm"
-	"gorm.io/driver/postgres"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
-	
-	// Import migrations to trigger their init() functions
-	_ "%s"
-)
-
-func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: goosegorm <command> [args...]")
-		fmt.Println("Commands: migrate, rollback, show")
-		os.Exit(1)
-	}
-
-	command := os.Args[1]
-	configPath := "goosegorm.yml"
-	
-	// Simple config loading (inline to avoid internal package dependency)
-	type Config struct {
-		DatabaseURL    string
-		MigrationTable string
-	}
-	
-	configData, err := os.ReadFile(configPath)
-	if err != nil {
-		log.Fatalf("goosegorm.yml not found. Run 'goosegorm init' first")
-	}
-	
-	// Simple YAML parsing for database_url and migration_table
-	cfg := Config{
-		DatabaseURL:    "sqlite://:memory:",
-		MigrationTable: "_goosegorm_migrations",
-	}
-	lines := strings.Split(string(configData), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "database_url:") {
-			cfg.DatabaseURL = strings.TrimSpace(strings.TrimPrefix(line, "database_url:"))
-		} else if strings.HasPrefix(line, "migration_table:") {
-			cfg.MigrationTable = strings.TrimSpace(strings.TrimPrefix(line, "migration_table:"))
-		}
-	}
-
-	// Connect to database
-	db, err := connectDB(cfg.DatabaseURL)
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %%v", err)
-	}
-
-	// Initialize versioner using public API
-	ver := goosegorm.NewVersioner(db, cfg.MigrationTable)
-	if err := ver.Initialize(); err != nil {
-		log.Fatalf("Failed to initialize version table: %%v", err)
-	}
-
-	// Get the global registry (migrations register themselves via init())
-	registry := goosegorm.GetGlobalRegistry()
-
-	// Create runner using public API
-	run := goosegorm.NewRunner(db, registry, ver)
-
-	switch command {
-	case "migrate":
-		// Get pending migrations
-		pending, err := run.GetPendingMigrations()
+		// Embed checksums of the migration sources so applied migrations can be verified
+		checksums, err := loader.ComputeChecksums(migrationsAbsPath)
 		if err != nil {
-			log.Fatalf("Failed to get pending migrations: %%v", err)
-		}
-
-		if len(pending) == 0 {
-			fmt.Println("No pending migrations")
-			return
-		}
-
-		fmt.Printf("Applying %%d migration(s)...\n", len(pending))
-
-		// Apply migrations
-		if err := run.Migrate(); err != nil {
-			log.Fatalf("Failed to apply migrations: %%v", err)
-		}
-
-		fmt.Printf("Applied %%d migration(s)\n", len(pending))
-
-	case "rollback":
-		n := 1
-		if len(os.Args) > 2 {
-			n, err = strconv.Atoi(os.Args[2])
-			if err != nil {
-				log.Fatalf("Invalid number: %%v", err)
-			}
-		}
-
-		// Get applied count
-		appliedCount, err := ver.GetAppliedCount()
-		if err != nil {
-			log.Fatalf("Failed to get applied count: %%v", err)
-		}
-
-		if appliedCount == 0 {
-			fmt.Println("No migrations to rollback")
-			return
-		}
-
-		if int64(n) > appliedCount {
-			n = int(appliedCount)
-		}
-
-		fmt.Printf("Rolling back %%d migration(s)...\n", n)
-
-		// Rollback
-		if err := run.Rollback(n); err != nil {
-			log.Fatalf("Failed to rollback: %%v", err)
-		}
-
-		fmt.Printf("Rolled back %%d migration(s)\n", n)
-
-	case "show":
-		// Get applied migrations
-		applied, err := run.GetAppliedMigrations()
-		if err != nil {
-			log.Fatalf("Failed to get applied migrations: %%v", err)
-		}
-
-		// Get pending migrations
-		pending, err := run.GetPendingMigrations()
-		if err != nil {
-			log.Fatalf("Failed to get pending migrations: %%v", err)
-		}
-
-		fmt.Println("\n" + strings.Repeat("=", 60))
-		fmt.Println("Migration Status")
-		fmt.Println(strings.Repeat("=", 60))
-
-		if len(applied) > 0 {
-			fmt.Println("\n✓ Applied Migrations:")
-			for _, m := range applied {
-				fmt.Printf("  %%s - %%s\n", m.Version(), m.Name())
-			}
-		} else {
-			fmt.Println("\n✓ Applied Migrations: (none)")
+			utils.PrintError("Failed to compute migration checksums: %v", err)
+			os.Exit(1)
 		}
 
-		if len(pending) > 0 {
-			fmt.Println("\n○ Pending Migrations:")
-			for _, m := range pending {
-				fmt.Printf("  %%s - %%s\n", m.Version(), m.Name())
-			}
-		} else {
-			fmt.Println("\n○ Pending Migrations: (none)")
+		// Label the build in the migration history, with the git commit unless given
+		label, _ := cmd.Flags().GetString("label")
+		if label == "" {
+			label = gitCommit(configDir)
 		}
 
-		fmt.Println()
-
-	default:
-		fmt.Printf("Unknown command: %%s\n", command)
-		fmt.Println("Commands: migrate, rollback, show")
-		os.Exit(1)
-	}
-}
-
-func connectDB(databaseURL string) (*gorm.DB, error) {
-	if strings.Contains(databaseURL, "postgres://") || strings.Contains(databaseURL, "postgresql://") {
-		return gorm.Open(postgres.Open(databaseURL), &gorm.Config{})
-	} else if strings.Contains(databaseURL, "sqlite://") {
-		path := strings.TrimPrefix(databaseURL, "sqlite://")
-		return gorm.Open(sqlite.Open(path), &gorm.Config{})
-	}
-	return nil, fmt.Errorf("unsupported database URL: %%s", databaseURL)
-}
-`, migrationsImportPath)
+		// Create main.go for temporary migrator
+		mainFile := filepath.Join(tempMigratorDir, "main.go")
+		mainContent := generator.MigratorMainContent(migrationsImportPath, checksums, migrationTables(migrationsAbsPath, cfg.PackageName), label, cfg.Name, cfg.UsesDialect("mysql"))
 
 		if err := os.WriteFile(mainFile, []byte(mainContent), 0644); err != nil {
 			utils.PrintError("Failed to create temporary migrator: %v", err)
@@ -344,10 +181,25 @@ func connectDB(databaseURL string) (*gorm.DB, error) {
 		}
 
 		utils.PrintSuccess("Migrator binary built and saved to: %s", buildPath)
+		if label != "" {
+			utils.PrintInfo("Build label: %s", label)
+		}
 		utils.PrintInfo("You can now use this binary in production with: %s migrate|rollback|show", buildPath)
 	},
 }
 
+// gitCommit returns the short commit hash of the git checkout in dir, or "" if there is none
+func gitCommit(dir string) string {
+	cmd := exec.Command("git", "rev-parse", "--short", "HEAD")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
 func init() {
+	buildCmd.Flags().String("label", "", "Build label recorded with every migration the binary applies (default: the git commit)")
 	rootCmd.AddCommand(buildCmd)
 }
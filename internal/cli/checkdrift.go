@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pankajredekar/goosegorm/internal/config"
+	"github.com/pankajredekar/goosegorm/internal/drift"
+	"github.com/pankajredekar/goosegorm/internal/runner"
+	"github.com/pankajredekar/goosegorm/internal/schema"
+	"github.com/pankajredekar/goosegorm/internal/utils"
+	"github.com/pankajredekar/goosegorm/internal/versioner"
+	"github.com/spf13/cobra"
+)
+
+// exitDrift is the exit status of check-drift when drift is found, so scripts can tell it
+// from a failure to run the check (status 1)
+const exitDrift = 2
+
+var checkDriftCmd = &cobra.Command{
+	Use:   "check-drift",
+	Short: "Detect schema changes made outside of migrations",
+	Long:  "Compares the connected database with the schema its applied migrations produce, and reports extra or missing tables, columns and indexes and column type mismatches. Exits with status 2 if any drift is found, and 1 if the check fails",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		if format != "text" && format != "json" {
+			utils.PrintError("Invalid --format %q: must be text or json", format)
+			os.Exit(1)
+		}
+
+		configPath := "goosegorm.yml"
+		if !utils.FileExists(configPath) {
+			utils.PrintError("goosegorm.yml not found. Run 'goosegorm init' first")
+			os.Exit(1)
+		}
+
+		cfg, err := config.LoadConfig(configPath)
+		if err != nil {
+			utils.PrintError("Failed to load config: %v", err)
+			os.Exit(1)
+		}
+
+		if err := cfg.Validate(); err != nil {
+			utils.PrintError("Invalid config: %v", err)
+			os.Exit(1)
+		}
+		cfg = selectDatabase(cfg)
+
+		db, err := connectDB(cfg.DatabaseURL)
+		if err != nil {
+			utils.PrintError("Failed to connect to database: %v", err)
+			os.Exit(1)
+		}
+
+		// Compiled migrations can't be simulated, so the sources are interpreted
+		registry, err := loadMigrationsFromDir(cfg.MigrationsDir, cfg.PackageName)
+		if err != nil {
+			utils.PrintError("Failed to load migrations: %v", err)
+			os.Exit(1)
+		}
+
+		// The check only reads the database: without a tracking table no migration has been applied
+		expected := schema.NewSchemaBuilder()
+		ver := versioner.NewVersioner(db, cfg.MigrationTable)
+		if ver.Exists() {
+			expected, err = runner.NewRunner(db, registry, ver).SimulateAppliedSchema()
+			if err != nil {
+				utils.PrintError("Failed to simulate applied migrations: %v", err)
+				os.Exit(1)
+			}
+		}
+
+		skip := []string{cfg.MigrationTable, cfg.MigrationTable + "_lock"}
+		issues, err := drift.Detect(db, expected.Schema, skip)
+		if err != nil {
+			utils.PrintError("Failed to check drift: %v", err)
+			os.Exit(1)
+		}
+
+		if format == "json" {
+			report := struct {
+				Drift  bool          `json:"drift"`
+				Issues []drift.Issue `json:"issues"`
+			}{Drift: len(issues) > 0, Issues: issues}
+			if report.Issues == nil {
+				report.Issues = []drift.Issue{}
+			}
+			out, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				utils.PrintError("Failed to encode report: %v", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(out))
+		} else if len(issues) == 0 {
+			utils.PrintSuccess("No schema drift detected")
+		} else {
+			utils.PrintWarning("Schema drift detected (%d issue(s)):", len(issues))
+			for _, issue := range issues {
+				fmt.Printf("  %s\n", issue)
+			}
+		}
+
+		if len(issues) > 0 {
+			os.Exit(exitDrift)
+		}
+	},
+}
+
+func init() {
+	checkDriftCmd.Flags().String("format", "text", "Output format: text or json")
+	rootCmd.AddCommand(checkDriftCmd)
+}
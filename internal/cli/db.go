@@ -2,11 +2,14 @@ package cli
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/pankajredekar/goosegorm/internal/config"
-	"github.com/pankajredekar/goosegorm/internal/loader"
-	"github.com/pankajredekar/goosegorm/internal/runner"
+	"github.com/pankajredekar/goosegorm/internal/modelreflect"
+	"github.com/pankajredekar/goosegorm/internal/utils"
+	"github.com/pankajredekar/goosegorm/mysqldsn"
+	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -19,25 +22,38 @@ func connectDB(databaseURL string) (*gorm.DB, error) {
 	} else if strings.Contains(databaseURL, "sqlite://") {
 		path := strings.TrimPrefix(databaseURL, "sqlite://")
 		return gorm.Open(sqlite.Open(path), &gorm.Config{})
+	} else if strings.Contains(databaseURL, "mysql://") {
+		dsn, err := mysqldsn.FromURL(databaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return gorm.Open(mysql.Open(dsn), &gorm.Config{})
 	}
 	return nil, fmt.Errorf("unsupported database URL: %s", databaseURL)
 }
 
-// loadMigrations loads migrations from the directory
-// For simulation (makemigrations): uses AST parsing
-// For real DB execution (migrate): compiles and executes migrations
-func loadMigrations(migrationsDir string, useCompiled bool) (*runner.Registry, error) {
-	// Get package name from config
-	cfg, err := config.LoadConfig("goosegorm.yml")
+// selectDatabase returns the config of the database chosen with --database
+func selectDatabase(cfg *config.Config) *config.Config {
+	selected, err := cfg.ForDatabase(databaseFlag)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load config: %w", err)
+		utils.PrintError("%v", err)
+		os.Exit(1)
 	}
+	return selected
+}
 
-	if useCompiled {
-		// For real DB execution: compile and execute migrations
-		return loader.LoadMigrationsFromCompiled(migrationsDir, cfg.PackageName)
+// managedModels returns the models whose tables the selected database's migrations manage
+// Models annotated goosegorm:"database:<name>" belong to that database; the others to the
+// database that owns their models directory.
+func managedModels(cfg *config.Config, models []modelreflect.ParsedModel) []modelreflect.ParsedModel {
+	var managed []modelreflect.ParsedModel
+	for _, m := range models {
+		if !m.Managed || m.ShouldIgnore(cfg.IgnoreModels) {
+			continue
+		}
+		if m.Database == cfg.Name || (m.Database == "" && cfg.OwnsUnannotatedModels) {
+			managed = append(managed, m)
+		}
 	}
-
-	// For simulation: use AST parsing
-	return loader.LoadMigrationsFromAST(migrationsDir, cfg.PackageName)
+	return managed
 }
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/pankajredekar/goosegorm/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var forceCmd = &cobra.Command{
+	Use:   "force <version>",
+	Short: "Record a migration as applied or unapplied without running it",
+	Long:  "Repairs the record of a migration that failed part way through and left the database dirty. Fix the database by hand first, then record the migration as applied (its changes are complete) or unapplied (they were undone)",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		state, _ := cmd.Flags().GetString("state")
+		if state != "applied" && state != "unapplied" {
+			utils.PrintError("Invalid --state %q: must be applied or unapplied", state)
+			os.Exit(1)
+		}
+
+		migratorArgs := []string{"force", args[0], "--state", state}
+		migratorArgs = append(migratorArgs, lockArgs(cmd)...)
+
+		runTempMigrator(migratorArgs)
+	},
+}
+
+func init() {
+	forceCmd.Flags().String("state", "", "State to record: applied or unapplied")
+	addLockFlags(forceCmd)
+	rootCmd.AddCommand(forceCmd)
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pankajredekar/goosegorm/internal/config"
+	"github.com/pankajredekar/goosegorm/internal/diff"
+	"github.com/pankajredekar/goosegorm/internal/inspectdb"
+	"github.com/pankajredekar/goosegorm/internal/modelreflect"
+	"github.com/pankajredekar/goosegorm/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var inspectdbCmd = &cobra.Command{
+	Use:   "inspectdb",
+	Short: "Generate models from an existing database",
+	Long:  "Introspects the tables of the configured database and writes a GORM model for each of them into models_dir",
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath := "goosegorm.yml"
+		if !utils.FileExists(configPath) {
+			utils.PrintError("goosegorm.yml not found. Run 'goosegorm init' first")
+			os.Exit(1)
+		}
+
+		cfg, err := config.LoadConfig(configPath)
+		if err != nil {
+			utils.PrintError("Failed to load config: %v", err)
+			os.Exit(1)
+		}
+
+		if err := cfg.Validate(); err != nil {
+			utils.PrintError("Invalid config: %v", err)
+			os.Exit(1)
+		}
+		cfg = selectDatabase(cfg)
+
+		tableNames, _ := cmd.Flags().GetStringSlice("tables")
+		unmanaged, _ := cmd.Flags().GetBool("unmanaged")
+		overwrite, _ := cmd.Flags().GetBool("overwrite")
+
+		db, err := connectDB(cfg.DatabaseURL)
+		if err != nil {
+			utils.PrintError("Failed to connect to database: %v", err)
+			os.Exit(1)
+		}
+
+		skip := []string{cfg.MigrationTable, cfg.MigrationTable + "_lock"}
+		tables, err := inspectdb.Inspect(db, tableNames, skip)
+		if err != nil {
+			utils.PrintError("Failed to inspect database: %v", err)
+			os.Exit(1)
+		}
+		if len(tables) == 0 {
+			utils.PrintInfo("No tables found")
+			return
+		}
+
+		if err := os.MkdirAll(cfg.ModelsDir, 0755); err != nil {
+			utils.PrintError("Failed to create models directory: %v", err)
+			os.Exit(1)
+		}
+		packageName := modelsPackageName(cfg.ModelsDir)
+
+		// Models of a database sharing another database's models directory are annotated with it
+		database := ""
+		if !cfg.OwnsUnannotatedModels {
+			database = cfg.Name
+		}
+
+		var written []inspectdb.Table
+		for _, table := range tables {
+			filePath := filepath.Join(cfg.ModelsDir, table.Name+".go")
+			if utils.FileExists(filePath) && !overwrite {
+				utils.PrintWarning("Skipping %s: %s already exists (use --overwrite to replace it)", table.Name, filepath.Base(filePath))
+				continue
+			}
+
+			content, err := inspectdb.GenerateModel(packageName, table, !unmanaged, database)
+			if err != nil {
+				utils.PrintError("Failed to generate model for %s: %v", table.Name, err)
+				os.Exit(1)
+			}
+			if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+				utils.PrintError("Failed to write %s: %v", filePath, err)
+				os.Exit(1)
+			}
+
+			utils.PrintSuccess("Generated model %s: %s", inspectdb.ModelName(table.Name), filepath.Base(filePath))
+			written = append(written, table)
+		}
+
+		if len(written) > 0 {
+			checkRoundTrip(cfg.ModelsDir, written)
+		}
+	},
+}
+
+// checkRoundTrip warns about generated models that makemigrations would not read back as their table
+func checkRoundTrip(modelsDir string, tables []inspectdb.Table) {
+	models, err := modelreflect.ParseModelsFromDir(modelsDir, nil)
+	if err != nil {
+		utils.PrintWarning("Could not parse the generated models: %v", err)
+		return
+	}
+
+	generated := make(map[string]bool)
+	for _, table := range tables {
+		generated[table.Name] = true
+	}
+
+	var generatedModels []modelreflect.ParsedModel
+	for _, m := range models {
+		if generated[m.GetTableName()] {
+			// Unmanaged models are compared too
+			m.Managed = true
+			generatedModels = append(generatedModels, m)
+		}
+	}
+
+	diffs, err := diff.CompareSchema(inspectdb.Schema(tables), generatedModels)
+	if err != nil {
+		utils.PrintWarning("Could not compare the generated models with the database: %v", err)
+		return
+	}
+	if len(diffs) == 0 {
+		return
+	}
+
+	utils.PrintWarning("Some generated models don't match their table as makemigrations reads them; review them before running makemigrations:")
+	for _, d := range diffs {
+		switch {
+		case d.Column != nil:
+			utils.PrintWarning("  %s %s.%s", d.Type, d.TableName, d.Column.Name)
+		case d.Index != nil:
+			utils.PrintWarning("  %s %s.%s", d.Type, d.TableName, d.Index.Name)
+		default:
+			utils.PrintWarning("  %s %s", d.Type, d.TableName)
+		}
+	}
+}
+
+// modelsPackageName returns the package name of the Go files in the models directory,
+// or the directory name if it has none
+func modelsPackageName(dir string) string {
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, parser.PackageClauseOnly)
+	if err == nil {
+		for name := range pkgs {
+			return name
+		}
+	}
+	return strings.ReplaceAll(filepath.Base(dir), "-", "_")
+}
+
+func init() {
+	inspectdbCmd.Flags().StringSlice("tables", nil, "Only inspect these tables (comma-separated)")
+	inspectdbCmd.Flags().Bool("unmanaged", false, "Mark the generated models goosegorm:\"managed:false\" so makemigrations leaves their tables alone")
+	inspectdbCmd.Flags().Bool("overwrite", false, "Replace model files that already exist")
+	rootCmd.AddCommand(inspectdbCmd)
+}
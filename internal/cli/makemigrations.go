@@ -12,7 +12,6 @@ import (
 	"github.com/pankajredekar/goosegorm/internal/loader"
 	"github.com/pankajredekar/goosegorm/internal/modelreflect"
 	"github.com/pankajredekar/goosegorm/internal/runner"
-	"github.com/pankajredekar/goosegorm/internal/schema"
 	"github.com/pankajredekar/goosegorm/internal/utils"
 	"github.com/spf13/cobra"
 )
@@ -20,7 +19,7 @@ import (
 var makemigrationsCmd = &cobra.Command{
 	Use:   "makemigrations [migration_name]",
 	Short: "Generate new migration files",
-	Long:  "Compares the current models with the simulated schema and generates migration files. Use --empty to create an empty migration file.",
+	Long:  "Compares the current models with the simulated schema and generates migration files. Use --empty to create an empty migration file, and --empty --data for a data migration.",
 	Run: func(cmd *cobra.Command, args []string) {
 		configPath := "goosegorm.yml"
 		if !utils.FileExists(configPath) {
@@ -41,7 +40,16 @@ var makemigrationsCmd = &cobra.Command{
 
 		// Check for --empty flag
 		emptyFlag, _ := cmd.Flags().GetBool("empty")
+		dataFlag, _ := cmd.Flags().GetBool("data")
+		checkFlag, _ := cmd.Flags().GetBool("check")
+		dryRunFlag, _ := cmd.Flags().GetBool("dry-run")
 		if emptyFlag {
+			if checkFlag || dryRunFlag {
+				utils.PrintError("--check and --dry-run cannot be used with --empty")
+				os.Exit(1)
+			}
+			cfg = selectDatabase(cfg)
+
 			// Handle empty migration generation
 			var migrationName string
 			if len(args) > 0 {
@@ -49,7 +57,27 @@ var makemigrationsCmd = &cobra.Command{
 			}
 			// If no name provided, will use Migration{version} format
 
+			// Depend on the current leaves so the new migration runs after every existing one
+			leaves, err := migrationLeaves(cfg.MigrationsDir, cfg.PackageName)
+			if err != nil {
+				utils.PrintError("Failed to load migrations: %v", err)
+				os.Exit(1)
+			}
+
 			gen := generator.NewGenerator(cfg.MigrationsDir, cfg.PackageName)
+			gen.SetDependencies(leaves)
+
+			if dataFlag {
+				filePath, err := gen.GenerateDataMigration(migrationName)
+				if err != nil {
+					utils.PrintError("Failed to generate data migration: %v", err)
+					os.Exit(1)
+				}
+
+				utils.PrintSuccess("Generated data migration: %s", filepath.Base(filePath))
+				return
+			}
+
 			filePath, err := gen.GenerateEmptyMigration(migrationName)
 			if err != nil {
 				utils.PrintError("Failed to generate empty migration: %v", err)
@@ -59,88 +87,254 @@ var makemigrationsCmd = &cobra.Command{
 			utils.PrintSuccess("Generated empty migration: %s", filepath.Base(filePath))
 			return
 		}
+		if dataFlag {
+			utils.PrintError("--data can only be used with --empty")
+			os.Exit(1)
+		}
+
+		// Without --database, migrations are made for every configured database
+		names := cfg.DatabaseNames()
+		if databaseFlag != "" {
+			names = []string{databaseFlag}
+		}
+
+		needed := 0
+		for _, name := range names {
+			dbCfg, err := cfg.ForDatabase(name)
+			if err != nil {
+				utils.PrintError("%v", err)
+				os.Exit(1)
+			}
+			if len(names) > 1 {
+				utils.PrintInfo("Database %s:", name)
+			}
+			needed += makeDatabaseMigrations(dbCfg, checkFlag, dryRunFlag)
+		}
+		warnUnknownDatabases(cfg)
+
+		if checkFlag && needed > 0 {
+			utils.PrintError("Models have changes without a migration (%d migration(s) needed). Run 'goosegorm makemigrations' and commit the result", needed)
+			os.Exit(1)
+		}
+		if dryRunFlag && needed > 0 {
+			utils.PrintSuccess("Dry run: %d migration(s) would be generated; nothing was written", needed)
+		}
+	},
+}
+
+// makeDatabaseMigrations runs makemigrations for one database
+// With check or dryRun nothing is written; the number of migrations that would be generated is returned.
+func makeDatabaseMigrations(cfg *config.Config, checkFlag, dryRunFlag bool) int {
+	models, err := modelreflect.ParseModelsFromDir(cfg.ModelsDir, cfg.IgnoreModels)
+	if err != nil {
+		utils.PrintError("Failed to parse models: %v", err)
+		os.Exit(1)
+	}
+
+	// Filter managed models of this database
+	managed := managedModels(cfg, models)
+
+	utils.PrintInfo("Found %d managed models", len(managed))
 
-		// Parse models (only need to do this once)
-		models, err := modelreflect.ParseModelsFromDir(cfg.ModelsDir, cfg.IgnoreModels)
+	if !checkFlag && !dryRunFlag {
+		// A newly configured database has no migrations directory yet
+		if err := os.MkdirAll(cfg.MigrationsDir, 0755); err != nil {
+			utils.PrintError("Failed to create migrations directory: %v", err)
+			os.Exit(1)
+		}
+
+		planned, err := makeMigrations(cfg.MigrationsDir, cfg.PackageName, cfg.MigrationDialect(), managed)
+		for i, m := range planned {
+			utils.PrintInfo("Found %d changes (iteration %d)", len(m.Diffs), i+1)
+			utils.PrintSuccess("Generated migration: %s", filepath.Base(m.FilePath))
+		}
 		if err != nil {
-			utils.PrintError("Failed to parse models: %v", err)
+			utils.PrintError("Failed to make migrations: %v", err)
 			os.Exit(1)
 		}
 
-		// Filter managed models
-		var managedModels []modelreflect.ParsedModel
+		if len(planned) == 0 {
+			utils.PrintSuccess("No changes detected")
+		} else {
+			utils.PrintSuccess("No more changes detected after %d iteration(s)", len(planned))
+		}
+		return 0
+	}
+
+	// Run the loop on a copy of the migrations, so nothing is written
+	previewDir, err := os.MkdirTemp("", "goosegorm-makemigrations-")
+	if err != nil {
+		utils.PrintError("Failed to create temporary directory: %v", err)
+		os.Exit(1)
+	}
+	planned, err := previewMigrations(cfg.MigrationsDir, previewDir, cfg.PackageName, cfg.MigrationDialect(), managed)
+	os.RemoveAll(previewDir)
+	if err != nil {
+		utils.PrintError("Failed to plan migrations: %v", err)
+		os.Exit(1)
+	}
+
+	if len(planned) == 0 {
+		utils.PrintSuccess("No changes detected")
+		return 0
+	}
+
+	if dryRunFlag {
+		for _, m := range planned {
+			utils.PrintInfo("Would generate %s:", filepath.Base(m.FilePath))
+			for _, d := range m.Diffs {
+				fmt.Printf("  - %s\n", describeDiff(d))
+			}
+		}
+	}
+	return len(planned)
+}
+
+// warnUnknownDatabases warns about models annotated with a database that isn't configured,
+// as no database manages their tables
+func warnUnknownDatabases(cfg *config.Config) {
+	known := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, name := range cfg.DatabaseNames() {
+		known[name] = true
+		if dbCfg, err := cfg.ForDatabase(name); err == nil {
+			dirs[dbCfg.ModelsDir] = true
+		}
+	}
+
+	for dir := range dirs {
+		models, err := modelreflect.ParseModelsFromDir(dir, cfg.IgnoreModels)
+		if err != nil {
+			continue
+		}
 		for _, m := range models {
-			if m.Managed && !m.ShouldIgnore(cfg.IgnoreModels) {
-				managedModels = append(managedModels, m)
+			if m.Database != "" && !known[m.Database] {
+				utils.PrintWarning("Model %s is annotated with database %q, which is not configured in goosegorm.yml", m.Name, m.Database)
 			}
 		}
+	}
+}
 
-		utils.PrintInfo("Found %d managed models", len(managedModels))
+// plannedMigration is a migration generated by the makemigrations loop
+type plannedMigration struct {
+	FilePath string
+	Diffs    []diff.Diff
+}
 
-		// Initialize variables for loop
-		var registry *runner.Registry
-		var simulatedSchema *schema.SchemaBuilder
+// makeMigrations generates migrations in dir until the models match the simulated schema
+// The migrations are reloaded after each one is generated, so the next iteration builds on it.
+// Their real DB code is written for dialect (see Config.MigrationDialect).
+// Returns the migrations generated before any error.
+func makeMigrations(dir, packageName, dialect string, models []modelreflect.ParsedModel) ([]plannedMigration, error) {
+	var planned []plannedMigration
 
-		// Loop until no changes are found
-		iteration := 0
-		maxIterations := 100 // Safety limit to prevent infinite loops
+	maxIterations := 100 // Safety limit to prevent infinite loops
+	for iteration := 1; ; iteration++ {
+		if iteration > maxIterations {
+			return planned, fmt.Errorf("maximum iterations (%d) reached. Stopping to prevent infinite loop", maxIterations)
+		}
 
-		for {
-			iteration++
-			if iteration > maxIterations {
-				utils.PrintError("Maximum iterations (%d) reached. Stopping to prevent infinite loop.", maxIterations)
-				os.Exit(1)
-			}
+		// Reload migrations to include newly generated ones
+		registry, err := loadMigrationsFromDir(dir, packageName)
+		if err != nil {
+			return planned, err
+		}
 
-			// Reload migrations to include newly generated ones
-			registry, err = loadMigrationsFromDir(cfg.MigrationsDir, cfg.PackageName)
-			if err != nil {
-				utils.PrintError("Failed to load migrations: %v", err)
-				os.Exit(1)
-			}
+		// New migrations depend on the current leaves of the migration graph
+		leaves, err := registry.GetLeaves()
+		if err != nil {
+			return planned, fmt.Errorf("invalid migration dependencies: %w", err)
+		}
 
-			// Simulate schema from existing migrations
-			simRunner := runner.NewRunner(nil, registry, nil)
-			simulatedSchema, err = simRunner.SimulateSchema()
-			if err != nil {
-				utils.PrintError("Failed to simulate schema: %v", err)
-				os.Exit(1)
-			}
+		// Simulate schema from existing migrations
+		simulatedSchema, err := runner.NewRunner(nil, registry, nil).SimulateSchema()
+		if err != nil {
+			return planned, fmt.Errorf("failed to simulate schema: %w", err)
+		}
 
-			// Compare schema
-			diffs, err := diff.CompareSchema(simulatedSchema.Schema, managedModels)
-			if err != nil {
-				utils.PrintError("Failed to compare schema: %v", err)
-				os.Exit(1)
-			}
+		// Compare schema
+		diffs, err := diff.CompareSchema(simulatedSchema.Schema, models)
+		if err != nil {
+			return planned, fmt.Errorf("failed to compare schema: %w", err)
+		}
 
-			if len(diffs) == 0 {
-				if iteration == 1 {
-					utils.PrintSuccess("No changes detected")
-				} else {
-					utils.PrintSuccess("No more changes detected after %d iteration(s)", iteration-1)
-				}
-				return
-			}
+		if len(diffs) == 0 {
+			return planned, nil
+		}
 
-			utils.PrintInfo("Found %d changes (iteration %d)", len(diffs), iteration)
+		// Generate migration file
+		gen := generator.NewGenerator(dir, packageName)
+		gen.SetDependencies(leaves)
+		gen.SetDialect(dialect)
+		filePath, err := gen.GenerateMigration(generateMigrationName(diffs), diffs)
+		if err != nil {
+			return planned, fmt.Errorf("failed to generate migration: %w", err)
+		}
 
-			// Generate migration name from diffs
-			migrationName := generateMigrationName(diffs)
+		planned = append(planned, plannedMigration{FilePath: filePath, Diffs: diffs})
+	}
+}
 
-			// Generate migration file
-			gen := generator.NewGenerator(cfg.MigrationsDir, cfg.PackageName)
-			filePath, err := gen.GenerateMigration(migrationName, diffs)
+// previewMigrations runs the makemigrations loop on a copy of the migrations in previewDir
+func previewMigrations(dir, previewDir, packageName, dialect string, models []modelreflect.ParsedModel) ([]plannedMigration, error) {
+	if utils.FileExists(dir) {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 			if err != nil {
-				utils.PrintError("Failed to generate migration: %v", err)
-				os.Exit(1)
+				return err
+			}
+			// Subdirectories hold other databases' migrations
+			if info.IsDir() && path != dir {
+				return filepath.SkipDir
+			}
+			if info.IsDir() || !strings.HasSuffix(path, ".go") {
+				return nil
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			target := filepath.Join(previewDir, rel)
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
 			}
+			return os.WriteFile(target, content, 0644)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy migrations: %w", err)
+		}
+	}
 
-			utils.PrintSuccess("Generated migration: %s", filepath.Base(filePath))
+	return makeMigrations(previewDir, packageName, dialect, models)
+}
 
-			// Continue loop to check for more changes
+// describeDiff describes a schema change for --dry-run
+func describeDiff(d diff.Diff) string {
+	switch d.Type {
+	case "create_table":
+		return "create table " + d.TableName
+	case "drop_table":
+		return "drop table " + d.TableName
+	case "add_column":
+		return fmt.Sprintf("add column %s.%s (%s)", d.TableName, d.Column.Name, d.Column.Type)
+	case "drop_column":
+		return fmt.Sprintf("drop column %s.%s", d.TableName, d.Column.Name)
+	case "modify_column":
+		return fmt.Sprintf("alter column %s.%s (%s -> %s)", d.TableName, d.Column.Name, d.Column.OldType, d.Column.Type)
+	case "add_index":
+		kind := "index"
+		if d.Index.Unique {
+			kind = "unique index"
 		}
-	},
+		return fmt.Sprintf("add %s %s on %s (%s)", kind, d.Index.Name, d.TableName, strings.Join(d.Index.Fields, ", "))
+	case "drop_index":
+		return fmt.Sprintf("drop index %s on %s", d.Index.Name, d.TableName)
+	default:
+		return d.Type + " " + d.TableName
+	}
 }
 
 func generateMigrationName(diffs []diff.Diff) string {
@@ -181,6 +375,19 @@ func loadMigrationsFromDir(dir string, packageName string) (*runner.Registry, er
 	return loader.LoadMigrationsFromAST(dir, packageName)
 }
 
+// migrationLeaves returns the leaves of the migration graph in dir, or none if dir doesn't exist yet
+func migrationLeaves(dir string, packageName string) ([]string, error) {
+	if !utils.FileExists(dir) {
+		return nil, nil
+	}
+
+	registry, err := loadMigrationsFromDir(dir, packageName)
+	if err != nil {
+		return nil, err
+	}
+	return registry.GetLeaves()
+}
+
 // findModulePath finds the module path from go.mod
 func findModulePath(dir string) (string, error) {
 	goModPath := filepath.Join(dir, "go.mod")
@@ -212,5 +419,8 @@ func findModulePath(dir string) (string, error) {
 
 func init() {
 	makemigrationsCmd.Flags().Bool("empty", false, "Create an empty migration file")
+	makemigrationsCmd.Flags().Bool("data", false, "With --empty, create a data migration using goosegorm.RunGo")
+	makemigrationsCmd.Flags().Bool("check", false, "Exit with status 1 if the models have changes without a migration, without writing anything")
+	makemigrationsCmd.Flags().Bool("dry-run", false, "Print the migrations that would be generated and their operations, without writing anything")
 	rootCmd.AddCommand(makemigrationsCmd)
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/pankajredekar/goosegorm/internal/config"
 	"github.com/pankajredekar/goosegorm/internal/diff"
+	"github.com/pankajredekar/goosegorm/internal/modelreflect"
 	"github.com/pankajredekar/goosegorm/internal/utils"
 )
 
@@ -223,3 +224,114 @@ func TestMakemigrationsLoop_IterationLogic(t *testing.T) {
 		t.Errorf("Expected 4 iterations, got %d", iteration)
 	}
 }
+
+func TestPreviewMigrationsWritesNothing(t *testing.T) {
+	tmpDir := t.TempDir()
+	modelsDir := filepath.Join(tmpDir, "models")
+	migrationsDir := filepath.Join(tmpDir, "migrations")
+	if err := os.MkdirAll(modelsDir, 0755); err != nil {
+		t.Fatalf("Failed to create models directory: %v", err)
+	}
+
+	modelContent := `package models
+
+type User struct {
+	ID    uint   ` + "`gorm:\"primaryKey\"`" + `
+	Email string ` + "`gorm:\"index:idx_email\"`" + `
+}
+`
+	if err := os.WriteFile(filepath.Join(modelsDir, "user.go"), []byte(modelContent), 0644); err != nil {
+		t.Fatalf("Failed to write model file: %v", err)
+	}
+	models, err := modelreflect.ParseModelsFromDir(modelsDir, nil)
+	if err != nil {
+		t.Fatalf("ParseModelsFromDir failed: %v", err)
+	}
+
+	planned, err := previewMigrations(migrationsDir, t.TempDir(), "migrations", "sqlite", models)
+	if err != nil {
+		t.Fatalf("previewMigrations failed: %v", err)
+	}
+	if len(planned) == 0 {
+		t.Fatal("Expected migrations to be planned for a new model")
+	}
+	if planned[0].Diffs[0].Type != "create_table" {
+		t.Errorf("Expected the first migration to create the table, got %s", planned[0].Diffs[0].Type)
+	}
+	if utils.FileExists(migrationsDir) {
+		t.Error("Preview should not write to the migrations directory")
+	}
+
+	if err := os.MkdirAll(migrationsDir, 0755); err != nil {
+		t.Fatalf("Failed to create migrations directory: %v", err)
+	}
+	generated, err := makeMigrations(migrationsDir, "migrations", "sqlite", models)
+	if err != nil {
+		t.Fatalf("makeMigrations failed: %v", err)
+	}
+	if len(generated) != len(planned) {
+		t.Errorf("Expected %d migrations generated, as planned, got %d", len(planned), len(generated))
+	}
+
+	// Once the migrations exist, there is nothing left to plan
+	planned, err = previewMigrations(migrationsDir, t.TempDir(), "migrations", "sqlite", models)
+	if err != nil {
+		t.Fatalf("previewMigrations failed: %v", err)
+	}
+	if len(planned) != 0 {
+		t.Errorf("Expected no planned migrations, got %d", len(planned))
+	}
+}
+
+func TestDescribeDiff(t *testing.T) {
+	d := diff.Diff{
+		Type:      "add_index",
+		TableName: "user",
+		Index:     &diff.IndexDiff{Name: "idx_name_email", Unique: true, Fields: []string{"name", "email"}},
+	}
+	expected := "add unique index idx_name_email on user (name, email)"
+	if got := describeDiff(d); got != expected {
+		t.Errorf("describeDiff() = %q, expected %q", got, expected)
+	}
+}
+
+func TestManagedModelsByDatabase(t *testing.T) {
+	cfg := &config.Config{
+		DatabaseURL:   "sqlite://./app.db",
+		ModelsDir:     "./models",
+		MigrationsDir: "./migrations",
+		PackageName:   "migrations",
+		Databases: map[string]config.DatabaseConfig{
+			"analytics": {DatabaseURL: "sqlite://./analytics.db", MigrationsDir: "./migrations/analytics"},
+		},
+	}
+	models := []modelreflect.ParsedModel{
+		{Name: "User", Managed: true},
+		{Name: "Event", Managed: true, Database: "analytics"},
+		{Name: "Legacy", Managed: false, Database: "analytics"},
+	}
+
+	names := func(models []modelreflect.ParsedModel) []string {
+		var names []string
+		for _, m := range models {
+			names = append(names, m.Name)
+		}
+		return names
+	}
+
+	defaultCfg, err := cfg.ForDatabase("default")
+	if err != nil {
+		t.Fatalf("ForDatabase failed: %v", err)
+	}
+	if got := names(managedModels(defaultCfg, models)); len(got) != 1 || got[0] != "User" {
+		t.Errorf("Expected the default database to manage User, got %v", got)
+	}
+
+	analyticsCfg, err := cfg.ForDatabase("analytics")
+	if err != nil {
+		t.Fatalf("ForDatabase failed: %v", err)
+	}
+	if got := names(managedModels(analyticsCfg, models)); len(got) != 1 || got[0] != "Event" {
+		t.Errorf("Expected the analytics database to manage Event, got %v", got)
+	}
+}
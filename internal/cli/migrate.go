@@ -4,9 +4,14 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"syscall"
 
 	"github.com/pankajredekar/goosegorm/internal/config"
+	"github.com/pankajredekar/goosegorm/internal/generator"
+	"github.com/pankajredekar/goosegorm/internal/loader"
 	"github.com/pankajredekar/goosegorm/internal/utils"
 	"github.com/spf13/cobra"
 )
@@ -16,298 +21,241 @@ var migrateCmd = &cobra.Command{
 	Short: "Apply pending migrations",
 	Long:  "Applies all migrations that haven't been applied yet using compiled migrator",
 	Run: func(cmd *cobra.Command, args []string) {
-		configPath := "goosegorm.yml"
-		if !utils.FileExists(configPath) {
-			utils.PrintError("goosegorm.yml not found. Run 'goosegorm init' first")
-			os.Exit(1)
+		migratorArgs := []string{"migrate"}
+		if to, _ := cmd.Flags().GetString("to"); to != "" {
+			migratorArgs = append(migratorArgs, "--to", to)
 		}
-
-		cfg, err := config.LoadConfig(configPath)
-		if err != nil {
-			utils.PrintError("Failed to load config: %v", err)
-			os.Exit(1)
-		}
-
-		if err := cfg.Validate(); err != nil {
-			utils.PrintError("Invalid config: %v", err)
-			os.Exit(1)
+		if ignore, _ := cmd.Flags().GetBool("ignore-checksums"); ignore {
+			migratorArgs = append(migratorArgs, "--ignore-checksums")
 		}
-
-		// Get the directory where goosegorm.yml is located
-		configDir, err := os.Getwd()
-		if err != nil {
-			utils.PrintError("Failed to get current directory: %v", err)
-			os.Exit(1)
-		}
-
-		// Check if migrations directory exists and has migration files
-		migrationsAbsPath, err := filepath.Abs(cfg.MigrationsDir)
-		if err != nil {
-			utils.PrintError("Failed to get absolute path for migrations: %v", err)
-			os.Exit(1)
+		if allow, _ := cmd.Flags().GetBool("allow-out-of-order"); allow {
+			migratorArgs = append(migratorArgs, "--allow-out-of-order")
 		}
-
-		// Check if migrations directory exists
-		if !utils.FileExists(migrationsAbsPath) {
-			utils.PrintError("Migrations directory does not exist: %s", migrationsAbsPath)
-			utils.PrintInfo("Please run 'goosegorm makemigrations' first to create initial migrations")
-			os.Exit(1)
+		if force, _ := cmd.Flags().GetBool("force"); force {
+			migratorArgs = append(migratorArgs, "--force")
 		}
-
-		// Check if there are any .go files in the migrations directory
-		hasMigrations, err := utils.HasMigrationFiles(migrationsAbsPath)
-		if err != nil {
-			utils.PrintError("Failed to check migrations directory: %v", err)
-			os.Exit(1)
+		if fake, _ := cmd.Flags().GetString("fake"); fake != "" {
+			migratorArgs = append(migratorArgs, "--fake", fake)
 		}
-
-		if !hasMigrations {
-			utils.PrintError("No migration files found in: %s", migrationsAbsPath)
-			utils.PrintInfo("Please run 'goosegorm makemigrations' first to create initial migrations")
-			os.Exit(1)
+		if fakeInitial, _ := cmd.Flags().GetBool("fake-initial"); fakeInitial {
+			migratorArgs = append(migratorArgs, "--fake-initial")
 		}
-
-		// Find module path
-		modulePath, err := findModulePath(configDir)
-		if err != nil {
-			utils.PrintError("Failed to find module path: %v", err)
-			os.Exit(1)
+		migratorArgs = append(migratorArgs, yesArgs(cmd)...)
+		migratorArgs = append(migratorArgs, tenantArgs(cmd)...)
+		if continueOnError, _ := cmd.Flags().GetBool("continue-on-error"); continueOnError {
+			migratorArgs = append(migratorArgs, "--continue-on-error")
 		}
+		migratorArgs = append(migratorArgs, lockArgs(cmd)...)
 
-		// Calculate the relative path from project root to migrations directory
-		// migrationsAbsPath was already calculated above
-		relPath, err := filepath.Rel(configDir, migrationsAbsPath)
-		if err != nil {
-			utils.PrintError("Failed to calculate relative path: %v", err)
-			os.Exit(1)
-		}
-		// Convert to forward slashes for import path (Go uses forward slashes)
-		relPath = filepath.ToSlash(relPath)
-		// Build the import path: modulePath/relativePath
-		migrationsImportPath := fmt.Sprintf("%s/%s", modulePath, relPath)
+		runTempMigrator(migratorArgs)
+	},
+}
 
-		// Create temporary migrator package in the same directory as goosegorm.yml
-		tempMigratorDir := filepath.Join(configDir, ".goosegorm_migrator")
-		defer os.RemoveAll(tempMigratorDir) // Clean up after migration
+// lockArgs forwards the lock flags of a command to the migrator
+func lockArgs(cmd *cobra.Command) []string {
+	var args []string
+	if noLock, _ := cmd.Flags().GetBool("no-lock"); noLock {
+		args = append(args, "--no-lock")
+	}
+	if lockTimeout, _ := cmd.Flags().GetString("lock-timeout"); lockTimeout != "" {
+		args = append(args, "--lock-timeout", lockTimeout)
+	}
+	return args
+}
 
-		if err := os.MkdirAll(tempMigratorDir, 0755); err != nil {
-			utils.PrintError("Failed to create temporary migrator directory: %v", err)
-			os.Exit(1)
-		}
+// yesArgs forwards --yes to the migrator
+func yesArgs(cmd *cobra.Command) []string {
+	if yes, _ := cmd.Flags().GetBool("yes"); yes {
+		return []string{"--yes"}
+	}
+	return nil
+}
 
-		// Create main.go for temporary migrator
-		mainFile := filepath.Join(tempMigratorDir, "main.go")
-		mainContent := fmt.Sprintf(`package main
+// addLockFlags registers the lock flags on a command
+func addLockFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("no-lock", false, "Do not acquire the migration lock")
+	cmd.Flags().String("lock-timeout", "", "How long to wait for the migration lock (e.g. 30s, overrides lock_timeout)")
+}
 
-import (
-	"fmt"
-	"log"
-	"os"
-	"strings"
+// tenantArgs forwards the tenant flags of a command to the migrator
+func tenantArgs(cmd *cobra.Command) []string {
+	var args []string
+	if tenants, _ := cmd.Flags().GetBool("tenants"); tenants {
+		args = append(args, "--tenants")
+	}
+	if concurrency, _ := cmd.Flags().GetInt("concurrency"); concurrency > 0 {
+		args = append(args, "--concurrency", strconv.Itoa(concurrency))
+	}
+	return args
+}
 
-	"github.com/pankajredekar/goosegorm"
-	"gorm.io/driver/postgres"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
-	
-	// Import migrations to trigger their init() functions
-	_ "%s"
-)
+// addTenantFlags registers the tenant flags on a command
+func addTenantFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("tenants", false, "Run against every tenant schema listed under tenants in goosegorm.yml (PostgreSQL)")
+	cmd.Flags().Int("concurrency", 0, "With --tenants, how many tenant schemas to process at once (overrides tenants.concurrency)")
+}
 
-func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: goosegorm <command>")
-		fmt.Println("Commands: migrate, rollback, show")
-		os.Exit(1)
+// migrationTables returns the tables each migration creates, for migrate --fake-initial
+// Failing to simulate the migrations only disables --fake-initial, so it is reported as a warning.
+func migrationTables(migrationsDir, packageName string) map[string][]string {
+	tables, err := loader.ComputeCreatedTables(migrationsDir, packageName)
+	if err != nil {
+		utils.PrintWarning("Failed to simulate migrations, --fake-initial will not fake any: %v", err)
+		return map[string][]string{}
 	}
+	return tables
+}
 
-	command := os.Args[1]
+// runTempMigrator builds a temporary migrator for the project and runs it with the given arguments
+// The migrator is compiled against the project's go.mod so migrations run as real compiled code
+func runTempMigrator(migratorArgs []string) {
 	configPath := "goosegorm.yml"
-	
-	// Simple config loading (inline to avoid internal package dependency)
-	type Config struct {
-		DatabaseURL    string
-		MigrationTable string
+	if !utils.FileExists(configPath) {
+		utils.PrintError("goosegorm.yml not found. Run 'goosegorm init' first")
+		os.Exit(1)
 	}
-	
-	configData, err := os.ReadFile(configPath)
+
+	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
-		log.Fatalf("goosegorm.yml not found. Run 'goosegorm init' first")
-	}
-	
-	// Simple YAML parsing for database_url and migration_table
-	cfg := Config{
-		DatabaseURL:    "sqlite://:memory:",
-		MigrationTable: "_goosegorm_migrations",
+		utils.PrintError("Failed to load config: %v", err)
+		os.Exit(1)
 	}
-	lines := strings.Split(string(configData), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "database_url:") {
-			cfg.DatabaseURL = strings.TrimSpace(strings.TrimPrefix(line, "database_url:"))
-		} else if strings.HasPrefix(line, "migration_table:") {
-			cfg.MigrationTable = strings.TrimSpace(strings.TrimPrefix(line, "migration_table:"))
-		}
+
+	if err := cfg.Validate(); err != nil {
+		utils.PrintError("Invalid config: %v", err)
+		os.Exit(1)
 	}
+	cfg = selectDatabase(cfg)
 
-	// Connect to database
-	db, err := connectDB(cfg.DatabaseURL)
+	// Get the directory where goosegorm.yml is located
+	configDir, err := os.Getwd()
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %%v", err)
+		utils.PrintError("Failed to get current directory: %v", err)
+		os.Exit(1)
 	}
 
-	// Initialize versioner using public API
-	ver := goosegorm.NewVersioner(db, cfg.MigrationTable)
-	if err := ver.Initialize(); err != nil {
-		log.Fatalf("Failed to initialize version table: %%v", err)
+	// Check if migrations directory exists and has migration files
+	migrationsAbsPath, err := filepath.Abs(cfg.MigrationsDir)
+	if err != nil {
+		utils.PrintError("Failed to get absolute path for migrations: %v", err)
+		os.Exit(1)
 	}
 
-	// Get the global registry (migrations register themselves via init())
-	registry := goosegorm.GetGlobalRegistry()
-
-	// Create runner using public API
-	run := goosegorm.NewRunner(db, registry, ver)
-
-	switch command {
-	case "migrate":
-		// Get pending migrations
-		pending, err := run.GetPendingMigrations()
-		if err != nil {
-			log.Fatalf("Failed to get pending migrations: %%v", err)
-		}
-
-		if len(pending) == 0 {
-			fmt.Println("No pending migrations")
-			return
-		}
-
-		fmt.Printf("Applying %%d migration(s)...\n", len(pending))
-
-		// Apply migrations
-		if err := run.Migrate(); err != nil {
-			log.Fatalf("Failed to apply migrations: %%v", err)
-		}
-
-		fmt.Printf("Applied %%d migration(s)\n", len(pending))
-
-	case "rollback":
-		n := 1
-		if len(os.Args) > 2 {
-			if _, err := fmt.Sscanf(os.Args[2], "%%d", &n); err != nil {
-				log.Fatalf("Invalid number: %%v", err)
-			}
-		}
-
-		// Get applied count
-		appliedCount, err := ver.GetAppliedCount()
-		if err != nil {
-			log.Fatalf("Failed to get applied count: %%v", err)
-		}
-
-		if appliedCount == 0 {
-			fmt.Println("No migrations to rollback")
-			return
-		}
-
-		if int64(n) > appliedCount {
-			n = int(appliedCount)
-		}
-
-		fmt.Printf("Rolling back %%d migration(s)...\n", n)
+	// Check if migrations directory exists
+	if !utils.FileExists(migrationsAbsPath) {
+		utils.PrintError("Migrations directory does not exist: %s", migrationsAbsPath)
+		utils.PrintInfo("Please run 'goosegorm makemigrations' first to create initial migrations")
+		os.Exit(1)
+	}
 
-		// Rollback
-		if err := run.Rollback(n); err != nil {
-			log.Fatalf("Failed to rollback: %%v", err)
-		}
+	// Check if there are any .go files in the migrations directory
+	hasMigrations, err := utils.HasMigrationFiles(migrationsAbsPath)
+	if err != nil {
+		utils.PrintError("Failed to check migrations directory: %v", err)
+		os.Exit(1)
+	}
 
-		fmt.Printf("Rolled back %%d migration(s)\n", n)
+	if !hasMigrations {
+		utils.PrintError("No migration files found in: %s", migrationsAbsPath)
+		utils.PrintInfo("Please run 'goosegorm makemigrations' first to create initial migrations")
+		os.Exit(1)
+	}
 
-	case "show":
-		// Get applied migrations
-		applied, err := run.GetAppliedMigrations()
-		if err != nil {
-			log.Fatalf("Failed to get applied migrations: %%v", err)
-		}
+	// Find module path
+	modulePath, err := findModulePath(configDir)
+	if err != nil {
+		utils.PrintError("Failed to find module path: %v", err)
+		os.Exit(1)
+	}
 
-		// Get pending migrations
-		pending, err := run.GetPendingMigrations()
-		if err != nil {
-			log.Fatalf("Failed to get pending migrations: %%v", err)
-		}
+	// Calculate the relative path from project root to migrations directory
+	// migrationsAbsPath was already calculated above
+	relPath, err := filepath.Rel(configDir, migrationsAbsPath)
+	if err != nil {
+		utils.PrintError("Failed to calculate relative path: %v", err)
+		os.Exit(1)
+	}
+	// Convert to forward slashes for import path (Go uses forward slashes)
+	relPath = filepath.ToSlash(relPath)
+	// Build the import path: modulePath/relativePath
+	migrationsImportPath := fmt.Sprintf("%s/%s", modulePath, relPath)
 
-		fmt.Println("\n" + strings.Repeat("=", 60))
-		fmt.Println("Migration Status")
-		fmt.Println(strings.Repeat("=", 60))
+	// Create temporary migrator package in the same directory as goosegorm.yml
+	tempMigratorDir := filepath.Join(configDir, ".goosegorm_migrator")
+	defer os.RemoveAll(tempMigratorDir) // Clean up after migration
 
-		if len(applied) > 0 {
-			fmt.Println("\n✓ Applied Migrations:")
-			for _, m := range applied {
-				fmt.Printf("  %%s - %%s\n", m.Version(), m.Name())
-			}
-		} else {
-			fmt.Println("\n✓ Applied Migrations: (none)")
-		}
+	if err := os.MkdirAll(tempMigratorDir, 0755); err != nil {
+		utils.PrintError("Failed to create temporary migrator directory: %v", err)
+		os.Exit(1)
+	}
 
-		if len(pending) > 0 {
-			fmt.Println("\n○ Pending Migrations:")
-			for _, m := range pending {
-				fmt.Printf("  %%s - %%s\n", m.Version(), m.Name())
-			}
-		} else {
-			fmt.Println("\n○ Pending Migrations: (none)")
-		}
+	// Embed checksums of the migration sources so applied migrations can be verified
+	checksums, err := loader.ComputeChecksums(migrationsAbsPath)
+	if err != nil {
+		utils.PrintError("Failed to compute migration checksums: %v", err)
+		os.Exit(1)
+	}
 
-		fmt.Println()
+	// Create main.go for temporary migrator
+	mainFile := filepath.Join(tempMigratorDir, "main.go")
+	mainContent := generator.MigratorMainContent(migrationsImportPath, checksums, migrationTables(migrationsAbsPath, cfg.PackageName), "", cfg.Name, cfg.UsesDialect("mysql"))
 
-	default:
-		fmt.Printf("Unknown command: %%s\n", command)
-		fmt.Println("Commands: migrate, rollback, show")
+	if err := os.WriteFile(mainFile, []byte(mainContent), 0644); err != nil {
+		utils.PrintError("Failed to create temporary migrator: %v", err)
 		os.Exit(1)
 	}
-}
 
-func connectDB(databaseURL string) (*gorm.DB, error) {
-	if strings.Contains(databaseURL, "postgres://") || strings.Contains(databaseURL, "postgresql://") {
-		return gorm.Open(postgres.Open(databaseURL), &gorm.Config{})
-	} else if strings.Contains(databaseURL, "sqlite://") {
-		path := strings.TrimPrefix(databaseURL, "sqlite://")
-		return gorm.Open(sqlite.Open(path), &gorm.Config{})
+	// Build the migrator using the project's go.mod
+	// Since the migrator is in a subdirectory of the project, we can use the project's go.mod
+	utils.PrintInfo("Building migrator...")
+	binaryPath := filepath.Join(tempMigratorDir, "goosegorm")
+	// Build from project root to use project's go.mod
+	buildCmd := exec.Command("go", "build", "-o", binaryPath, mainFile)
+	buildCmd.Dir = configDir // Build from project root to use project's go.mod
+	buildCmd.Env = os.Environ()
+	if output, err := buildCmd.CombinedOutput(); err != nil {
+		utils.PrintError("Failed to build migrator: %v\nOutput: %s", err, string(output))
+		os.Exit(1)
 	}
-	return nil, fmt.Errorf("unsupported database URL: %%s", databaseURL)
-}
-`, migrationsImportPath)
 
-		if err := os.WriteFile(mainFile, []byte(mainContent), 0644); err != nil {
-			utils.PrintError("Failed to create temporary migrator: %v", err)
-			os.Exit(1)
-		}
+	// Run the migrator
+	utils.PrintInfo("Running migrator...")
+	runCmd := exec.Command(binaryPath, migratorArgs...)
+	runCmd.Dir = configDir  // Run from configDir so it can find goosegorm.yml
+	runCmd.Stdin = os.Stdin // reset asks for confirmation
+	runCmd.Stdout = os.Stdout
+	runCmd.Stderr = os.Stderr
+	if err := runCmd.Start(); err != nil {
+		utils.PrintError("Failed to start migrator: %v", err)
+		os.Exit(1)
+	}
 
-		// Build the migrator using the project's go.mod
-		// Since the migrator is in a subdirectory of the project, we can use the project's go.mod
-		utils.PrintInfo("Building migrator...")
-		binaryPath := filepath.Join(tempMigratorDir, "goosegorm")
-		// Build from project root to use project's go.mod
-		buildCmd := exec.Command("go", "build", "-o", binaryPath, mainFile)
-		buildCmd.Dir = configDir // Build from project root to use project's go.mod
-		buildCmd.Env = os.Environ()
-		if output, err := buildCmd.CombinedOutput(); err != nil {
-			utils.PrintError("Failed to build migrator: %v\nOutput: %s", err, string(output))
-			os.Exit(1)
+	// Forward SIGINT/SIGTERM so the migrator can stop between migrations instead of being killed
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		for sig := range signals {
+			runCmd.Process.Signal(sig)
 		}
+	}()
 
-		// Run the migrator
-		utils.PrintInfo("Running migrator...")
-		runCmd := exec.Command(binaryPath, "migrate")
-		runCmd.Dir = configDir // Run from configDir so it can find goosegorm.yml
-		runCmd.Stdout = os.Stdout
-		runCmd.Stderr = os.Stderr
-		if err := runCmd.Run(); err != nil {
-			utils.PrintError("Migration failed: %v", err)
-			os.Exit(1)
-		}
-	},
+	if err := runCmd.Wait(); err != nil {
+		utils.PrintError("Migration failed: %v", err)
+		os.Exit(1)
+	}
 }
 
 func init() {
+	migrateCmd.Flags().String("to", "", "Migrate forwards or backwards to this version (\"zero\" unapplies everything)")
+	migrateCmd.Flags().Bool("allow-out-of-order", false, "Apply pending migrations that are older than the latest applied migration")
+	migrateCmd.Flags().String("fake", "", "Record this migration as applied without running it")
+	migrateCmd.Flags().Bool("fake-initial", false, "Record create-table migrations as applied if all their tables already exist")
+	migrateCmd.Flags().Bool("force", false, "With --to, skip irreversible migrations that would be rolled back, marking them as unapplied")
+	migrateCmd.Flags().Bool("ignore-checksums", false, "Migrate even if applied migrations were edited or deleted")
+	migrateCmd.Flags().Bool("yes", false, "With --to, do not ask for confirmation before rolling back in a protected environment")
+	migrateCmd.Flags().Bool("continue-on-error", false, "With --tenants, keep migrating the other tenant schemas after one fails")
+	addTenantFlags(migrateCmd)
+	addLockFlags(migrateCmd)
 	rootCmd.AddCommand(migrateCmd)
 }
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/pankajredekar/goosegorm/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var redoCmd = &cobra.Command{
+	Use:   "redo [n]",
+	Short: "Roll back and re-apply migrations",
+	Long:  "Rolls back the last N applied migrations (default: 1) and applies them again, for iterating on a migration during development. Asks for confirmation in a protected environment unless --yes is given",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		migratorArgs := []string{"redo"}
+		if len(args) > 0 {
+			if _, err := strconv.Atoi(args[0]); err != nil {
+				utils.PrintError("Invalid number: %v", err)
+				os.Exit(1)
+			}
+			migratorArgs = append(migratorArgs, args[0])
+		}
+		migratorArgs = append(migratorArgs, yesArgs(cmd)...)
+		migratorArgs = append(migratorArgs, lockArgs(cmd)...)
+
+		runTempMigrator(migratorArgs)
+	},
+}
+
+var resetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Roll back every migration and apply them all again",
+	Long:  "Rolls back every applied migration and applies all migrations again, losing the data in their tables. Asks for confirmation unless --yes is given",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		migratorArgs := []string{"reset"}
+		migratorArgs = append(migratorArgs, yesArgs(cmd)...)
+		migratorArgs = append(migratorArgs, lockArgs(cmd)...)
+
+		runTempMigrator(migratorArgs)
+	},
+}
+
+func init() {
+	redoCmd.Flags().Bool("yes", false, "Do not ask for confirmation in a protected environment")
+	addLockFlags(redoCmd)
+	rootCmd.AddCommand(redoCmd)
+
+	resetCmd.Flags().Bool("yes", false, "Do not ask for confirmation")
+	addLockFlags(resetCmd)
+	rootCmd.AddCommand(resetCmd)
+}
@@ -4,97 +4,46 @@ import (
 	"os"
 	"strconv"
 
-	"github.com/pankajredekar/goosegorm/internal/config"
-	"github.com/pankajredekar/goosegorm/internal/runner"
 	"github.com/pankajredekar/goosegorm/internal/utils"
-	"github.com/pankajredekar/goosegorm/internal/versioner"
 	"github.com/spf13/cobra"
 )
 
 var rollbackCmd = &cobra.Command{
 	Use:   "rollback [n]",
 	Short: "Rollback migrations",
-	Long:  "Rolls back the last N migrations (default: 1)",
+	Long:  "Rolls back the last N migrations (default: 1), or every migration after --to <version> using compiled migrator. Asks for confirmation in a protected environment unless --yes is given",
 	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		configPath := "goosegorm.yml"
-		if !utils.FileExists(configPath) {
-			utils.PrintError("goosegorm.yml not found. Run 'goosegorm init' first")
-			os.Exit(1)
-		}
-
-		cfg, err := config.LoadConfig(configPath)
-		if err != nil {
-			utils.PrintError("Failed to load config: %v", err)
-			os.Exit(1)
-		}
+		migratorArgs := []string{"rollback"}
 
-		if err := cfg.Validate(); err != nil {
-			utils.PrintError("Invalid config: %v", err)
-			os.Exit(1)
-		}
-
-		n := 1
-		if len(args) > 0 {
-			n, err = strconv.Atoi(args[0])
-			if err != nil {
+		to, _ := cmd.Flags().GetString("to")
+		if to != "" {
+			if len(args) > 0 {
+				utils.PrintError("Cannot combine a rollback count with --to")
+				os.Exit(1)
+			}
+			migratorArgs = append(migratorArgs, "--to", to)
+		} else if len(args) > 0 {
+			if _, err := strconv.Atoi(args[0]); err != nil {
 				utils.PrintError("Invalid number: %v", err)
 				os.Exit(1)
 			}
+			migratorArgs = append(migratorArgs, args[0])
 		}
-
-		// Connect to database
-		db, err := connectDB(cfg.DatabaseURL)
-		if err != nil {
-			utils.PrintError("Failed to connect to database: %v", err)
-			os.Exit(1)
-		}
-
-		// Initialize versioner
-		ver := versioner.NewVersioner(db, cfg.MigrationTable)
-		if err := ver.Initialize(); err != nil {
-			utils.PrintError("Failed to initialize version table: %v", err)
-			os.Exit(1)
-		}
-
-		// Load migrations - use compiled execution for real DB operations
-		registry, err := loadMigrations(cfg.MigrationsDir, true)
-		if err != nil {
-			utils.PrintError("Failed to load migrations: %v", err)
-			os.Exit(1)
-		}
-
-		// Create runner
-		run := runner.NewRunner(db, registry, ver)
-
-		// Get applied count
-		appliedCount, err := ver.GetAppliedCount()
-		if err != nil {
-			utils.PrintError("Failed to get applied count: %v", err)
-			os.Exit(1)
-		}
-
-		if appliedCount == 0 {
-			utils.PrintWarning("No migrations to rollback")
-			return
-		}
-
-		if int64(n) > appliedCount {
-			n = int(appliedCount)
-		}
-
-		utils.PrintInfo("Rolling back %d migration(s)...", n)
-
-		// Rollback
-		if err := run.Rollback(n); err != nil {
-			utils.PrintError("Failed to rollback: %v", err)
-			os.Exit(1)
+		if force, _ := cmd.Flags().GetBool("force"); force {
+			migratorArgs = append(migratorArgs, "--force")
 		}
+		migratorArgs = append(migratorArgs, yesArgs(cmd)...)
+		migratorArgs = append(migratorArgs, lockArgs(cmd)...)
 
-		utils.PrintSuccess("Rolled back %d migration(s)", n)
+		runTempMigrator(migratorArgs)
 	},
 }
 
 func init() {
+	rollbackCmd.Flags().String("to", "", "Roll back every migration applied after this version (\"zero\" unapplies everything)")
+	rollbackCmd.Flags().Bool("force", false, "Skip irreversible migrations instead of refusing, marking them as unapplied")
+	rollbackCmd.Flags().Bool("yes", false, "Do not ask for confirmation in a protected environment")
+	addLockFlags(rollbackCmd)
 	rootCmd.AddCommand(rollbackCmd)
 }
@@ -2,8 +2,10 @@ package cli
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/pankajredekar/goosegorm"
+	"github.com/pankajredekar/goosegorm/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -12,6 +14,12 @@ var rootCmd = &cobra.Command{
 	Short:   "Django-style migration framework for GORM",
 	Long:    "GooseGORM is a Go-based migration framework for GORM with in-memory schema simulation",
 	Version: goosegorm.GetVersion(),
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// The migrator reads the environment from GOOSEGORM_ENV too
+		if envFlag != "" {
+			os.Setenv(config.EnvVar, envFlag)
+		}
+	},
 }
 
 var versionCmd = &cobra.Command{
@@ -23,7 +31,15 @@ var versionCmd = &cobra.Command{
 	},
 }
 
+// envFlag selects the environment whose overrides in goosegorm.yml apply
+var envFlag string
+
+// databaseFlag selects one of the databases configured in goosegorm.yml
+var databaseFlag string
+
 func init() {
+	rootCmd.PersistentFlags().StringVar(&envFlag, "env", "", "Environment in goosegorm.yml whose overrides apply (default: $GOOSEGORM_ENV)")
+	rootCmd.PersistentFlags().StringVar(&databaseFlag, "database", "", "Database to use when goosegorm.yml configures several (default: the default database)")
 	rootCmd.AddCommand(versionCmd)
 }
 
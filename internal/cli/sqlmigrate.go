@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var sqlmigrateCmd = &cobra.Command{
+	Use:   "sqlmigrate <version>",
+	Short: "Print the SQL a migration will execute",
+	Long: "Runs a migration's Up (or Down with --down) against a dry-run session of the configured database and prints the SQL statements it would execute, without running them.\n\n" +
+		"GORM's Migrator still inspects the configured database to decide what to emit, so the SQL is what the migration would execute against it now: " +
+		"AutoMigrate prints nothing for tables that already exist as declared, for example once the migration is applied.",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		migratorArgs := []string{"sqlmigrate", args[0]}
+		if down, _ := cmd.Flags().GetBool("down"); down {
+			migratorArgs = append(migratorArgs, "--down")
+		}
+
+		runTempMigrator(migratorArgs)
+	},
+}
+
+func init() {
+	sqlmigrateCmd.Flags().Bool("down", false, "Print the SQL of the Down method instead of Up")
+	rootCmd.AddCommand(sqlmigrateCmd)
+}
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pankajredekar/goosegorm/internal/config"
+	"github.com/pankajredekar/goosegorm/internal/diff"
+	"github.com/pankajredekar/goosegorm/internal/generator"
+	"github.com/pankajredekar/goosegorm/internal/modelreflect"
+	"github.com/pankajredekar/goosegorm/internal/runner"
+	"github.com/pankajredekar/goosegorm/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var squashmigrationsCmd = &cobra.Command{
+	Use:   "squashmigrations <from> <to>",
+	Short: "Squash a range of migrations into one",
+	Long:  "Simulates the migrations from <from> to <to> and generates a single migration with the same schema changes that replaces them",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath := "goosegorm.yml"
+		if !utils.FileExists(configPath) {
+			utils.PrintError("goosegorm.yml not found. Run 'goosegorm init' first")
+			os.Exit(1)
+		}
+
+		cfg, err := config.LoadConfig(configPath)
+		if err != nil {
+			utils.PrintError("Failed to load config: %v", err)
+			os.Exit(1)
+		}
+
+		if err := cfg.Validate(); err != nil {
+			utils.PrintError("Invalid config: %v", err)
+			os.Exit(1)
+		}
+		cfg = selectDatabase(cfg)
+
+		from, to := args[0], args[1]
+
+		registry, err := loadMigrationsFromDir(cfg.MigrationsDir, cfg.PackageName)
+		if err != nil {
+			utils.PrintError("Failed to load migrations: %v", err)
+			os.Exit(1)
+		}
+
+		squashed, dependencies, err := registry.SquashRange(from, to)
+		if err != nil {
+			utils.PrintError("Cannot squash %s to %s: %v", from, to, err)
+			os.Exit(1)
+		}
+
+		// Index columns aren't simulated, so they are looked up in the models
+		models, err := modelreflect.ParseModelsFromDir(cfg.ModelsDir, cfg.IgnoreModels)
+		if err != nil {
+			utils.PrintError("Failed to parse models: %v", err)
+			os.Exit(1)
+		}
+
+		diffs, err := squashDiffs(registry, squashed, managedModels(cfg, models))
+		if err != nil {
+			utils.PrintError("Failed to simulate migrations: %v", err)
+			os.Exit(1)
+		}
+		if len(diffs) == 0 {
+			utils.PrintError("The migrations from %s to %s make no schema changes, so there is nothing to squash", from, to)
+			os.Exit(1)
+		}
+
+		replaces := make([]string, len(squashed))
+		for i, m := range squashed {
+			replaces[i] = m.Version()
+		}
+
+		name, _ := cmd.Flags().GetString("name")
+		if name == "" {
+			name = fmt.Sprintf("squashed_%s_to_%s", from, to)
+		}
+
+		gen := generator.NewGenerator(cfg.MigrationsDir, cfg.PackageName)
+		gen.SetDependencies(dependencies)
+		gen.SetReplaces(replaces)
+		gen.SetDialect(cfg.MigrationDialect())
+		filePath, err := gen.GenerateMigration(name, diffs)
+		if err != nil {
+			utils.PrintError("Failed to generate squashed migration: %v", err)
+			os.Exit(1)
+		}
+
+		utils.PrintSuccess("Generated squashed migration: %s (replaces %d migrations)", filepath.Base(filePath), len(replaces))
+		utils.PrintWarning("Only schema changes are squashed; copy any data changes or hand-written SQL from the replaced migrations")
+		utils.PrintInfo("Once every database has applied the replaced migrations, they can be deleted")
+	},
+}
+
+// squashDiffs returns the schema changes made by a range of migrations
+// The migrations before the range and the migrations up to its end are simulated separately
+// and the two schemas compared.
+func squashDiffs(registry *runner.Registry, squashed []runner.Migration, models []modelreflect.ParsedModel) ([]diff.Diff, error) {
+	first := squashed[0].Version()
+	last := squashed[len(squashed)-1].Version()
+
+	migrations, err := registry.GetAllMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	before := runner.NewRegistry()
+	after := runner.NewRegistry()
+	inRange := false
+	for _, m := range migrations {
+		if m.Version() == first {
+			inRange = true
+		}
+		if !inRange {
+			before.RegisterMigration(m)
+		}
+		after.RegisterMigration(m)
+		if m.Version() == last {
+			break
+		}
+	}
+
+	fromSchema, err := runner.NewRunner(nil, before, nil).SimulateSchema()
+	if err != nil {
+		return nil, err
+	}
+	toSchema, err := runner.NewRunner(nil, after, nil).SimulateSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	return diff.CompareSchemas(fromSchema.Schema, toSchema.Schema, models)
+}
+
+func init() {
+	squashmigrationsCmd.Flags().String("name", "", "Name of the squashed migration (default: squashed_<from>_to_<to>)")
+	rootCmd.AddCommand(squashmigrationsCmd)
+}
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check applied migrations against their source files",
+	Long:  "Compares the checksum recorded for each applied migration with its current source and reports migrations that were edited or deleted after being applied. Use --repair to accept reviewed changes",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		migratorArgs := []string{"verify"}
+		if repair, _ := cmd.Flags().GetBool("repair"); repair {
+			migratorArgs = append(migratorArgs, "--repair")
+		}
+
+		runTempMigrator(migratorArgs)
+	},
+}
+
+func init() {
+	verifyCmd.Flags().Bool("repair", false, "Re-stamp the recorded checksums of edited migrations with their current checksums")
+	rootCmd.AddCommand(verifyCmd)
+}
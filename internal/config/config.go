@@ -4,30 +4,120 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/pankajredekar/goosegorm/internal/lock"
+	"github.com/pankajredekar/goosegorm/internal/tenant"
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
+	DatabaseURL      string   `yaml:"database_url"`
+	ModelsDir        string   `yaml:"models_dir"`
+	MigrationsDir    string   `yaml:"migrations_dir"`
+	PackageName      string   `yaml:"package_name"`
+	MigrationTable   string   `yaml:"migration_table"`
+	IgnoreModels     []string `yaml:"ignore_models"`
+	BuildPath        string   `yaml:"build_path"`        // Optional: Path to save migrator binary for production use
+	LockTimeout      string   `yaml:"lock_timeout"`      // Optional: How long to wait for the migration lock (e.g. 30s)
+	MigrationTimeout string   `yaml:"migration_timeout"` // Optional: How long a single migration may run before it is cancelled (e.g. 5m)
+
+	// Tenants configures the PostgreSQL schemas that migrate --tenants runs the migrations against
+	Tenants TenantsConfig `yaml:"tenants"`
+
+	// Databases configures additional named databases; the top-level settings are the "default" database
+	Databases map[string]DatabaseConfig `yaml:"databases"`
+
+	// Environments configures per-environment overrides of the top-level settings
+	Environments map[string]EnvironmentConfig `yaml:"environments"`
+	// Env is the environment whose overrides were applied by LoadConfigForEnv
+	Env string `yaml:"-"`
+	// Protected is set when the environment asks for confirmation before destructive commands
+	Protected bool `yaml:"-"`
+
+	// Name is the database the config was selected for with ForDatabase
+	Name string `yaml:"-"`
+	// OwnsUnannotatedModels is set when models without a goosegorm:"database:<name>" annotation
+	// in ModelsDir belong to the selected database
+	OwnsUnannotatedModels bool `yaml:"-"`
+}
+
+// DefaultDatabase is the name of the database configured by the top-level settings
+const DefaultDatabase = "default"
+
+// TenantsConfig lists the schemas of a schema-per-tenant PostgreSQL database
+type TenantsConfig struct {
+	Schemas     []string `yaml:"schemas"`     // Static list of tenant schemas
+	Query       string   `yaml:"query"`       // Or a query returning one tenant schema per row
+	Concurrency int      `yaml:"concurrency"` // How many tenants are migrated at once (default 1)
+}
+
+// EnvVar selects the environment when --env isn't given
+const EnvVar = "GOOSEGORM_ENV"
+
+// EnvironmentConfig overrides the top-level settings in one environment, such as dev, staging or prod
+// Unset fields keep the top-level value.
+type EnvironmentConfig struct {
+	DatabaseURL      string `yaml:"database_url"`
+	MigrationTable   string `yaml:"migration_table"`
+	BuildPath        string `yaml:"build_path"`
+	LockTimeout      string `yaml:"lock_timeout"`
+	MigrationTimeout string `yaml:"migration_timeout"`
+	// Protected environments ask for confirmation before rollback, redo, reset and migrate --to backwards
+	Protected bool `yaml:"protected"`
+}
+
+// DatabaseConfig configures one named database
+// Unset fields fall back to the top-level settings, except that each database needs its own migrations_dir.
+type DatabaseConfig struct {
 	DatabaseURL    string   `yaml:"database_url"`
 	ModelsDir      string   `yaml:"models_dir"`
 	MigrationsDir  string   `yaml:"migrations_dir"`
 	PackageName    string   `yaml:"package_name"`
 	MigrationTable string   `yaml:"migration_table"`
 	IgnoreModels   []string `yaml:"ignore_models"`
-	BuildPath      string   `yaml:"build_path"` // Optional: Path to save migrator binary for production use
+	BuildPath      string   `yaml:"build_path"`
 }
 
+// LoadConfig loads the config for the environment named by GOOSEGORM_ENV, if set
 func LoadConfig(configPath string) (*Config, error) {
+	return LoadConfigForEnv(configPath, os.Getenv(EnvVar))
+}
+
+// LoadConfigForEnv loads the config with the overrides of an environment applied
+// An empty env applies none. ${VAR} and ${VAR:-default} in values are replaced with environment
+// variables. GOOSEGORM_DATABASE_URL overrides the database_url of the default database, unless
+// the environment sets its own, and GOOSEGORM_<NAME>_DATABASE_URL that of a named database.
+func LoadConfigForEnv(configPath, env string) (*Config, error) {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
+	if err := interpolate(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	var cfg Config
+	if len(doc.Content) > 0 {
+		if err := doc.Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config: %w", err)
+		}
+	}
+	if err := cfg.applyEnvironment(env); err != nil {
+		return nil, err
+	}
+
+	// The database URLs can be passed in the environment instead of being written in the file
+	if err := cfg.applyDatabaseURLVars(); err != nil {
+		return nil, err
+	}
 
 	// Set defaults
 	if cfg.MigrationTable == "" {
@@ -45,11 +135,220 @@ func LoadConfig(configPath string) (*Config, error) {
 	if !filepath.IsAbs(cfg.MigrationsDir) {
 		cfg.MigrationsDir = filepath.Join(filepath.Dir(configPath), cfg.MigrationsDir)
 	}
+	for name, db := range cfg.Databases {
+		if db.ModelsDir != "" && !filepath.IsAbs(db.ModelsDir) {
+			db.ModelsDir = filepath.Join(filepath.Dir(configPath), db.ModelsDir)
+		}
+		if db.MigrationsDir != "" && !filepath.IsAbs(db.MigrationsDir) {
+			db.MigrationsDir = filepath.Join(filepath.Dir(configPath), db.MigrationsDir)
+		}
+		cfg.Databases[name] = db
+	}
 
 	return &cfg, nil
 }
 
+// applyEnvironment overrides the top-level settings with those of the named environment
+func (c *Config) applyEnvironment(env string) error {
+	if env == "" {
+		return nil
+	}
+	overrides, ok := c.Environments[env]
+	if !ok {
+		names := make([]string, 0, len(c.Environments))
+		for name := range c.Environments {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown environment %q (configured: %s)", env, strings.Join(names, ", "))
+	}
+
+	if overrides.DatabaseURL != "" {
+		c.DatabaseURL = overrides.DatabaseURL
+	}
+	if overrides.MigrationTable != "" {
+		c.MigrationTable = overrides.MigrationTable
+	}
+	if overrides.BuildPath != "" {
+		c.BuildPath = overrides.BuildPath
+	}
+	if overrides.LockTimeout != "" {
+		c.LockTimeout = overrides.LockTimeout
+	}
+	if overrides.MigrationTimeout != "" {
+		c.MigrationTimeout = overrides.MigrationTimeout
+	}
+	c.Env = env
+	c.Protected = overrides.Protected
+	return nil
+}
+
+// applyDatabaseURLVars overrides the URL of each database with its variable, GOOSEGORM_DATABASE_URL
+// for the default database and GOOSEGORM_<NAME>_DATABASE_URL for the named ones
+// It runs after applyEnvironment.
+func (c *Config) applyDatabaseURLVars() error {
+	url, ok, err := lookupEnv(DatabaseURLEnvVar)
+	if err != nil {
+		return err
+	}
+	if ok && url != "" {
+		switch db, named := c.Databases[DefaultDatabase]; {
+		case c.Env != "" && c.Environments[c.Env].DatabaseURL != "":
+			// The environment's own database_url wins, so a variable left in the shell
+			// can't point an environment such as prod at another database
+		case c.hasDefaultDatabase():
+			c.DatabaseURL = url
+		case named:
+			db.DatabaseURL = url
+			c.Databases[DefaultDatabase] = db
+		default:
+			// Setting it on the top level would configure a "default" database that doesn't exist
+			return fmt.Errorf("%s sets the URL of the default database, which isn't configured; use GOOSEGORM_<NAME>_DATABASE_URL for a named database", DatabaseURLEnvVar)
+		}
+	}
+
+	for name, db := range c.Databases {
+		if name == DefaultDatabase {
+			continue
+		}
+		url, ok, err := lookupEnv(DatabaseURLEnvVarFor(name))
+		if err != nil {
+			return err
+		}
+		if ok && url != "" {
+			db.DatabaseURL = url
+			c.Databases[name] = db
+		}
+	}
+	return nil
+}
+
+// DatabaseNames returns the names of the configured databases, sorted
+// Without a databases map, the top-level settings configure the only database, "default".
+func (c *Config) DatabaseNames() []string {
+	var names []string
+	if c.hasDefaultDatabase() {
+		names = append(names, DefaultDatabase)
+	}
+	for name := range c.Databases {
+		if name != DefaultDatabase || !c.hasDefaultDatabase() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// hasDefaultDatabase reports whether the top-level settings configure a database
+func (c *Config) hasDefaultDatabase() bool {
+	return len(c.Databases) == 0 || c.DatabaseURL != ""
+}
+
+// ForDatabase returns the config of a single database, with its settings in the top-level fields
+// An empty name selects the default database, or the only database if there is just one.
+func (c *Config) ForDatabase(name string) (*Config, error) {
+	names := c.DatabaseNames()
+	if name == "" {
+		switch {
+		case len(names) == 1:
+			name = names[0]
+		case c.hasDefaultDatabase():
+			name = DefaultDatabase
+		default:
+			return nil, fmt.Errorf("several databases are configured (%s); choose one with --database", strings.Join(names, ", "))
+		}
+	}
+
+	selected := *c
+	selected.Databases = nil
+	selected.Name = name
+
+	if name == DefaultDatabase && c.hasDefaultDatabase() {
+		selected.OwnsUnannotatedModels = c.ownsModelsDir(name, c.ModelsDir)
+		return &selected, nil
+	}
+
+	db, ok := c.Databases[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown database %q (configured: %s)", name, strings.Join(names, ", "))
+	}
+	if db.DatabaseURL != "" {
+		selected.DatabaseURL = db.DatabaseURL
+	}
+	if db.ModelsDir != "" {
+		selected.ModelsDir = db.ModelsDir
+	}
+	if db.MigrationsDir != "" {
+		selected.MigrationsDir = db.MigrationsDir
+	}
+	if db.PackageName != "" {
+		selected.PackageName = db.PackageName
+	}
+	if db.MigrationTable != "" {
+		selected.MigrationTable = db.MigrationTable
+	}
+	if db.IgnoreModels != nil {
+		selected.IgnoreModels = db.IgnoreModels
+	}
+	if db.BuildPath != "" {
+		selected.BuildPath = db.BuildPath
+	}
+	selected.OwnsUnannotatedModels = c.ownsModelsDir(name, selected.ModelsDir)
+	return &selected, nil
+}
+
+// ownsModelsDir reports whether unannotated models in a models directory belong to the named database
+// A directory read by several databases belongs to the default database, if it is one of them.
+func (c *Config) ownsModelsDir(name, modelsDir string) bool {
+	var readers []string
+	for _, other := range c.DatabaseNames() {
+		dir := c.ModelsDir
+		if db, ok := c.Databases[other]; ok && !(other == DefaultDatabase && c.hasDefaultDatabase()) && db.ModelsDir != "" {
+			dir = db.ModelsDir
+		}
+		if filepath.Clean(dir) == filepath.Clean(modelsDir) {
+			readers = append(readers, other)
+		}
+	}
+	if len(readers) == 1 {
+		return readers[0] == name
+	}
+	return name == DefaultDatabase
+}
+
 func (c *Config) Validate() error {
+	if len(c.Databases) > 0 {
+		return c.validateDatabases()
+	}
+	return c.validate()
+}
+
+// validateDatabases validates each configured database
+func (c *Config) validateDatabases() error {
+	if _, ok := c.Databases[DefaultDatabase]; ok && c.DatabaseURL != "" {
+		return fmt.Errorf("database %q is configured both by the top-level settings and under databases", DefaultDatabase)
+	}
+
+	migrationsDirs := make(map[string]string)
+	for _, name := range c.DatabaseNames() {
+		db, err := c.ForDatabase(name)
+		if err != nil {
+			return err
+		}
+		if err := db.validate(); err != nil {
+			return fmt.Errorf("database %s: %w", name, err)
+		}
+		// The migrations of each database are compiled and simulated on their own
+		dir := filepath.Clean(db.MigrationsDir)
+		if other, ok := migrationsDirs[dir]; ok {
+			return fmt.Errorf("databases %s and %s share migrations_dir %s; each database needs its own", other, name, db.MigrationsDir)
+		}
+		migrationsDirs[dir] = name
+	}
+	return nil
+}
+
+func (c *Config) validate() error {
 	if c.DatabaseURL == "" {
 		return fmt.Errorf("database_url is required")
 	}
@@ -59,5 +358,50 @@ func (c *Config) Validate() error {
 	if c.MigrationsDir == "" {
 		return fmt.Errorf("migrations_dir is required")
 	}
+	if _, err := c.GetLockTimeout(); err != nil {
+		return err
+	}
+	if _, err := c.GetMigrationTimeout(); err != nil {
+		return err
+	}
+	return c.Tenants.validate()
+}
+
+func (t TenantsConfig) validate() error {
+	if len(t.Schemas) > 0 && t.Query != "" {
+		return fmt.Errorf("tenants: set either schemas or query, not both")
+	}
+	for _, schema := range t.Schemas {
+		if err := tenant.ValidateSchema(schema); err != nil {
+			return fmt.Errorf("tenants: %w", err)
+		}
+	}
+	if t.Concurrency < 0 {
+		return fmt.Errorf("tenants: concurrency must not be negative")
+	}
 	return nil
 }
+
+// GetLockTimeout returns the configured migration lock timeout, or the default if unset
+func (c *Config) GetLockTimeout() (time.Duration, error) {
+	if c.LockTimeout == "" {
+		return lock.DefaultTimeout, nil
+	}
+	d, err := time.ParseDuration(c.LockTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid lock_timeout %q: %w", c.LockTimeout, err)
+	}
+	return d, nil
+}
+
+// GetMigrationTimeout returns the configured per-migration timeout, or 0 (no timeout) if unset
+func (c *Config) GetMigrationTimeout() (time.Duration, error) {
+	if c.MigrationTimeout == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(c.MigrationTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid migration_timeout %q: %w", c.MigrationTimeout, err)
+	}
+	return d, nil
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -99,6 +100,25 @@ func TestValidateConfig(t *testing.T) {
 	if err := cfg.Validate(); err == nil {
 		t.Error("Config with missing migrations_dir should error")
 	}
+
+	// Test invalid migration_timeout
+	cfg.MigrationsDir = "./migrations"
+	cfg.MigrationTimeout = "five minutes"
+	if err := cfg.Validate(); err == nil {
+		t.Error("Config with invalid migration_timeout should error")
+	}
+}
+
+func TestGetMigrationTimeout(t *testing.T) {
+	cfg := &Config{}
+	if d, err := cfg.GetMigrationTimeout(); err != nil || d != 0 {
+		t.Errorf("Unset migration_timeout should disable the timeout, got %v, %v", d, err)
+	}
+
+	cfg.MigrationTimeout = "5m"
+	if d, err := cfg.GetMigrationTimeout(); err != nil || d != 5*time.Minute {
+		t.Errorf("Expected 5m, got %v, %v", d, err)
+	}
 }
 
 func TestLoadConfigWithRelativePaths(t *testing.T) {
@@ -127,3 +147,189 @@ migrations_dir: ./migrations
 		}
 	}
 }
+
+func TestLoadConfigWithDatabases(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "goosegorm.yml")
+
+	content := `database_url: postgres://localhost/app
+models_dir: ./models
+migrations_dir: ./migrations
+lock_timeout: 30s
+databases:
+  analytics:
+    database_url: postgres://localhost/analytics
+    migrations_dir: ./migrations/analytics
+    migration_table: _analytics_migrations
+  archive:
+    database_url: postgres://localhost/archive
+    models_dir: ./models/archive
+    migrations_dir: ./migrations/archive
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	names := cfg.DatabaseNames()
+	if len(names) != 3 || names[0] != "analytics" || names[1] != "archive" || names[2] != "default" {
+		t.Fatalf("Expected [analytics archive default], got %v", names)
+	}
+
+	analytics, err := cfg.ForDatabase("analytics")
+	if err != nil {
+		t.Fatalf("ForDatabase failed: %v", err)
+	}
+	if analytics.Name != "analytics" || analytics.DatabaseURL != "postgres://localhost/analytics" || analytics.MigrationTable != "_analytics_migrations" {
+		t.Errorf("Unexpected analytics config: %+v", analytics)
+	}
+	if analytics.MigrationsDir != filepath.Join(tmpDir, "migrations", "analytics") {
+		t.Errorf("Expected the migrations dir to be resolved, got %s", analytics.MigrationsDir)
+	}
+	// Unset settings fall back to the top-level ones
+	if analytics.ModelsDir != filepath.Join(tmpDir, "models") || analytics.LockTimeout != "30s" || analytics.PackageName != "migrations" {
+		t.Errorf("Expected top-level settings to be inherited, got %+v", analytics)
+	}
+	// The default database owns the unannotated models of the directory it shares with analytics
+	if analytics.OwnsUnannotatedModels {
+		t.Error("Expected analytics not to own unannotated models in the shared models dir")
+	}
+
+	archive, err := cfg.ForDatabase("archive")
+	if err != nil {
+		t.Fatalf("ForDatabase failed: %v", err)
+	}
+	if !archive.OwnsUnannotatedModels {
+		t.Error("Expected archive to own the models in its own models dir")
+	}
+
+	def, err := cfg.ForDatabase("")
+	if err != nil {
+		t.Fatalf("ForDatabase failed: %v", err)
+	}
+	if def.Name != DefaultDatabase || def.DatabaseURL != "postgres://localhost/app" || !def.OwnsUnannotatedModels {
+		t.Errorf("Expected the top-level settings as the default database, got %+v", def)
+	}
+
+	if _, err := cfg.ForDatabase("missing"); err == nil {
+		t.Error("Expected an error for an unknown database")
+	}
+}
+
+func TestValidateDatabases(t *testing.T) {
+	cfg := &Config{
+		ModelsDir:     "./models",
+		MigrationsDir: "./migrations",
+		Databases: map[string]DatabaseConfig{
+			"primary":   {DatabaseURL: "postgres://localhost/app"},
+			"analytics": {DatabaseURL: "postgres://localhost/analytics", MigrationsDir: "./migrations/analytics"},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	// Without a default database one has to be chosen
+	if _, err := cfg.ForDatabase(""); err == nil {
+		t.Error("Expected an error selecting a database without a name")
+	}
+
+	cfg.Databases["analytics"] = DatabaseConfig{DatabaseURL: "postgres://localhost/analytics"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected an error for databases sharing a migrations dir")
+	}
+
+	cfg.Databases["analytics"] = DatabaseConfig{MigrationsDir: "./migrations/analytics"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected an error for a database without a database_url")
+	}
+}
+
+func TestValidateTenants(t *testing.T) {
+	cfg := &Config{
+		DatabaseURL:   "postgres://localhost/app",
+		ModelsDir:     "./models",
+		MigrationsDir: "./migrations",
+		Tenants:       TenantsConfig{Schemas: []string{"acme", "globex"}, Concurrency: 4},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	cfg.Tenants.Query = "SELECT schema_name FROM tenants"
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected an error for both schemas and a query")
+	}
+
+	cfg.Tenants = TenantsConfig{Schemas: []string{"acme-corp"}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected an error for an invalid schema name")
+	}
+
+	cfg.Tenants = TenantsConfig{Query: "SELECT schema_name FROM tenants", Concurrency: -1}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected an error for a negative concurrency")
+	}
+}
+
+func TestLoadConfigWithEnvironments(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "goosegorm.yml")
+
+	content := `database_url: sqlite://./dev.db
+models_dir: ./models
+migrations_dir: ./migrations
+build_path: ./bin/goosegorm
+environments:
+  staging:
+    database_url: postgres://staging/app
+  prod:
+    database_url: postgres://prod/app
+    migration_table: _prod_migrations
+    lock_timeout: 5m
+    protected: true
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	t.Setenv(EnvVar, "")
+	dev, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if dev.DatabaseURL != "sqlite://./dev.db" || dev.Env != "" || dev.Protected {
+		t.Errorf("Expected the top-level settings without an environment, got %+v", dev)
+	}
+
+	t.Setenv(EnvVar, "prod")
+	prod, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if prod.DatabaseURL != "postgres://prod/app" || prod.MigrationTable != "_prod_migrations" || prod.LockTimeout != "5m" {
+		t.Errorf("Expected the prod overrides, got %+v", prod)
+	}
+	if prod.Env != "prod" || !prod.Protected || prod.BuildPath != "./bin/goosegorm" {
+		t.Errorf("Expected a protected prod environment keeping the top-level build_path, got %+v", prod)
+	}
+
+	staging, err := LoadConfigForEnv(configPath, "staging")
+	if err != nil {
+		t.Fatalf("LoadConfigForEnv failed: %v", err)
+	}
+	if staging.DatabaseURL != "postgres://staging/app" || staging.MigrationTable != "_goosegorm_migrations" || staging.Protected {
+		t.Errorf("Expected the staging overrides, got %+v", staging)
+	}
+
+	if _, err := LoadConfigForEnv(configPath, "qa"); err == nil {
+		t.Error("Expected an error for an unknown environment")
+	}
+}
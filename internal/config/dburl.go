@@ -0,0 +1,54 @@
+package config
+
+import (
+	"strings"
+)
+
+// Dialect returns the GORM dialect of a database URL: postgres, sqlite or mysql, or "" if it's not supported
+func Dialect(databaseURL string) string {
+	switch {
+	case strings.HasPrefix(databaseURL, "postgres://"), strings.HasPrefix(databaseURL, "postgresql://"):
+		return "postgres"
+	case strings.HasPrefix(databaseURL, "sqlite://"):
+		return "sqlite"
+	case strings.HasPrefix(databaseURL, "mysql://"):
+		return "mysql"
+	default:
+		return ""
+	}
+}
+
+// MigrationDialect returns the dialect the migrations of the database are generated for: that of
+// its database_url, or "" when environments point it at different kinds of databases, so the
+// migrations choose their SQL at runtime
+func (c *Config) MigrationDialect() string {
+	dialect := Dialect(c.DatabaseURL)
+	// Environments only override the settings of the default database
+	if c.Name != "" && c.Name != DefaultDatabase {
+		return dialect
+	}
+	for _, env := range c.Environments {
+		if env.DatabaseURL != "" && Dialect(env.DatabaseURL) != dialect {
+			return ""
+		}
+	}
+	return dialect
+}
+
+// UsesDialect reports whether the database_url of the database, or of one of the environments
+// that override it, is of the dialect
+func (c *Config) UsesDialect(dialect string) bool {
+	if Dialect(c.DatabaseURL) == dialect {
+		return true
+	}
+	// Environments only override the settings of the default database
+	if c.Name != "" && c.Name != DefaultDatabase {
+		return false
+	}
+	for _, env := range c.Environments {
+		if Dialect(env.DatabaseURL) == dialect {
+			return true
+		}
+	}
+	return false
+}
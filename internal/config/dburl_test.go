@@ -0,0 +1,64 @@
+package config
+
+import "testing"
+
+func TestDialect(t *testing.T) {
+	cases := map[string]string{
+		"postgres://localhost/app":   "postgres",
+		"postgresql://localhost/app": "postgres",
+		"sqlite://./app.db":          "sqlite",
+		"mysql://root@localhost/app": "mysql",
+		"oracle://localhost/app":     "",
+	}
+	for databaseURL, want := range cases {
+		if got := Dialect(databaseURL); got != want {
+			t.Errorf("Dialect(%s) = %q, expected %q", databaseURL, got, want)
+		}
+	}
+}
+
+func TestMigrationDialect(t *testing.T) {
+	cfg := &Config{
+		DatabaseURL: "postgres://localhost/app",
+		Environments: map[string]EnvironmentConfig{
+			"staging": {DatabaseURL: "postgres://staging/app"},
+			"qa":      {MigrationTable: "_qa_migrations"},
+		},
+	}
+	if got := cfg.MigrationDialect(); got != "postgres" {
+		t.Errorf("Expected postgres, got %q", got)
+	}
+
+	// Migrations of a database that is SQLite in development and PostgreSQL elsewhere choose at runtime
+	cfg.DatabaseURL = "sqlite://./dev.db"
+	if got := cfg.MigrationDialect(); got != "" {
+		t.Errorf("Expected no dialect for environments on different databases, got %q", got)
+	}
+
+	// Environments don't apply to named databases
+	cfg.Name = "analytics"
+	if got := cfg.MigrationDialect(); got != "sqlite" {
+		t.Errorf("Expected sqlite for a named database, got %q", got)
+	}
+}
+
+func TestUsesDialect(t *testing.T) {
+	cfg := &Config{
+		DatabaseURL: "sqlite://./dev.db",
+		Environments: map[string]EnvironmentConfig{
+			"prod": {DatabaseURL: "mysql://app@db/app"},
+		},
+	}
+	if !cfg.UsesDialect("sqlite") || !cfg.UsesDialect("mysql") {
+		t.Error("Expected the dialects of the database and its environments")
+	}
+	if cfg.UsesDialect("postgres") {
+		t.Error("Expected postgres not to be used")
+	}
+
+	// Environments don't apply to named databases
+	cfg.Name = "analytics"
+	if cfg.UsesDialect("mysql") {
+		t.Error("Expected the environments of a named database to be ignored")
+	}
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DatabaseURLEnvVar overrides the database_url of the default database when set
+// GOOSEGORM_DATABASE_URL_FILE names a file holding the URL instead.
+const DatabaseURLEnvVar = "GOOSEGORM_DATABASE_URL"
+
+// nonAlphanumeric matches the characters of a database name that can't be part of a variable name
+var nonAlphanumeric = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// DatabaseURLEnvVarFor returns the variable overriding the database_url of a database,
+// such as GOOSEGORM_ANALYTICS_DATABASE_URL for "analytics"
+func DatabaseURLEnvVarFor(name string) string {
+	if name == DefaultDatabase {
+		return DatabaseURLEnvVar
+	}
+	return "GOOSEGORM_" + nonAlphanumeric.ReplaceAllString(strings.ToUpper(name), "_") + "_DATABASE_URL"
+}
+
+// interpolation matches ${VAR} and ${VAR:-default}
+var interpolation = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// lookupEnv returns the value of an environment variable, or else the contents of the file
+// named by <name>_FILE, as Docker secrets are mounted
+func lookupEnv(name string) (string, bool, error) {
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true, nil
+	}
+	path := os.Getenv(name + "_FILE")
+	if path == "" {
+		return "", false, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s_FILE: %w", name, err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), true, nil
+}
+
+// expand replaces ${VAR} and ${VAR:-default} in a value with environment variables
+// The default is used when VAR is unset or empty; an unset VAR without a default is an error.
+func expand(value string) (string, error) {
+	var expandErr error
+	expanded := interpolation.ReplaceAllStringFunc(value, func(match string) string {
+		groups := interpolation.FindStringSubmatch(match)
+		name, hasDefault, def := groups[1], groups[2] != "", groups[3]
+
+		env, ok, err := lookupEnv(name)
+		if err != nil {
+			expandErr = err
+			return match
+		}
+		if hasDefault && env == "" {
+			return def
+		}
+		if !ok {
+			expandErr = fmt.Errorf("environment variable %s is not set (use ${%s:-default} for a default)", name, name)
+			return match
+		}
+		return env
+	})
+	if expandErr != nil {
+		return "", expandErr
+	}
+	return expanded, nil
+}
+
+// interpolate expands the environment variables in every value of a YAML document
+func interpolate(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		expanded, err := expand(node.Value)
+		if err != nil {
+			return err
+		}
+		if expanded != node.Value {
+			node.Value = expanded
+			// Let plain values such as ${CONCURRENCY:-4} resolve to their expanded type
+			node.Tag = ""
+		}
+	case yaml.MappingNode:
+		// Keys are left alone
+		for i := 1; i < len(node.Content); i += 2 {
+			if err := interpolate(node.Content[i]); err != nil {
+				return err
+			}
+		}
+	default:
+		for _, child := range node.Content {
+			if err := interpolate(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package dialect
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect writes the SQL of one database for the code generated into migrations
+type Dialect interface {
+	// Name is the name of the GORM dialector, as db.Dialector.Name() returns it
+	Name() string
+	// Quote quotes an identifier, so reserved words such as user or order can be used
+	Quote(identifier string) string
+	// TypeName returns the SQL type GORM gives a column type of the migrations (bigint, string, ...)
+	TypeName(columnType string, isPK bool) string
+	// CreateIndex returns the statement creating an index
+	CreateIndex(table, name string, unique bool, columns []string) string
+	// DropIndex returns the statement dropping an index
+	DropIndex(table, name string) string
+	// AlterColumn returns the statements changing the type and nullability of a column,
+	// or nil if columns can't be altered in place (see Features.AlterColumn)
+	AlterColumn(table, column, columnType string, isPK, null bool) []string
+	// Features reports the differences in what the database supports
+	Features() Features
+}
+
+// Features are the differences between databases that change the code generated for them
+type Features struct {
+	// IndexIfExists is support for CREATE INDEX IF NOT EXISTS and DROP INDEX IF EXISTS;
+	// without it the generated code checks Migrator().HasIndex first
+	IndexIfExists bool
+	// AlterColumn is support for changing the type of a column in place; without it
+	// GORM's migrator rebuilds the table
+	AlterColumn bool
+	// TransactionalDDL reports whether schema changes are undone when their transaction rolls back
+	TransactionalDDL bool
+	// SizedStrings reports whether string columns need a size to be indexed
+	SizedStrings bool
+}
+
+// All returns the supported dialects, PostgreSQL first
+func All() []Dialect {
+	return []Dialect{postgresDialect{}, sqliteDialect{}, mysqlDialect{}}
+}
+
+// For returns the dialect of a GORM dialector name, or nil if it isn't supported
+func For(name string) Dialect {
+	for _, d := range All() {
+		if d.Name() == name {
+			return d
+		}
+	}
+	return nil
+}
+
+// indexColumns quotes the columns of an index
+func indexColumns(d Dialect, columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = d.Quote(column)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// unique returns the UNIQUE keyword of a unique index
+func unique(isUnique bool) string {
+	if isUnique {
+		return "UNIQUE "
+	}
+	return ""
+}
+
+// isInteger reports whether a column type of the migrations is an integer type
+func isInteger(columnType string) bool {
+	switch columnType {
+	case "bigint", "integer", "smallint", "tinyint":
+		return true
+	}
+	return false
+}
+
+// postgresDialect is PostgreSQL
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string { return "postgres" }
+
+func (postgresDialect) Quote(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+func (postgresDialect) TypeName(columnType string, isPK bool) string {
+	switch columnType {
+	case "bigint":
+		return "bigint"
+	case "integer":
+		return "integer"
+	case "smallint", "tinyint":
+		return "smallint"
+	case "float":
+		return "double precision"
+	case "bool":
+		return "boolean"
+	case "timestamp":
+		return "timestamptz"
+	default:
+		return "text"
+	}
+}
+
+func (d postgresDialect) CreateIndex(table, name string, isUnique bool, columns []string) string {
+	return fmt.Sprintf("CREATE %sINDEX IF NOT EXISTS %s ON %s (%s)", unique(isUnique), d.Quote(name), d.Quote(table), indexColumns(d, columns))
+}
+
+func (d postgresDialect) DropIndex(table, name string) string {
+	return fmt.Sprintf("DROP INDEX IF EXISTS %s", d.Quote(name))
+}
+
+func (d postgresDialect) AlterColumn(table, column, columnType string, isPK, null bool) []string {
+	sqlType := d.TypeName(columnType, isPK)
+	alter := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s", d.Quote(table), d.Quote(column))
+	nullability := " SET NOT NULL"
+	if null {
+		nullability = " DROP NOT NULL"
+	}
+	return []string{
+		fmt.Sprintf("%s TYPE %s USING %s::%s", alter, sqlType, d.Quote(column), sqlType),
+		alter + nullability,
+	}
+}
+
+func (postgresDialect) Features() Features {
+	return Features{IndexIfExists: true, AlterColumn: true, TransactionalDDL: true}
+}
+
+// sqliteDialect is SQLite
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string { return "sqlite" }
+
+func (sqliteDialect) Quote(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+func (sqliteDialect) TypeName(columnType string, isPK bool) string {
+	switch columnType {
+	case "bigint", "integer", "smallint", "tinyint":
+		return "integer"
+	case "float":
+		return "real"
+	case "bool":
+		return "numeric"
+	case "timestamp":
+		return "datetime"
+	default:
+		return "text"
+	}
+}
+
+func (d sqliteDialect) CreateIndex(table, name string, isUnique bool, columns []string) string {
+	return fmt.Sprintf("CREATE %sINDEX IF NOT EXISTS %s ON %s (%s)", unique(isUnique), d.Quote(name), d.Quote(table), indexColumns(d, columns))
+}
+
+func (d sqliteDialect) DropIndex(table, name string) string {
+	return fmt.Sprintf("DROP INDEX IF EXISTS %s", d.Quote(name))
+}
+
+// AlterColumn returns nil: SQLite has no ALTER COLUMN, so the table has to be rebuilt
+func (sqliteDialect) AlterColumn(table, column, columnType string, isPK, null bool) []string {
+	return nil
+}
+
+func (sqliteDialect) Features() Features {
+	return Features{IndexIfExists: true, TransactionalDDL: true}
+}
+
+// mysqlDialect is MySQL and MariaDB
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string { return "mysql" }
+
+func (mysqlDialect) Quote(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
+
+func (mysqlDialect) TypeName(columnType string, isPK bool) string {
+	unsigned := ""
+	if isPK {
+		unsigned = " unsigned"
+	}
+	switch columnType {
+	case "bigint":
+		return "bigint" + unsigned
+	case "integer":
+		return "int" + unsigned
+	case "smallint":
+		return "smallint"
+	case "tinyint":
+		return "tinyint"
+	case "float":
+		return "double"
+	case "bool":
+		return "boolean"
+	case "timestamp":
+		return "datetime(3)"
+	default:
+		return "varchar(255)"
+	}
+}
+
+// CreateIndex returns a plain CREATE INDEX, as MySQL has no IF NOT EXISTS for indexes
+func (d mysqlDialect) CreateIndex(table, name string, isUnique bool, columns []string) string {
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)", unique(isUnique), d.Quote(name), d.Quote(table), indexColumns(d, columns))
+}
+
+// DropIndex returns DROP INDEX ... ON, as MySQL indexes belong to their table
+func (d mysqlDialect) DropIndex(table, name string) string {
+	return fmt.Sprintf("DROP INDEX %s ON %s", d.Quote(name), d.Quote(table))
+}
+
+// AlterColumn returns MODIFY COLUMN, which replaces the whole column definition, so integer
+// primary keys keep their AUTO_INCREMENT
+func (d mysqlDialect) AlterColumn(table, column, columnType string, isPK, null bool) []string {
+	definition := d.TypeName(columnType, isPK)
+	if null {
+		definition += " NULL"
+	} else {
+		definition += " NOT NULL"
+	}
+	if isPK && isInteger(columnType) {
+		definition += " AUTO_INCREMENT"
+	}
+	return []string{
+		fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s", d.Quote(table), d.Quote(column), definition),
+	}
+}
+
+func (mysqlDialect) Features() Features {
+	return Features{AlterColumn: true, SizedStrings: true}
+}
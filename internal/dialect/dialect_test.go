@@ -0,0 +1,110 @@
+package dialect
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "dialect.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	return db
+}
+
+func TestFor(t *testing.T) {
+	for _, name := range []string{"postgres", "sqlite", "mysql"} {
+		if d := For(name); d == nil || d.Name() != name {
+			t.Errorf("Expected the %s dialect, got %v", name, d)
+		}
+	}
+	if For("oracle") != nil || For("") != nil {
+		t.Error("Expected no dialect for an unsupported database")
+	}
+}
+
+func TestQuote(t *testing.T) {
+	if got := For("postgres").Quote(`my"table`); got != `"my""table"` {
+		t.Errorf("Unexpected PostgreSQL quoting %s", got)
+	}
+	if got := For("mysql").Quote("my`table"); got != "`my``table`" {
+		t.Errorf("Unexpected MySQL quoting %s", got)
+	}
+}
+
+func TestIndexStatements(t *testing.T) {
+	cases := []struct {
+		dialect, create, drop string
+	}{
+		{"postgres", `CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_email" ON "user" ("email", "tenant_id")`, `DROP INDEX IF EXISTS "idx_user_email"`},
+		{"sqlite", `CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_email" ON "user" ("email", "tenant_id")`, `DROP INDEX IF EXISTS "idx_user_email"`},
+		{"mysql", "CREATE UNIQUE INDEX `idx_user_email` ON `user` (`email`, `tenant_id`)", "DROP INDEX `idx_user_email` ON `user`"},
+	}
+	for _, c := range cases {
+		d := For(c.dialect)
+		if got := d.CreateIndex("user", "idx_user_email", true, []string{"email", "tenant_id"}); got != c.create {
+			t.Errorf("%s: expected %s, got %s", c.dialect, c.create, got)
+		}
+		if got := d.DropIndex("user", "idx_user_email"); got != c.drop {
+			t.Errorf("%s: expected %s, got %s", c.dialect, c.drop, got)
+		}
+	}
+}
+
+func TestAlterColumn(t *testing.T) {
+	got := For("postgres").AlterColumn("user", "age", "bigint", false, true)
+	if len(got) != 2 || got[0] != `ALTER TABLE "user" ALTER COLUMN "age" TYPE bigint USING "age"::bigint` || got[1] != `ALTER TABLE "user" ALTER COLUMN "age" DROP NOT NULL` {
+		t.Errorf("Unexpected PostgreSQL statements %v", got)
+	}
+
+	got = For("mysql").AlterColumn("user", "name", "string", false, false)
+	if len(got) != 1 || got[0] != "ALTER TABLE `user` MODIFY COLUMN `name` varchar(255) NOT NULL" {
+		t.Errorf("Unexpected MySQL statements %v", got)
+	}
+
+	// MODIFY COLUMN would otherwise drop the AUTO_INCREMENT of the key
+	got = For("mysql").AlterColumn("user", "id", "integer", true, false)
+	if len(got) != 1 || got[0] != "ALTER TABLE `user` MODIFY COLUMN `id` int unsigned NOT NULL AUTO_INCREMENT" {
+		t.Errorf("Unexpected MySQL statements for a primary key %v", got)
+	}
+	got = For("mysql").AlterColumn("user", "code", "string", true, false)
+	if len(got) != 1 || got[0] != "ALTER TABLE `user` MODIFY COLUMN `code` varchar(255) NOT NULL" {
+		t.Errorf("Unexpected MySQL statements for a string primary key %v", got)
+	}
+
+	// SQLite has no ALTER COLUMN; the table has to be rebuilt
+	lite := For("sqlite")
+	if lite.Features().AlterColumn || lite.AlterColumn("user", "age", "bigint", false, true) != nil {
+		t.Error("Expected SQLite not to alter columns in place")
+	}
+}
+
+func TestSQLiteStatementsRun(t *testing.T) {
+	db := setupTestDB(t)
+	d := For("sqlite")
+
+	if err := db.Exec(`CREATE TABLE "order" ("id" integer PRIMARY KEY, "code" text)`).Error; err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	// Running the statements twice checks IF [NOT] EXISTS
+	for i := 0; i < 2; i++ {
+		if err := db.Exec(d.CreateIndex("order", "idx_order_code", true, []string{"code"})).Error; err != nil {
+			t.Fatalf("CreateIndex failed: %v", err)
+		}
+	}
+	if !db.Migrator().HasIndex("order", "idx_order_code") {
+		t.Fatal("Expected the index to exist")
+	}
+	for i := 0; i < 2; i++ {
+		if err := db.Exec(d.DropIndex("order", "idx_order_code")).Error; err != nil {
+			t.Fatalf("DropIndex failed: %v", err)
+		}
+	}
+	if db.Migrator().HasIndex("order", "idx_order_code") {
+		t.Error("Expected the index to be dropped")
+	}
+}
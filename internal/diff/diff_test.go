@@ -1,6 +1,7 @@
 package diff
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/pankajredekar/goosegorm/internal/modelreflect"
@@ -417,3 +418,73 @@ func TestMapGoTypeToSQLType(t *testing.T) {
 		})
 	}
 }
+
+func TestCompareSchemas(t *testing.T) {
+	from := schema.NewSchemaBuilder()
+	from.CreateTable("user").
+		AddColumnWithOptions("id", "bigint", false, true, false).
+		AddColumnWithOptions("name", "string", true, false, false)
+	from.CreateTable("legacy").
+		AddColumnWithOptions("id", "bigint", false, true, false)
+
+	to := schema.NewSchemaBuilder()
+	to.CreateTable("user").
+		AddColumnWithOptions("id", "bigint", false, true, false).
+		AddColumnWithOptions("name", "text", true, false, false).
+		AddColumnWithOptions("email", "string", false, false, false)
+	to.AlterTable("user").AddIndex("idx_email")
+	to.CreateTable("post").
+		AddColumnWithOptions("id", "bigint", false, true, false)
+
+	models := []modelreflect.ParsedModel{
+		{
+			Name:    "User",
+			Managed: true,
+			Fields: []modelreflect.Field{
+				{Name: "ID", Type: "uint", GormTag: "primaryKey"},
+				{Name: "Email", Type: "string", Indexes: []modelreflect.IndexInfo{{Name: "idx_email"}}},
+			},
+		},
+	}
+
+	diffs, err := CompareSchemas(from.Schema, to.Schema, models)
+	if err != nil {
+		t.Fatalf("CompareSchemas failed: %v", err)
+	}
+
+	var got []string
+	for _, d := range diffs {
+		switch {
+		case d.Column != nil:
+			got = append(got, d.Type+" "+d.TableName+"."+d.Column.Name)
+		case d.Index != nil:
+			got = append(got, d.Type+" "+d.TableName+"."+d.Index.Name)
+		default:
+			got = append(got, d.Type+" "+d.TableName)
+		}
+	}
+	expected := []string{
+		"create_table post",
+		"add_column user.email",
+		"modify_column user.name",
+		"add_index user.idx_email",
+		"drop_table legacy",
+	}
+	if strings.Join(got, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("Expected diffs %v, got %v", expected, got)
+	}
+
+	for _, d := range diffs {
+		if d.Type == "add_index" && (len(d.Index.Fields) != 1 || d.Index.Fields[0] != "email") {
+			t.Errorf("Expected idx_email on email, got %v", d.Index.Fields)
+		}
+		if d.Type == "modify_column" && d.Column.OldType != "string" {
+			t.Errorf("Expected old type 'string', got '%s'", d.Column.OldType)
+		}
+	}
+
+	// Index columns can't be determined without a model defining the index
+	if _, err := CompareSchemas(from.Schema, to.Schema, nil); err == nil {
+		t.Error("Expected an error for an index no model defines")
+	}
+}
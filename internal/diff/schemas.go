@@ -0,0 +1,148 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pankajredekar/goosegorm/internal/modelreflect"
+	"github.com/pankajredekar/goosegorm/internal/schema"
+)
+
+// CompareSchemas compares two simulated schemas, returning the changes that turn from into to
+// Simulated schemas only record index names, so the columns of added and dropped indexes are
+// taken from the models; an index that no model defines is an error.
+func CompareSchemas(from, to *schema.SchemaState, models []modelreflect.ParsedModel) ([]Diff, error) {
+	var diffs []Diff
+	expected := buildExpectedSchema(models)
+
+	indexDiff := func(tableName, indexName string) (*IndexDiff, error) {
+		if table, ok := expected[tableName]; ok {
+			if idx, ok := table.Indexes[indexName]; ok {
+				return idx, nil
+			}
+		}
+		return nil, fmt.Errorf("cannot determine the columns of index %s on %s: no model defines it", indexName, tableName)
+	}
+
+	for _, tableName := range sortedTableNames(to) {
+		toTable := to.Tables[tableName]
+		fromTable, exists := from.Tables[tableName]
+
+		if !exists {
+			diffs = append(diffs, Diff{
+				Type:      "create_table",
+				TableName: tableName,
+				Table:     tableDiff(toTable),
+			})
+			for _, indexName := range toTable.Indexes {
+				idx, err := indexDiff(tableName, indexName)
+				if err != nil {
+					return nil, err
+				}
+				diffs = append(diffs, Diff{Type: "add_index", TableName: tableName, Index: idx})
+			}
+			continue
+		}
+
+		for _, col := range sortedColumns(toTable) {
+			fromCol, exists := fromTable.Columns[col.Name]
+			if !exists {
+				diffs = append(diffs, Diff{Type: "add_column", TableName: tableName, Column: columnDiff(col)})
+				continue
+			}
+			if fromCol.Type != col.Type || fromCol.Null != col.Null || fromCol.PK != col.PK || fromCol.Unique != col.Unique {
+				modified := columnDiff(col)
+				modified.OldType = fromCol.Type
+				diffs = append(diffs, Diff{Type: "modify_column", TableName: tableName, Column: modified})
+			}
+		}
+		for _, col := range sortedColumns(fromTable) {
+			if _, exists := toTable.Columns[col.Name]; !exists {
+				diffs = append(diffs, Diff{Type: "drop_column", TableName: tableName, Column: &ColumnDiff{Name: col.Name}})
+			}
+		}
+
+		for _, indexName := range toTable.Indexes {
+			if !containsString(fromTable.Indexes, indexName) {
+				idx, err := indexDiff(tableName, indexName)
+				if err != nil {
+					return nil, err
+				}
+				diffs = append(diffs, Diff{Type: "add_index", TableName: tableName, Index: idx})
+			}
+		}
+		for _, indexName := range fromTable.Indexes {
+			if !containsString(toTable.Indexes, indexName) {
+				idx, err := indexDiff(tableName, indexName)
+				if err != nil {
+					return nil, err
+				}
+				diffs = append(diffs, Diff{Type: "drop_index", TableName: tableName, Index: idx})
+			}
+		}
+	}
+
+	for _, tableName := range sortedTableNames(from) {
+		if _, exists := to.Tables[tableName]; !exists {
+			diffs = append(diffs, Diff{Type: "drop_table", TableName: tableName})
+		}
+	}
+
+	return diffs, nil
+}
+
+// tableDiff describes a simulated table, primary key columns first
+func tableDiff(table *schema.Table) *TableDiff {
+	t := &TableDiff{
+		Name:    table.Name,
+		Columns: []*ColumnDiff{},
+		Indexes: make(map[string]*IndexDiff),
+	}
+	for _, col := range sortedColumns(table) {
+		t.Columns = append(t.Columns, columnDiff(col))
+	}
+	return t
+}
+
+func columnDiff(col *schema.Column) *ColumnDiff {
+	return &ColumnDiff{
+		Name:   col.Name,
+		Type:   col.Type,
+		Null:   col.Null,
+		PK:     col.PK,
+		Unique: col.Unique,
+	}
+}
+
+// sortedColumns returns the columns of a table, primary key columns first, then by name
+func sortedColumns(table *schema.Table) []*schema.Column {
+	columns := make([]*schema.Column, 0, len(table.Columns))
+	for _, col := range table.Columns {
+		columns = append(columns, col)
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		if columns[i].PK != columns[j].PK {
+			return columns[i].PK
+		}
+		return columns[i].Name < columns[j].Name
+	})
+	return columns
+}
+
+func sortedTableNames(s *schema.SchemaState) []string {
+	names := make([]string, 0, len(s.Tables))
+	for name := range s.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,228 @@
+package drift
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pankajredekar/goosegorm/internal/inspectdb"
+	"github.com/pankajredekar/goosegorm/internal/schema"
+	"gorm.io/gorm"
+)
+
+// Kinds of drift reported by Compare
+const (
+	ExtraTable    = "extra_table"
+	MissingTable  = "missing_table"
+	ExtraColumn   = "extra_column"
+	MissingColumn = "missing_column"
+	TypeMismatch  = "type_mismatch"
+	ExtraIndex    = "extra_index"
+	MissingIndex  = "missing_index"
+)
+
+// Issue is a difference between the database and the schema its applied migrations produce
+// Extra objects exist only in the database, missing ones only in the migrations.
+type Issue struct {
+	Kind     string `json:"kind"`
+	Table    string `json:"table"`
+	Column   string `json:"column,omitempty"`
+	Index    string `json:"index,omitempty"`
+	Expected string `json:"expected,omitempty"` // Column type in the migrations
+	Actual   string `json:"actual,omitempty"`   // Column type in the database
+}
+
+func (i Issue) String() string {
+	switch i.Kind {
+	case ExtraTable:
+		return fmt.Sprintf("extra table %s", i.Table)
+	case MissingTable:
+		return fmt.Sprintf("missing table %s", i.Table)
+	case ExtraColumn:
+		return fmt.Sprintf("extra column %s.%s", i.Table, i.Column)
+	case MissingColumn:
+		return fmt.Sprintf("missing column %s.%s", i.Table, i.Column)
+	case TypeMismatch:
+		return fmt.Sprintf("type mismatch on %s.%s: migrations have %s, database has %s", i.Table, i.Column, i.Expected, i.Actual)
+	case ExtraIndex:
+		return fmt.Sprintf("extra index %s on %s", i.Index, i.Table)
+	case MissingIndex:
+		return fmt.Sprintf("missing index %s on %s", i.Index, i.Table)
+	default:
+		return fmt.Sprintf("%s on %s", i.Kind, i.Table)
+	}
+}
+
+// Detect introspects the database and compares it with the expected schema
+// Tables listed in skip, such as the migration tables, are left out.
+func Detect(db *gorm.DB, expected *schema.SchemaState, skip []string) ([]Issue, error) {
+	tables, err := inspectdb.Inspect(db, nil, skip)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect database: %w", err)
+	}
+	return Compare(expected, tables, db.Dialector.Name()), nil
+}
+
+// Compare returns the differences between the expected schema and introspected tables
+// Column types are compared by kind (integer, float, string, ...), since each dialect stores
+// the generic types of the migrations under its own names.
+func Compare(expected *schema.SchemaState, tables []inspectdb.Table, dialect string) []Issue {
+	var issues []Issue
+
+	actual := make(map[string]inspectdb.Table)
+	for _, table := range tables {
+		actual[table.Name] = table
+	}
+
+	for _, name := range tableNames(expected, tables) {
+		want, inMigrations := expected.Tables[name]
+		got, inDatabase := actual[name]
+		switch {
+		case !inDatabase:
+			issues = append(issues, Issue{Kind: MissingTable, Table: name})
+		case !inMigrations:
+			issues = append(issues, Issue{Kind: ExtraTable, Table: name})
+		default:
+			issues = append(issues, compareColumns(want, got, dialect)...)
+			issues = append(issues, compareIndexes(want, got)...)
+		}
+	}
+
+	return issues
+}
+
+func compareColumns(want *schema.Table, got inspectdb.Table, dialect string) []Issue {
+	var issues []Issue
+
+	columns := make(map[string]inspectdb.Column)
+	for _, col := range got.Columns {
+		columns[col.Name] = col
+		if _, ok := want.Columns[col.Name]; !ok {
+			issues = append(issues, Issue{Kind: ExtraColumn, Table: got.Name, Column: col.Name})
+		}
+	}
+
+	names := make([]string, 0, len(want.Columns))
+	for name := range want.Columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		col, ok := columns[name]
+		if !ok {
+			issues = append(issues, Issue{Kind: MissingColumn, Table: got.Name, Column: name})
+			continue
+		}
+		if !compatible(dialect, want.Columns[name].Type, col) {
+			issues = append(issues, Issue{
+				Kind:     TypeMismatch,
+				Table:    got.Name,
+				Column:   name,
+				Expected: want.Columns[name].Type,
+				Actual:   col.DBType,
+			})
+		}
+	}
+
+	return issues
+}
+
+func compareIndexes(want *schema.Table, got inspectdb.Table) []Issue {
+	var issues []Issue
+
+	wanted := make(map[string]bool)
+	for _, name := range want.Indexes {
+		wanted[name] = true
+	}
+
+	found := make(map[string]bool)
+	for _, idx := range got.Indexes {
+		found[idx.Name] = true
+		if wanted[idx.Name] {
+			continue
+		}
+		// GORM backs unique columns with an index of its own naming
+		if idx.Unique && len(idx.Columns) == 1 {
+			if col, ok := want.Columns[idx.Columns[0]]; ok && col.Unique {
+				continue
+			}
+		}
+		issues = append(issues, Issue{Kind: ExtraIndex, Table: got.Name, Index: idx.Name})
+	}
+
+	for _, name := range want.Indexes {
+		if !found[name] {
+			issues = append(issues, Issue{Kind: MissingIndex, Table: got.Name, Index: name})
+		}
+	}
+
+	return issues
+}
+
+// compatible reports whether a database column can hold the type the migrations declare
+func compatible(dialect, expectedType string, col inspectdb.Column) bool {
+	want := typeKind(expectedType)
+	got := goTypeKind(col.GoType)
+	if want == got {
+		return true
+	}
+	// GORM stores booleans as numeric on SQLite and as tinyint(1) on MySQL
+	if want == "bool" {
+		return (dialect == "sqlite" && strings.EqualFold(col.DBType, "numeric")) ||
+			(dialect == "mysql" && strings.EqualFold(col.DBType, "tinyint"))
+	}
+	return false
+}
+
+// typeKind returns the kind of a column type in the migrations
+func typeKind(columnType string) string {
+	switch strings.ToLower(columnType) {
+	case "tinyint", "smallint", "integer", "int", "bigint":
+		return "integer"
+	case "float", "double", "real", "decimal", "numeric":
+		return "float"
+	case "bool", "boolean":
+		return "bool"
+	case "timestamp", "datetime", "date", "time":
+		return "time"
+	case "string", "text", "varchar":
+		return "string"
+	default:
+		return strings.ToLower(columnType)
+	}
+}
+
+// goTypeKind returns the kind of the Go type inspectdb maps a database column to
+func goTypeKind(goType string) string {
+	switch goType {
+	case "int8", "int16", "int32", "int64":
+		return "integer"
+	case "float64":
+		return "float"
+	case "bool":
+		return "bool"
+	case "time.Time":
+		return "time"
+	default:
+		// Binary columns hold the migrations' string type, which is also the fallback for unknown types
+		return "string"
+	}
+}
+
+// tableNames returns the names of the tables in either schema, sorted
+func tableNames(expected *schema.SchemaState, tables []inspectdb.Table) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for name := range expected.Tables {
+		seen[name] = true
+		names = append(names, name)
+	}
+	for _, table := range tables {
+		if !seen[table.Name] {
+			names = append(names, table.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
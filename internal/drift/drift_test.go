@@ -0,0 +1,117 @@
+package drift
+
+import (
+	"testing"
+
+	"github.com/pankajredekar/goosegorm/internal/inspectdb"
+	"github.com/pankajredekar/goosegorm/internal/schema"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T, statements ...string) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("Failed to create schema: %v", err)
+		}
+	}
+	return db
+}
+
+// expectedSchema is the schema of migrations creating users and orders
+func expectedSchema() *schema.SchemaState {
+	builder := schema.NewSchemaBuilder()
+	builder.CreateTable("users").
+		AddColumnWithOptions("id", "bigint", false, true, false).
+		AddColumnWithOptions("email", "string", false, false, true).
+		AddColumnWithOptions("active", "bool", false, false, false).
+		AddColumnWithOptions("age", "bigint", false, false, false)
+	builder.AlterTable("users").AddIndex("idx_users_active")
+	builder.CreateTable("orders").
+		AddColumnWithOptions("id", "bigint", false, true, false)
+	return builder.Schema
+}
+
+func TestDetectNoDrift(t *testing.T) {
+	// The tables as GORM creates them on SQLite
+	db := setupTestDB(t,
+		"CREATE TABLE `users` (`id` integer,`email` text NOT NULL,`active` numeric NOT NULL,`age` integer NOT NULL,PRIMARY KEY (`id`))",
+		"CREATE UNIQUE INDEX `idx_users_email` ON `users`(`email`)",
+		"CREATE INDEX `idx_users_active` ON `users`(`active`)",
+		"CREATE TABLE `orders` (`id` integer,PRIMARY KEY (`id`))",
+		"CREATE TABLE `_goosegorm_migrations` (`version` text)",
+	)
+
+	issues, err := Detect(db, expectedSchema(), []string{"_goosegorm_migrations"})
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	for _, issue := range issues {
+		t.Errorf("Unexpected drift: %s", issue)
+	}
+}
+
+func TestDetectDrift(t *testing.T) {
+	db := setupTestDB(t,
+		// age was changed to text, and nickname added by hand
+		"CREATE TABLE `users` (`id` integer,`email` text NOT NULL UNIQUE,`active` numeric NOT NULL,`age` text,`nickname` text,PRIMARY KEY (`id`))",
+		"CREATE INDEX `idx_users_nickname` ON `users`(`nickname`)",
+		"CREATE TABLE `audit_log` (`id` integer)",
+	)
+
+	issues, err := Detect(db, expectedSchema(), nil)
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+
+	expected := []Issue{
+		{Kind: ExtraTable, Table: "audit_log"},
+		{Kind: MissingTable, Table: "orders"},
+		{Kind: ExtraColumn, Table: "users", Column: "nickname"},
+		{Kind: TypeMismatch, Table: "users", Column: "age", Expected: "bigint", Actual: "text"},
+		{Kind: ExtraIndex, Table: "users", Index: "idx_users_nickname"},
+		{Kind: MissingIndex, Table: "users", Index: "idx_users_active"},
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("Expected %d issues, got %d: %v", len(expected), len(issues), issues)
+	}
+	for i := range expected {
+		if issues[i] != expected[i] {
+			t.Errorf("Issue %d: expected %+v, got %+v", i, expected[i], issues[i])
+		}
+	}
+}
+
+func TestIssueString(t *testing.T) {
+	issue := Issue{Kind: TypeMismatch, Table: "users", Column: "age", Expected: "bigint", Actual: "text"}
+	expected := "type mismatch on users.age: migrations have bigint, database has text"
+	if issue.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, issue.String())
+	}
+}
+
+func TestCompareMySQLBooleans(t *testing.T) {
+	builder := schema.NewSchemaBuilder()
+	builder.CreateTable("users").
+		AddColumnWithOptions("id", "bigint", false, true, false).
+		AddColumnWithOptions("active", "bool", false, false, false)
+
+	// inspectdb maps MySQL tinyint(1) columns to bool, and GORM creates booleans as tinyint(1)
+	tables := []inspectdb.Table{{
+		Name: "users",
+		Columns: []inspectdb.Column{
+			{Name: "id", DBType: "bigint", GoType: "int64", PK: true},
+			{Name: "active", DBType: "tinyint", GoType: "int8"},
+		},
+	}}
+	if issues := Compare(builder.Schema, tables, "mysql"); len(issues) != 0 {
+		t.Errorf("Expected tinyint to hold a bool on MySQL, got %v", issues)
+	}
+	if issues := Compare(builder.Schema, tables, "postgres"); len(issues) != 1 {
+		t.Errorf("Expected a type mismatch on PostgreSQL, got %v", issues)
+	}
+}
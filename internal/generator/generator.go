@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/pankajredekar/goosegorm/internal/dialect"
 	"github.com/pankajredekar/goosegorm/internal/diff"
 )
 
@@ -21,6 +22,12 @@ var (
 type Generator struct {
 	migrationsDir string
 	packageName   string
+
+	dependencies        []string
+	declareDependencies bool
+	replaces            []string
+
+	dialect dialect.Dialect
 }
 
 // GenerateMigrator generates migrator/main.go boilerplate
@@ -32,185 +39,9 @@ func GenerateMigrator(mainPkgPath, packageName, modulePath string) (string, erro
 	}
 
 	mainFile := filepath.Join(migratorDir, "main.go")
-	content := fmt.Sprintf(`package main
-
-import (
-	"fmt"
-	"log"
-	"os"
-	"strings"
-
-	"github.com/pankajredekar/goosegorm"
-	"gorm.io/driver/postgres"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
-	
-	// Import migrations to trigger their init() functions
-	_ "%s/%s"
-)
-
-func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: migrator <command>")
-		fmt.Println("Commands: migrate, rollback, show")
-		os.Exit(1)
-	}
-
-	command := os.Args[1]
-	configPath := "goosegorm.yml"
-	
-	// Simple config loading (inline to avoid internal package dependency)
-	type Config struct {
-		DatabaseURL    string
-		MigrationTable string
-	}
-	
-	configData, err := os.ReadFile(configPath)
-	if err != nil {
-		log.Fatalf("goosegorm.yml not found. Run 'goosegorm init' first")
-	}
-	
-	// Simple YAML parsing for database_url and migration_table
-	cfg := Config{
-		DatabaseURL:    "sqlite://:memory:",
-		MigrationTable: "_goosegorm_migrations",
-	}
-	lines := strings.Split(string(configData), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "database_url:") {
-			cfg.DatabaseURL = strings.TrimSpace(strings.TrimPrefix(line, "database_url:"))
-		} else if strings.HasPrefix(line, "migration_table:") {
-			cfg.MigrationTable = strings.TrimSpace(strings.TrimPrefix(line, "migration_table:"))
-		}
-	}
-
-	// Connect to database
-	db, err := connectDB(cfg.DatabaseURL)
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %%v", err)
-	}
-
-	// Initialize versioner using public API
-	ver := goosegorm.NewVersioner(db, cfg.MigrationTable)
-	if err := ver.Initialize(); err != nil {
-		log.Fatalf("Failed to initialize version table: %%v", err)
-	}
-
-	// Get the global registry (migrations register themselves via init())
-	registry := goosegorm.GetGlobalRegistry()
-
-	// Create runner using public API
-	run := goosegorm.NewRunner(db, registry, ver)
-
-	switch command {
-	case "migrate":
-		// Get pending migrations
-		pending, err := run.GetPendingMigrations()
-		if err != nil {
-			log.Fatalf("Failed to get pending migrations: %%v", err)
-		}
-
-		if len(pending) == 0 {
-			fmt.Println("No pending migrations")
-			return
-		}
-
-		fmt.Printf("Applying %%d migration(s)...\n", len(pending))
-
-		// Apply migrations
-		if err := run.Migrate(); err != nil {
-			log.Fatalf("Failed to apply migrations: %%v", err)
-		}
-
-		fmt.Printf("Applied %%d migration(s)\n", len(pending))
-
-	case "rollback":
-		n := 1
-		if len(os.Args) > 2 {
-			if _, err := fmt.Sscanf(os.Args[2], "%%d", &n); err != nil {
-				log.Fatalf("Invalid number: %%v", err)
-			}
-		}
-
-		// Get applied count
-		appliedCount, err := ver.GetAppliedCount()
-		if err != nil {
-			log.Fatalf("Failed to get applied count: %%v", err)
-		}
-
-		if appliedCount == 0 {
-			fmt.Println("No migrations to rollback")
-			return
-		}
-
-		if int64(n) > appliedCount {
-			n = int(appliedCount)
-		}
-
-		fmt.Printf("Rolling back %%d migration(s)...\n", n)
-
-		// Rollback
-		if err := run.Rollback(n); err != nil {
-			log.Fatalf("Failed to rollback: %%v", err)
-		}
-
-		fmt.Printf("Rolled back %%d migration(s)\n", n)
-
-	case "show":
-		// Get applied migrations
-		applied, err := run.GetAppliedMigrations()
-		if err != nil {
-			log.Fatalf("Failed to get applied migrations: %%v", err)
-		}
-
-		// Get pending migrations
-		pending, err := run.GetPendingMigrations()
-		if err != nil {
-			log.Fatalf("Failed to get pending migrations: %%v", err)
-		}
-
-		fmt.Println("\n" + strings.Repeat("=", 60))
-		fmt.Println("Migration Status")
-		fmt.Println(strings.Repeat("=", 60))
-
-		if len(applied) > 0 {
-			fmt.Println("\n✓ Applied Migrations:")
-			for _, m := range applied {
-				fmt.Printf("  %%s - %%s\n", m.Version(), m.Name())
-			}
-		} else {
-			fmt.Println("\n✓ Applied Migrations: (none)")
-		}
-
-		if len(pending) > 0 {
-			fmt.Println("\n○ Pending Migrations:")
-			for _, m := range pending {
-				fmt.Printf("  %%s - %%s\n", m.Version(), m.Name())
-			}
-		} else {
-			fmt.Println("\n○ Pending Migrations: (none)")
-		}
-
-		fmt.Println()
-
-	default:
-		fmt.Printf("Unknown command: %%s\n", command)
-		fmt.Println("Commands: migrate, rollback, show")
-		os.Exit(1)
-	}
-}
-
-func connectDB(databaseURL string) (*gorm.DB, error) {
-	if strings.Contains(databaseURL, "postgres://") || strings.Contains(databaseURL, "postgresql://") {
-		return gorm.Open(postgres.Open(databaseURL), &gorm.Config{})
-	} else if strings.Contains(databaseURL, "sqlite://") {
-		path := strings.TrimPrefix(databaseURL, "sqlite://")
-		return gorm.Open(sqlite.Open(path), &gorm.Config{})
-	}
-	return nil, fmt.Errorf("unsupported database URL: %%s", databaseURL)
-}
-`, modulePath, packageName)
+	// Checksums are not embedded here since the migrations directory isn't known;
+	// applied migrations are then recorded and verified without checksums
+	content := MigratorMainContent(fmt.Sprintf("%s/%s", modulePath, packageName), nil, nil, "", "", false)
 
 	if err := os.WriteFile(mainFile, []byte(content), 0644); err != nil {
 		return "", fmt.Errorf("failed to write migrator main.go: %w", err)
@@ -227,6 +58,26 @@ func NewGenerator(migrationsDir, packageName string) *Generator {
 	}
 }
 
+// SetDependencies makes generated migrations declare Dependencies() returning the given versions
+// (usually the current leaves of the migration graph). Without it no Dependencies() method is generated.
+func (g *Generator) SetDependencies(versions []string) {
+	g.dependencies = versions
+	g.declareDependencies = true
+}
+
+// SetDialect sets the database the real DB code of generated migrations is written for
+// (postgres, sqlite or mysql, see config.Dialect). Without a supported dialect, the SQL of
+// every dialect is generated and chosen at runtime from db.Dialector.Name().
+func (g *Generator) SetDialect(name string) {
+	g.dialect = dialect.For(name)
+}
+
+// SetReplaces makes generated migrations declare Replaces() returning the given versions,
+// marking them as a squash of those migrations
+func (g *Generator) SetReplaces(versions []string) {
+	g.replaces = versions
+}
+
 // GenerateMigration generates a migration file from diffs
 func (g *Generator) GenerateMigration(name string, diffs []diff.Diff) (string, error) {
 	if len(diffs) == 0 {
@@ -254,6 +105,17 @@ func (g *Generator) GenerateMigration(name string, diffs []diff.Diff) (string, e
 // GenerateEmptyMigration generates an empty migration file
 // If name is empty, uses Migration{version} format for struct and file name
 func (g *Generator) GenerateEmptyMigration(name string) (string, error) {
+	return g.writeHandWrittenMigration(name, g.generateEmptyMigrationContent)
+}
+
+// GenerateDataMigration generates an empty data migration file using RunGo/RunSQL
+// If name is empty, uses Migration{version} format for struct and file name
+func (g *Generator) GenerateDataMigration(name string) (string, error) {
+	return g.writeHandWrittenMigration(name, g.generateDataMigrationContent)
+}
+
+// writeHandWrittenMigration writes a migration file meant to be filled in by hand
+func (g *Generator) writeHandWrittenMigration(name string, content func(version, migrationName, structName string) string) (string, error) {
 	version := generateVersion()
 
 	// Determine migration name and struct name
@@ -281,9 +143,7 @@ func (g *Generator) GenerateEmptyMigration(name string) (string, error) {
 		return "", fmt.Errorf("failed to create migrations directory: %w", err)
 	}
 
-	content := g.generateEmptyMigrationContent(version, migrationName, structName)
-
-	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+	if err := os.WriteFile(filePath, []byte(content(version, migrationName, structName)), 0644); err != nil {
 		return "", fmt.Errorf("failed to write migration file: %w", err)
 	}
 
@@ -310,6 +170,9 @@ func (g *Generator) generateEmptyMigrationContent(version, migrationName, struct
 	// Name method
 	sb.WriteString(fmt.Sprintf("func (m %s) Name() string { return \"%s\" }\n\n", structName, migrationName))
 
+	// Dependencies method
+	sb.WriteString(g.generateDependencies(structName))
+
 	// Up method
 	sb.WriteString(fmt.Sprintf("func (m %s) Up(db *gorm.DB) error {\n", structName))
 	sb.WriteString("\tif sim, ok := any(db).(*goosegorm.SchemaBuilder); ok {\n")
@@ -355,15 +218,74 @@ func (g *Generator) generateEmptyMigrationContent(version, migrationName, struct
 	return sb.String()
 }
 
+// generateDataMigrationContent generates the content for an empty data migration file
+// The data change lives in a DataOperation, which simulation skips
+func (g *Generator) generateDataMigrationContent(version, migrationName, structName string) string {
+	var sb strings.Builder
+	dataVar := strings.ToLower(structName[:1]) + structName[1:] + "Data"
+
+	// Header
+	sb.WriteString(fmt.Sprintf("package %s\n\n", g.packageName))
+	sb.WriteString("import (\n")
+	sb.WriteString("\t\"gorm.io/gorm\"\n")
+	sb.WriteString("\t\"github.com/pankajredekar/goosegorm\"\n")
+	sb.WriteString(")\n\n")
+
+	// Migration struct
+	sb.WriteString(fmt.Sprintf("type %s struct{}\n\n", structName))
+
+	// Version method
+	sb.WriteString(fmt.Sprintf("func (m %s) Version() string { return \"%s\" }\n\n", structName, version))
+
+	// Name method
+	sb.WriteString(fmt.Sprintf("func (m %s) Name() string { return \"%s\" }\n\n", structName, migrationName))
+
+	// Dependencies method
+	sb.WriteString(g.generateDependencies(structName))
+
+	// Data operation
+	sb.WriteString(fmt.Sprintf("// %s is the data change made by this migration (skipped during simulation)\n", dataVar))
+	sb.WriteString("// For plain SQL use goosegorm.RunSQL(upSQL, downSQL) instead.\n")
+	sb.WriteString("// For large tables add .WithBatchSize(1000): the functions are then called until a call\n")
+	sb.WriteString("// processes fewer than batchSize rows.\n")
+	sb.WriteString(fmt.Sprintf("var %s = goosegorm.RunGo(\n", dataVar))
+	sb.WriteString("\tfunc(db *gorm.DB, batchSize int) (int64, error) {\n")
+	sb.WriteString("\t\t// TODO: Add your data migration logic here\n")
+	sb.WriteString("\t\t// Example:\n")
+	sb.WriteString("\t\t// result := db.Exec(\"UPDATE users SET status = ? WHERE status IS NULL\", \"active\")\n")
+	sb.WriteString("\t\t// return result.RowsAffected, result.Error\n")
+	sb.WriteString("\t\treturn 0, nil\n")
+	sb.WriteString("\t},\n")
+	sb.WriteString("\tfunc(db *gorm.DB, batchSize int) (int64, error) {\n")
+	sb.WriteString("\t\t// TODO: Reverse the data migration here (or pass nil if nothing needs to be undone)\n")
+	sb.WriteString("\t\treturn 0, nil\n")
+	sb.WriteString("\t},\n")
+	sb.WriteString(")\n\n")
+
+	// Up and Down methods
+	sb.WriteString(fmt.Sprintf("func (m %s) Up(db *gorm.DB) error { return %s.Up(db) }\n\n", structName, dataVar))
+	sb.WriteString(fmt.Sprintf("func (m %s) Down(db *gorm.DB) error { return %s.Down(db) }\n\n", structName, dataVar))
+
+	// Init function
+	sb.WriteString("func init() {\n")
+	sb.WriteString(fmt.Sprintf("\tgoosegorm.RegisterMigration(%s{})\n", structName))
+	sb.WriteString("}\n")
+
+	return sb.String()
+}
+
 func (g *Generator) generateMigrationContent(version, name string, diffs []diff.Diff) string {
 	var sb strings.Builder
+	upRealDB := g.generateUpRealDB(diffs)
+	downRealDB := g.generateDownRealDB(diffs)
 
 	// Header
 	sb.WriteString(fmt.Sprintf("package %s\n\n", g.packageName))
 	sb.WriteString("import (\n")
 	sb.WriteString("\t\"gorm.io/gorm\"\n")
 	sb.WriteString("\t\"github.com/pankajredekar/goosegorm\"\n")
-	if needsTimeImport(diffs) {
+	// Structs are only declared where the dialect's code uses GORM's migrator
+	if strings.Contains(upRealDB+downRealDB, "time.Time") {
 		sb.WriteString("\t\"time\"\n")
 	}
 	sb.WriteString(")\n\n")
@@ -378,26 +300,35 @@ func (g *Generator) generateMigrationContent(version, name string, diffs []diff.
 	// Name method
 	sb.WriteString(fmt.Sprintf("func (m %s) Name() string { return \"%s\" }\n\n", structName, name))
 
+	// Dependencies method
+	sb.WriteString(g.generateDependencies(structName))
+
+	// Replaces method
+	sb.WriteString(g.generateReplaces(structName))
+
 	// Up method
 	sb.WriteString(fmt.Sprintf("func (m %s) Up(db *gorm.DB) error {\n", structName))
 	sb.WriteString("\tif sim, ok := any(db).(*goosegorm.SchemaBuilder); ok {\n")
 	sb.WriteString(g.generateUpSimulation(diffs))
 	sb.WriteString("\t\treturn nil\n")
 	sb.WriteString("\t}\n\n")
-	upRealDB := g.generateUpRealDB(diffs)
 	sb.WriteString(upRealDB)
 	if !strings.Contains(upRealDB, "return ") {
 		sb.WriteString("\treturn nil\n")
 	}
 	sb.WriteString("}\n\n")
 
+	// Irreversible method
+	if isIrreversible(diffs) {
+		sb.WriteString(fmt.Sprintf("func (m %s) Irreversible() bool { return true }\n\n", structName))
+	}
+
 	// Down method
 	sb.WriteString(fmt.Sprintf("func (m %s) Down(db *gorm.DB) error {\n", structName))
 	sb.WriteString("\tif sim, ok := any(db).(*goosegorm.SchemaBuilder); ok {\n")
 	sb.WriteString(g.generateDownSimulation(diffs))
 	sb.WriteString("\t\treturn nil\n")
 	sb.WriteString("\t}\n\n")
-	downRealDB := g.generateDownRealDB(diffs)
 	sb.WriteString(downRealDB)
 	if !strings.Contains(downRealDB, "return ") {
 		sb.WriteString("\treturn nil\n")
@@ -412,6 +343,39 @@ func (g *Generator) generateMigrationContent(version, name string, diffs []diff.
 	return sb.String()
 }
 
+// generateReplaces generates the Replaces method of a squashed migration, if replaced versions were set
+func (g *Generator) generateReplaces(structName string) string {
+	if len(g.replaces) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("func (m %s) Replaces() []string {\n", structName))
+	sb.WriteString("\treturn []string{\n")
+	for _, version := range g.replaces {
+		sb.WriteString(fmt.Sprintf("\t\t%q,\n", version))
+	}
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+	return sb.String()
+}
+
+// generateDependencies generates the Dependencies method, if dependencies were set
+func (g *Generator) generateDependencies(structName string) string {
+	if !g.declareDependencies {
+		return ""
+	}
+	if len(g.dependencies) == 0 {
+		return fmt.Sprintf("func (m %s) Dependencies() []string { return nil }\n\n", structName)
+	}
+
+	quoted := make([]string, len(g.dependencies))
+	for i, version := range g.dependencies {
+		quoted[i] = fmt.Sprintf("%q", version)
+	}
+	return fmt.Sprintf("func (m %s) Dependencies() []string { return []string{%s} }\n\n", structName, strings.Join(quoted, ", "))
+}
+
 func (g *Generator) generateUpSimulation(diffs []diff.Diff) string {
 	var sb strings.Builder
 	sb.WriteString("\t\t// Simulation mode\n")
@@ -516,7 +480,7 @@ func (g *Generator) generateUpRealDB(diffs []diff.Diff) string {
 				for _, col := range d.Table.Columns {
 					fieldName := toPascalCase(col.Name)
 					goType := mapSQLTypeToGo(col.Type, col.PK)
-					gormTags := buildGormTags(col)
+					gormTags := buildGormTags(col, g.sizedStrings())
 					sb.WriteString(fmt.Sprintf("\t\t%s %s `%s`\n", fieldName, goType, gormTags))
 				}
 				sb.WriteString(fmt.Sprintf("\t}\n"))
@@ -537,7 +501,7 @@ func (g *Generator) generateUpRealDB(diffs []diff.Diff) string {
 			structKey := structName + "_" + fieldName
 			if !definedStructs[structKey] {
 				goType := mapSQLTypeToGo(d.Column.Type, d.Column.PK)
-				gormTags := buildGormTags(d.Column)
+				gormTags := buildGormTags(d.Column, g.sizedStrings())
 				sb.WriteString(fmt.Sprintf("\ttype %s%s struct {\n", structName, fieldName))
 				sb.WriteString(fmt.Sprintf("\t\t%s %s `%s`\n", fieldName, goType, gormTags))
 				sb.WriteString(fmt.Sprintf("\t}\n"))
@@ -555,45 +519,20 @@ func (g *Generator) generateUpRealDB(diffs []diff.Diff) string {
 		case "add_index":
 			// Create index using raw SQL
 			if d.Index != nil {
-				// Quote column names in index expression
-				quotedFields := make([]string, len(d.Index.Fields))
-				for i, field := range d.Index.Fields {
-					quotedFields[i] = quoteSQLIdentifier(field)
-				}
-				indexExpr := strings.Join(quotedFields, ", ")
-				quotedTableName := quoteSQLIdentifier(d.TableName)
-				uniqueStr := ""
-				if d.Index.Unique {
-					uniqueStr = "UNIQUE "
-				}
 				sb.WriteString(fmt.Sprintf("\t// Create index %s on %s (%s)\n", d.Index.Name, d.TableName, strings.Join(d.Index.Fields, ", ")))
-				// Escape quotes in SQL string for Go code generation
-				sqlStr := fmt.Sprintf("CREATE %sINDEX IF NOT EXISTS %s ON %s (%s)", uniqueStr, d.Index.Name, quotedTableName, indexExpr)
-				// Escape quotes for Go string literal
-				escapedSQL := strings.ReplaceAll(sqlStr, `"`, `\"`)
-				sb.WriteString(fmt.Sprintf("\tif err := db.Exec(\"%s\").Error; err != nil {\n", escapedSQL))
-				sb.WriteString(fmt.Sprintf("\t\treturn err\n"))
-				sb.WriteString(fmt.Sprintf("\t}\n"))
+				sb.WriteString(g.dialectCode(func(dl dialect.Dialect) string { return createIndexCode(dl, d.TableName, d.Index) }))
 			}
 		case "drop_index":
 			// Drop index using raw SQL
 			if d.Index != nil {
-				sb.WriteString(fmt.Sprintf("\tif err := db.Exec(\"DROP INDEX IF EXISTS %s\").Error; err != nil {\n", d.Index.Name))
-				sb.WriteString(fmt.Sprintf("\t\treturn err\n"))
-				sb.WriteString(fmt.Sprintf("\t}\n"))
+				sb.WriteString(g.dialectCode(func(dl dialect.Dialect) string { return dropIndexCode(dl, d.TableName, d.Index) }))
 			}
 		case "modify_column":
-			// Use AutoMigrate with updated struct
-			structName := toPascalCase(d.TableName)
-			fieldName := toPascalCase(d.Column.Name)
-			goType := mapSQLTypeToGo(d.Column.Type, d.Column.PK)
-			gormTags := buildGormTags(d.Column)
-			sb.WriteString(fmt.Sprintf("\ttype %s%s struct {\n", structName, fieldName))
-			sb.WriteString(fmt.Sprintf("\t\t%s %s `%s`\n", fieldName, goType, gormTags))
-			sb.WriteString(fmt.Sprintf("\t}\n"))
-			sb.WriteString(fmt.Sprintf("\tif err := db.Table(\"%s\").AutoMigrate(&%s%s{}); err != nil {\n", d.TableName, structName, fieldName))
-			sb.WriteString(fmt.Sprintf("\t\treturn err\n"))
-			sb.WriteString(fmt.Sprintf("\t}\n"))
+			// Alter the column in place, or rebuild the table with AutoMigrate where that's not supported
+			column := d.Column
+			sb.WriteString(g.dialectCode(func(dl dialect.Dialect) string {
+				return modifyColumnCode(dl, d.TableName, column, column.Type)
+			}))
 		}
 	}
 
@@ -604,10 +543,20 @@ func (g *Generator) generateUpRealDB(diffs []diff.Diff) string {
 
 func (g *Generator) generateDownRealDB(diffs []diff.Diff) string {
 	var sb strings.Builder
-	sb.WriteString("\t// Real DB mode - reverse operations\n")
 
-	// Track structs to avoid duplicates
-	definedStructs := make(map[string]bool)
+	// Dropped tables and columns and narrowed column types have lost their data, so refuse
+	// instead of recreating them empty
+	if isIrreversible(diffs) {
+		for _, d := range diffs {
+			if reason := irreversibleReason(d); reason != "" {
+				sb.WriteString(fmt.Sprintf("\t// Real DB mode - %s\n", reason))
+			}
+		}
+		sb.WriteString("\treturn goosegorm.ErrIrreversible\n")
+		return sb.String()
+	}
+
+	sb.WriteString("\t// Real DB mode - reverse operations\n")
 
 	for i := len(diffs) - 1; i >= 0; i-- {
 		d := diffs[i]
@@ -617,82 +566,27 @@ func (g *Generator) generateDownRealDB(diffs []diff.Diff) string {
 			sb.WriteString(fmt.Sprintf("\tif err := db.Migrator().DropTable(\"%s\"); err != nil {\n", d.TableName))
 			sb.WriteString(fmt.Sprintf("\t\treturn err\n"))
 			sb.WriteString(fmt.Sprintf("\t}\n"))
-		case "drop_table":
-			// Reverse: Recreate table (would need original structure, simplified for now)
-			sb.WriteString(fmt.Sprintf("\t// Note: Recreating table %s requires original structure\n", d.TableName))
-			sb.WriteString(fmt.Sprintf("\t// This is a simplified implementation - adjust as needed\n"))
-			structName := toPascalCase(d.TableName)
-			if !definedStructs[structName] {
-				sb.WriteString(fmt.Sprintf("\ttype %s struct {\n", structName))
-				sb.WriteString(fmt.Sprintf("\t\tID uint `gorm:\"primaryKey\"`\n"))
-				sb.WriteString(fmt.Sprintf("\t}\n"))
-				definedStructs[structName] = true
-			}
-			sb.WriteString(fmt.Sprintf("\tif err := db.Table(\"%s\").AutoMigrate(&%s{}); err != nil {\n", d.TableName, structName))
-			sb.WriteString(fmt.Sprintf("\t\treturn err\n"))
-			sb.WriteString(fmt.Sprintf("\t}\n"))
 		case "add_column":
 			// Reverse: Drop column
 			fieldName := toPascalCase(d.Column.Name)
 			sb.WriteString(fmt.Sprintf("\tif err := db.Migrator().DropColumn(\"%s\", \"%s\"); err != nil {\n", d.TableName, fieldName))
 			sb.WriteString(fmt.Sprintf("\t\treturn err\n"))
 			sb.WriteString(fmt.Sprintf("\t}\n"))
-		case "drop_column":
-			// Reverse: Add column back
-			structName := toPascalCase(d.TableName)
-			fieldName := toPascalCase(d.Column.Name)
-			structKey := structName + "_" + fieldName
-			if !definedStructs[structKey] {
-				goType := mapSQLTypeToGo(d.Column.Type, d.Column.PK)
-				gormTags := buildGormTags(d.Column)
-				sb.WriteString(fmt.Sprintf("\ttype %s%s struct {\n", structName, fieldName))
-				sb.WriteString(fmt.Sprintf("\t\t%s %s `%s`\n", fieldName, goType, gormTags))
-				sb.WriteString(fmt.Sprintf("\t}\n"))
-				definedStructs[structKey] = true
-			}
-			sb.WriteString(fmt.Sprintf("\tif err := db.Table(\"%s\").Migrator().AddColumn(&%s%s{}, \"%s\"); err != nil {\n", d.TableName, structName, fieldName, fieldName))
-			sb.WriteString(fmt.Sprintf("\t\treturn err\n"))
-			sb.WriteString(fmt.Sprintf("\t}\n"))
 		case "modify_column":
 			// Reverse: Revert to old type
-			structName := toPascalCase(d.TableName)
-			fieldName := toPascalCase(d.Column.Name)
-			goType := mapSQLTypeToGo(d.Column.OldType, d.Column.PK)
-			gormTags := buildGormTags(d.Column)
-			sb.WriteString(fmt.Sprintf("\ttype %s%s struct {\n", structName, fieldName))
-			sb.WriteString(fmt.Sprintf("\t\t%s %s `%s`\n", fieldName, goType, gormTags))
-			sb.WriteString(fmt.Sprintf("\t}\n"))
-			sb.WriteString(fmt.Sprintf("\tif err := db.Table(\"%s\").AutoMigrate(&%s%s{}); err != nil {\n", d.TableName, structName, fieldName))
-			sb.WriteString(fmt.Sprintf("\t\treturn err\n"))
-			sb.WriteString(fmt.Sprintf("\t}\n"))
+			column := d.Column
+			sb.WriteString(g.dialectCode(func(dl dialect.Dialect) string {
+				return modifyColumnCode(dl, d.TableName, column, column.OldType)
+			}))
 		case "add_index":
 			// Reverse: Drop index
 			if d.Index != nil {
-				sb.WriteString(fmt.Sprintf("\tif err := db.Exec(\"DROP INDEX IF EXISTS %s\").Error; err != nil {\n", d.Index.Name))
-				sb.WriteString(fmt.Sprintf("\t\treturn err\n"))
-				sb.WriteString(fmt.Sprintf("\t}\n"))
+				sb.WriteString(g.dialectCode(func(dl dialect.Dialect) string { return dropIndexCode(dl, d.TableName, d.Index) }))
 			}
 		case "drop_index":
 			// Reverse: Recreate index
 			if d.Index != nil {
-				// Quote column names in index expression
-				quotedFields := make([]string, len(d.Index.Fields))
-				for i, field := range d.Index.Fields {
-					quotedFields[i] = quoteSQLIdentifier(field)
-				}
-				indexExpr := strings.Join(quotedFields, ", ")
-				quotedTableName := quoteSQLIdentifier(d.TableName)
-				uniqueStr := ""
-				if d.Index.Unique {
-					uniqueStr = "UNIQUE "
-				}
-				// Escape quotes in SQL string for Go code generation
-				sqlStr := fmt.Sprintf("CREATE %sINDEX IF NOT EXISTS %s ON %s (%s)", uniqueStr, d.Index.Name, quotedTableName, indexExpr)
-				// Escape quotes for Go string literal
-				escapedSQL := strings.ReplaceAll(sqlStr, `"`, `\"`)
-				sb.WriteString(fmt.Sprintf("\tif err := db.Exec(\"%s\").Error; err != nil {\n", escapedSQL))
-				sb.WriteString(fmt.Sprintf("\t\treturn err\n"))
-				sb.WriteString(fmt.Sprintf("\t}\n"))
+				sb.WriteString(g.dialectCode(func(dl dialect.Dialect) string { return createIndexCode(dl, d.TableName, d.Index) }))
 			}
 		}
 	}
@@ -702,6 +596,50 @@ func (g *Generator) generateDownRealDB(diffs []diff.Diff) string {
 	return sb.String()
 }
 
+// isIrreversible reports whether the changes cannot be reversed by the generated Down method
+func isIrreversible(diffs []diff.Diff) bool {
+	for _, d := range diffs {
+		if irreversibleReason(d) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// irreversibleReason explains why a change cannot be reversed, or returns "" if it can
+func irreversibleReason(d diff.Diff) string {
+	switch {
+	case d.Type == "drop_table":
+		return fmt.Sprintf("table %s was dropped and cannot be recreated", d.TableName)
+	case d.Type == "drop_column":
+		return fmt.Sprintf("column %s.%s was dropped and its data cannot be restored", d.TableName, d.Column.Name)
+	case d.Type == "modify_column" && isLossyTypeChange(d.Column.OldType, d.Column.Type):
+		return fmt.Sprintf("column %s.%s was changed from %s to %s, which can lose data", d.TableName, d.Column.Name, d.Column.OldType, d.Column.Type)
+	}
+	return ""
+}
+
+// integerBits are the widths of the integer column types, bool being the narrowest
+var integerBits = map[string]int{"bool": 1, "tinyint": 8, "smallint": 16, "integer": 32, "bigint": 64}
+
+// isLossyTypeChange reports whether converting a column between the types can lose data:
+// narrowing an integer, converting a float or a string to a number, or converting anything
+// to a timestamp
+func isLossyTypeChange(from, to string) bool {
+	if from == to || to == "string" {
+		return false
+	}
+	fromBits, fromInteger := integerBits[from]
+	if toBits, ok := integerBits[to]; ok {
+		return !fromInteger || fromBits > toBits
+	}
+	if to == "float" {
+		// A float64 holds integers of up to 53 bits exactly
+		return !fromInteger || fromBits > 53
+	}
+	return true
+}
+
 func generateVersion() string {
 	versionMutex.Lock()
 	defer versionMutex.Unlock()
@@ -748,10 +686,112 @@ func toPascalCase(s string) string {
 	return toCamelCase(s)
 }
 
-// quoteSQLIdentifier quotes a SQL identifier for PostgreSQL (wraps in double quotes)
-// This is necessary for reserved keywords like "user", "order", etc.
-func quoteSQLIdentifier(identifier string) string {
-	return fmt.Sprintf("\"%s\"", identifier)
+// dialectCode returns the real DB code that code writes for the generator's dialect
+// Without a dialect, it returns a switch on db.Dialector.Name() choosing between the code of
+// every dialect, with PostgreSQL's as the default; dialects sharing its code get no case.
+func (g *Generator) dialectCode(code func(dialect.Dialect) string) string {
+	if g.dialect != nil {
+		return code(g.dialect)
+	}
+
+	dialects := dialect.All()
+	fallback := code(dialects[0])
+	var cases strings.Builder
+	for _, dl := range dialects[1:] {
+		if c := code(dl); c != fallback {
+			cases.WriteString(fmt.Sprintf("\tcase %q:\n", dl.Name()))
+			cases.WriteString(indentCode(c))
+		}
+	}
+	if cases.Len() == 0 {
+		return fallback
+	}
+	return "\tswitch db.Dialector.Name() {\n" + cases.String() + "\tdefault:\n" + indentCode(fallback) + "\t}\n"
+}
+
+// indentCode indents each line of generated code by one more tab
+func indentCode(code string) string {
+	lines := strings.SplitAfter(code, "\n")
+	for i, line := range lines {
+		if line != "" && line != "\n" {
+			lines[i] = "\t" + line
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// sizedStrings reports whether string columns need a size in some dialect the code is generated for
+func (g *Generator) sizedStrings() bool {
+	return g.dialect == nil || g.dialect.Features().SizedStrings
+}
+
+// createIndexCode returns the real DB code creating an index
+// Without IF NOT EXISTS, the index is looked up first.
+func createIndexCode(dl dialect.Dialect, tableName string, index *diff.IndexDiff) string {
+	sqlStr := dl.CreateIndex(tableName, index.Name, index.Unique, index.Fields)
+	if dl.Features().IndexIfExists {
+		return execCode(sqlStr, "")
+	}
+	return execCode(sqlStr, fmt.Sprintf("!db.Migrator().HasIndex(%q, %q)", tableName, index.Name))
+}
+
+// dropIndexCode returns the real DB code dropping an index
+// Without IF EXISTS, the index is looked up first.
+func dropIndexCode(dl dialect.Dialect, tableName string, index *diff.IndexDiff) string {
+	sqlStr := dl.DropIndex(tableName, index.Name)
+	if dl.Features().IndexIfExists {
+		return execCode(sqlStr, "")
+	}
+	return execCode(sqlStr, fmt.Sprintf("db.Migrator().HasIndex(%q, %q)", tableName, index.Name))
+}
+
+// modifyColumnCode returns the real DB code changing a column to columnType
+// Dialects that alter columns in place get ALTER statements, and a unique column the index
+// GORM would create for it; elsewhere AutoMigrate rebuilds the table with the column changed.
+func modifyColumnCode(dl dialect.Dialect, tableName string, col *diff.ColumnDiff, columnType string) string {
+	var sb strings.Builder
+	if dl.Features().AlterColumn {
+		for _, stmt := range dl.AlterColumn(tableName, col.Name, columnType, col.PK, col.Null) {
+			sb.WriteString(execCode(stmt, ""))
+		}
+		if col.Unique {
+			index := &diff.IndexDiff{Name: fmt.Sprintf("idx_%s_%s", tableName, col.Name), Unique: true, Fields: []string{col.Name}}
+			sb.WriteString(createIndexCode(dl, tableName, index))
+		}
+		return sb.String()
+	}
+
+	structName := toPascalCase(tableName)
+	fieldName := toPascalCase(col.Name)
+	goType := mapSQLTypeToGo(columnType, col.PK)
+	gormTags := buildGormTags(col, dl.Features().SizedStrings)
+	sb.WriteString(fmt.Sprintf("\ttype %s%s struct {\n", structName, fieldName))
+	sb.WriteString(fmt.Sprintf("\t\t%s %s `%s`\n", fieldName, goType, gormTags))
+	sb.WriteString("\t}\n")
+	sb.WriteString(fmt.Sprintf("\tif err := db.Table(\"%s\").AutoMigrate(&%s%s{}); err != nil {\n", tableName, structName, fieldName))
+	sb.WriteString("\t\treturn err\n")
+	sb.WriteString("\t}\n")
+	return sb.String()
+}
+
+// execCode returns the code executing a SQL statement and returning its error, run only if condition holds when one is given
+func execCode(sqlStr, condition string) string {
+	// Escape quotes for Go string literal
+	escapedSQL := strings.ReplaceAll(sqlStr, `"`, `\"`)
+
+	indent := "\t"
+	var sb strings.Builder
+	if condition != "" {
+		sb.WriteString(fmt.Sprintf("\tif %s {\n", condition))
+		indent = "\t\t"
+	}
+	sb.WriteString(fmt.Sprintf("%sif err := db.Exec(\"%s\").Error; err != nil {\n", indent, escapedSQL))
+	sb.WriteString(fmt.Sprintf("%s\treturn err\n", indent))
+	sb.WriteString(fmt.Sprintf("%s}\n", indent))
+	if condition != "" {
+		sb.WriteString("\t}\n")
+	}
+	return sb.String()
 }
 
 // mapSQLTypeToGo converts SQL type string to Go type
@@ -785,7 +825,8 @@ func mapSQLTypeToGo(sqlType string, isPK bool) string {
 }
 
 // buildGormTags builds GORM tags from column information
-func buildGormTags(col *diff.ColumnDiff) string {
+// sizedStrings gives string columns a size, for databases that can't index unsized ones.
+func buildGormTags(col *diff.ColumnDiff, sizedStrings bool) string {
 	var tags []string
 
 	if col.PK {
@@ -800,6 +841,11 @@ func buildGormTags(col *diff.ColumnDiff) string {
 		tags = append(tags, "not null")
 	}
 
+	// GORM makes unsized strings LONGTEXT on MySQL, which can't be indexed
+	if sizedStrings && col.Type == "string" {
+		tags = append(tags, "size:255")
+	}
+
 	if len(tags) == 0 {
 		return "gorm:\"\""
 	}
@@ -808,115 +854,7 @@ func buildGormTags(col *diff.ColumnDiff) string {
 }
 
 // buildGormTagsWithTableName builds GORM tags with table name specification
-func buildGormTagsWithTableName(col *diff.ColumnDiff, tableName string) string {
+func buildGormTagsWithTableName(col *diff.ColumnDiff, tableName string, sizedStrings bool) string {
 	// Table name is handled via db.Table() in AutoMigrate, not in tags
-	return buildGormTags(col)
-}
-
-// needsTimeImport checks if any diff requires time.Time type
-func needsTimeImport(diffs []diff.Diff) bool {
-	for _, d := range diffs {
-		if d.Table != nil {
-			for _, col := range d.Table.Columns {
-				if col.Type == "timestamp" {
-					return true
-				}
-			}
-		}
-		if d.Column != nil && d.Column.Type == "timestamp" {
-			return true
-		}
-	}
-	return false
-}
-
-// buildCreateTableSQL builds CREATE TABLE SQL statement
-func buildCreateTableSQL(tableName string, columns []*diff.ColumnDiff) string {
-	var parts []string
-	for _, col := range columns {
-		colDef := buildColumnDefinition(col)
-		parts = append(parts, colDef)
-	}
-	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n    %s\n)", tableName, strings.Join(parts, ",\n    "))
-}
-
-// buildColumnDefinition builds a column definition for SQL
-func buildColumnDefinition(col *diff.ColumnDiff) string {
-	var def strings.Builder
-
-	// Column name
-	def.WriteString(col.Name)
-	def.WriteString(" ")
-
-	// Column type
-	def.WriteString(mapSQLTypeToSQLType(col.Type, col.PK))
-
-	// Primary key
-	if col.PK {
-		def.WriteString(" PRIMARY KEY")
-	}
-
-	// NOT NULL
-	if !col.Null {
-		def.WriteString(" NOT NULL")
-	}
-
-	// Default value (if needed)
-	// Note: We don't have default value info in ColumnDiff, but can add it later
-
-	return def.String()
-}
-
-// mapSQLTypeToSQLType converts our internal SQL type to actual SQL type
-func mapSQLTypeToSQLType(sqlType string, isPK bool) string {
-	switch sqlType {
-	case "bigint":
-		if isPK {
-			return "BIGINT"
-		}
-		return "BIGINT"
-	case "integer":
-		return "INTEGER"
-	case "smallint":
-		return "SMALLINT"
-	case "tinyint":
-		return "TINYINT"
-	case "string":
-		return "VARCHAR(255)"
-	case "float":
-		return "DOUBLE PRECISION"
-	case "bool":
-		return "BOOLEAN"
-	case "timestamp":
-		return "TIMESTAMP"
-	default:
-		return "VARCHAR(255)"
-	}
-}
-
-// buildAddColumnSQL builds ALTER TABLE ADD COLUMN SQL
-func buildAddColumnSQL(tableName string, col *diff.ColumnDiff) string {
-	colDef := buildColumnDefinition(col)
-	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", tableName, colDef)
-}
-
-// buildDropColumnSQL builds ALTER TABLE DROP COLUMN SQL
-func buildDropColumnSQL(tableName, columnName string) string {
-	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", tableName, columnName)
-}
-
-// buildModifyColumnSQL builds ALTER TABLE ALTER COLUMN SQL
-func buildModifyColumnSQL(tableName string, col *diff.ColumnDiff) string {
-	// Note: SQL syntax varies by database, using PostgreSQL syntax
-	sqlType := mapSQLTypeToSQLType(col.Type, col.PK)
-	nullClause := ""
-	if !col.Null {
-		nullClause = " NOT NULL"
-	}
-	return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s%s", tableName, col.Name, sqlType, nullClause)
-}
-
-// buildDropTableSQL builds DROP TABLE SQL
-func buildDropTableSQL(tableName string) string {
-	return fmt.Sprintf("DROP TABLE IF EXISTS %s", tableName)
+	return buildGormTags(col, sizedStrings)
 }
@@ -2,6 +2,8 @@ package generator
 
 import (
 	"fmt"
+	"go/parser"
+	"go/token"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -326,12 +328,12 @@ func TestGenerateMigration_AddIndex(t *testing.T) {
 	}
 
 	// Check for index creation in real DB
-	if !strings.Contains(contentStr, "CREATE INDEX IF NOT EXISTS idx_email") {
+	if !strings.Contains(contentStr, "CREATE INDEX IF NOT EXISTS \\\"idx_email\\\"") {
 		t.Error("Migration should contain CREATE INDEX for real DB")
 	}
 
 	// Check for index drop in Down method
-	if !strings.Contains(contentStr, "DROP INDEX IF EXISTS idx_email") {
+	if !strings.Contains(contentStr, "DROP INDEX IF EXISTS \\\"idx_email\\\"") {
 		t.Error("Migration Down method should contain DROP INDEX")
 	}
 }
@@ -371,12 +373,12 @@ func TestGenerateMigration_DropIndex(t *testing.T) {
 	}
 
 	// Check for index drop in real DB
-	if !strings.Contains(contentStr, "DROP INDEX IF EXISTS idx_email") {
+	if !strings.Contains(contentStr, "DROP INDEX IF EXISTS \\\"idx_email\\\"") {
 		t.Error("Migration should contain DROP INDEX for real DB")
 	}
 
 	// Check for index recreation in Down method
-	if !strings.Contains(contentStr, "CREATE INDEX IF NOT EXISTS idx_email") {
+	if !strings.Contains(contentStr, "CREATE INDEX IF NOT EXISTS \\\"idx_email\\\"") {
 		t.Error("Migration Down method should contain CREATE INDEX")
 	}
 }
@@ -411,7 +413,7 @@ func TestGenerateMigration_UniqueIndex(t *testing.T) {
 	contentStr := string(content)
 
 	// Check for UNIQUE keyword in index creation
-	if !strings.Contains(contentStr, "CREATE UNIQUE INDEX IF NOT EXISTS idx_email_unique") {
+	if !strings.Contains(contentStr, "CREATE UNIQUE INDEX IF NOT EXISTS \\\"idx_email_unique\\\"") {
 		t.Error("Migration should contain CREATE UNIQUE INDEX for unique indexes")
 	}
 }
@@ -451,7 +453,7 @@ func TestGenerateMigration_CompositeIndex(t *testing.T) {
 	}
 
 	// Check for index creation
-	if !strings.Contains(contentStr, "CREATE INDEX IF NOT EXISTS idx_name_email") {
+	if !strings.Contains(contentStr, "CREATE INDEX IF NOT EXISTS \\\"idx_name_email\\\"") {
 		t.Error("Migration should contain CREATE INDEX for composite index")
 	}
 }
@@ -550,3 +552,409 @@ func TestGenerateEmptyMigration_WithoutName(t *testing.T) {
 		t.Error("Migration should register with Migration{version} struct")
 	}
 }
+
+func TestGenerateMigration_Dependencies(t *testing.T) {
+	tmpDir := t.TempDir()
+	gen := NewGenerator(tmpDir, "migrations")
+
+	filePath, err := gen.GenerateEmptyMigration("no_dependencies_declared")
+	if err != nil {
+		t.Fatalf("GenerateEmptyMigration failed: %v", err)
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read migration file: %v", err)
+	}
+	if strings.Contains(string(content), "Dependencies()") {
+		t.Error("Migration should not declare dependencies unless they were set")
+	}
+
+	gen.SetDependencies([]string{"20250101000000", "20250102000000"})
+	filePath, err = gen.GenerateEmptyMigration("merge_branches")
+	if err != nil {
+		t.Fatalf("GenerateEmptyMigration failed: %v", err)
+	}
+	content, err = os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read migration file: %v", err)
+	}
+	expected := `func (m MergeBranches) Dependencies() []string { return []string{"20250101000000", "20250102000000"} }`
+	if !strings.Contains(string(content), expected) {
+		t.Errorf("Migration should declare its dependencies, got:\n%s", content)
+	}
+
+	gen.SetDependencies(nil)
+	filePath, err = gen.GenerateEmptyMigration("initial")
+	if err != nil {
+		t.Fatalf("GenerateEmptyMigration failed: %v", err)
+	}
+	content, err = os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read migration file: %v", err)
+	}
+	if !strings.Contains(string(content), "func (m Initial) Dependencies() []string { return nil }") {
+		t.Error("First migration should declare that it has no dependencies")
+	}
+}
+
+func TestGenerateDataMigration(t *testing.T) {
+	tmpDir := t.TempDir()
+	migrationsDir := filepath.Join(tmpDir, "migrations")
+	gen := NewGenerator(migrationsDir, "migrations")
+	gen.SetDependencies([]string{"20250101000000"})
+
+	filePath, err := gen.GenerateDataMigration("backfill_user_status")
+	if err != nil {
+		t.Fatalf("GenerateDataMigration failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read migration file: %v", err)
+	}
+
+	contentStr := string(content)
+
+	if !strings.Contains(contentStr, "var backfillUserStatusData = goosegorm.RunGo(") {
+		t.Error("Migration should declare a RunGo data operation")
+	}
+	if !strings.Contains(contentStr, "func (m BackfillUserStatus) Up(db *gorm.DB) error { return backfillUserStatusData.Up(db) }") {
+		t.Error("Up should delegate to the data operation")
+	}
+	if !strings.Contains(contentStr, "func (m BackfillUserStatus) Down(db *gorm.DB) error { return backfillUserStatusData.Down(db) }") {
+		t.Error("Down should delegate to the data operation")
+	}
+	if !strings.Contains(contentStr, `return []string{"20250101000000"}`) {
+		t.Error("Migration should declare its dependencies")
+	}
+	if !strings.Contains(contentStr, "goosegorm.RegisterMigration(BackfillUserStatus{})") {
+		t.Error("Migration should register BackfillUserStatus")
+	}
+
+	if _, err := parser.ParseFile(token.NewFileSet(), filePath, content, 0); err != nil {
+		t.Errorf("Generated data migration is not valid Go: %v", err)
+	}
+}
+
+func TestGenerateMigration_DropTableIsIrreversible(t *testing.T) {
+	tmpDir := t.TempDir()
+	migrationsDir := filepath.Join(tmpDir, "migrations")
+	gen := NewGenerator(migrationsDir, "migrations")
+
+	diffs := []diff.Diff{
+		{
+			Type:      "drop_table",
+			TableName: "legacy_users",
+		},
+	}
+
+	filePath, err := gen.GenerateMigration("drop_legacy_users", diffs)
+	if err != nil {
+		t.Fatalf("GenerateMigration failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read migration file: %v", err)
+	}
+
+	contentStr := string(content)
+
+	if !strings.Contains(contentStr, "func (m DropLegacyUsers) Irreversible() bool { return true }") {
+		t.Error("Migration dropping a table should be marked irreversible")
+	}
+	if !strings.Contains(contentStr, "return goosegorm.ErrIrreversible") {
+		t.Error("Down should return goosegorm.ErrIrreversible")
+	}
+	if strings.Contains(contentStr, "AutoMigrate") {
+		t.Error("Down should not recreate a placeholder table")
+	}
+
+	if _, err := parser.ParseFile(token.NewFileSet(), filePath, content, 0); err != nil {
+		t.Errorf("Generated migration is not valid Go: %v", err)
+	}
+}
+
+func TestGenerateMigration_DropColumnIsIrreversible(t *testing.T) {
+	gen := NewGenerator(filepath.Join(t.TempDir(), "migrations"), "migrations")
+	diffs := []diff.Diff{
+		{
+			Type:      "drop_column",
+			TableName: "users",
+			Column:    &diff.ColumnDiff{Name: "nickname", Type: "string", Null: true},
+		},
+	}
+
+	filePath, err := gen.GenerateMigration("drop_users_nickname", diffs)
+	if err != nil {
+		t.Fatalf("GenerateMigration failed: %v", err)
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read migration file: %v", err)
+	}
+	contentStr := string(content)
+
+	if !strings.Contains(contentStr, "func (m DropUsersNickname) Irreversible() bool { return true }") {
+		t.Error("Migration dropping a column should be marked irreversible")
+	}
+	if !strings.Contains(contentStr, "// Real DB mode - column users.nickname was dropped and its data cannot be restored\n\treturn goosegorm.ErrIrreversible") {
+		t.Error("Down should return goosegorm.ErrIrreversible")
+	}
+	if strings.Contains(contentStr, "Migrator().AddColumn") {
+		t.Error("Down should not add back an empty column")
+	}
+
+	if _, err := parser.ParseFile(token.NewFileSet(), filePath, content, 0); err != nil {
+		t.Errorf("Generated migration is not valid Go: %v", err)
+	}
+}
+
+func TestGenerateMigration_LossyModifyColumnIsIrreversible(t *testing.T) {
+	cases := []struct{ from, to string }{
+		{"bigint", "integer"},
+		{"integer", "bool"},
+		{"float", "bigint"},
+		{"bigint", "float"},
+		{"string", "bigint"},
+		{"string", "timestamp"},
+	}
+	for _, c := range cases {
+		gen := NewGenerator(filepath.Join(t.TempDir(), "migrations"), "migrations")
+		diffs := []diff.Diff{
+			{
+				Type:      "modify_column",
+				TableName: "users",
+				Column:    &diff.ColumnDiff{Name: "age", Type: c.to, OldType: c.from},
+			},
+		}
+
+		filePath, err := gen.GenerateMigration("alter_users_age", diffs)
+		if err != nil {
+			t.Fatalf("GenerateMigration failed: %v", err)
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			t.Fatalf("Failed to read migration file: %v", err)
+		}
+		contentStr := string(content)
+
+		if !strings.Contains(contentStr, "func (m AlterUsersAge) Irreversible() bool { return true }") {
+			t.Errorf("Changing a column from %s to %s should be marked irreversible", c.from, c.to)
+		}
+		comment := fmt.Sprintf("// Real DB mode - column users.age was changed from %s to %s, which can lose data\n\treturn goosegorm.ErrIrreversible", c.from, c.to)
+		if !strings.Contains(contentStr, comment) {
+			t.Errorf("Down of a change from %s to %s should return goosegorm.ErrIrreversible", c.from, c.to)
+		}
+
+		if _, err := parser.ParseFile(token.NewFileSet(), filePath, content, 0); err != nil {
+			t.Errorf("Generated migration is not valid Go: %v", err)
+		}
+	}
+}
+
+func TestIsLossyTypeChange(t *testing.T) {
+	lossless := [][2]string{
+		{"integer", "bigint"}, {"tinyint", "smallint"}, {"bool", "tinyint"}, {"integer", "float"},
+		{"bigint", "string"}, {"timestamp", "string"}, {"float", "float"},
+	}
+	for _, c := range lossless {
+		if isLossyTypeChange(c[0], c[1]) {
+			t.Errorf("Changing %s to %s should not be lossy", c[0], c[1])
+		}
+	}
+	lossy := [][2]string{
+		{"bigint", "integer"}, {"smallint", "tinyint"}, {"tinyint", "bool"}, {"bigint", "float"},
+		{"float", "integer"}, {"string", "integer"}, {"bigint", "timestamp"}, {"timestamp", "bigint"},
+	}
+	for _, c := range lossy {
+		if !isLossyTypeChange(c[0], c[1]) {
+			t.Errorf("Changing %s to %s should be lossy", c[0], c[1])
+		}
+	}
+}
+
+func TestGenerateMigration_Replaces(t *testing.T) {
+	tmpDir := t.TempDir()
+	migrationsDir := filepath.Join(tmpDir, "migrations")
+	gen := NewGenerator(migrationsDir, "migrations")
+	gen.SetReplaces([]string{"20250101000000", "20250102000000"})
+
+	diffs := []diff.Diff{
+		{
+			Type:      "create_table",
+			TableName: "users",
+			Table: &diff.TableDiff{
+				Name: "users",
+				Columns: []*diff.ColumnDiff{
+					{Name: "id", Type: "bigint", PK: true},
+				},
+			},
+		},
+	}
+
+	filePath, err := gen.GenerateMigration("squashed_users", diffs)
+	if err != nil {
+		t.Fatalf("GenerateMigration failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read migration file: %v", err)
+	}
+
+	contentStr := string(content)
+
+	if !strings.Contains(contentStr, "func (m SquashedUsers) Replaces() []string {") {
+		t.Error("Squashed migration should declare the migrations it replaces")
+	}
+	if !strings.Contains(contentStr, "\"20250101000000\",\n\t\t\"20250102000000\",") {
+		t.Error("Replaces should list the replaced versions in order")
+	}
+
+	if _, err := parser.ParseFile(token.NewFileSet(), filePath, content, 0); err != nil {
+		t.Errorf("Generated migration is not valid Go: %v", err)
+	}
+}
+
+func TestGenerateMigration_MySQL(t *testing.T) {
+	tmpDir := t.TempDir()
+	migrationsDir := filepath.Join(tmpDir, "migrations")
+	gen := NewGenerator(migrationsDir, "migrations")
+	gen.SetDialect("mysql")
+
+	diffs := []diff.Diff{
+		{
+			Type:      "create_table",
+			TableName: "order",
+			Table: &diff.TableDiff{
+				Name: "order",
+				Columns: []*diff.ColumnDiff{
+					{Name: "id", Type: "bigint", PK: true},
+					{Name: "code", Type: "string", Unique: true},
+				},
+			},
+		},
+		{
+			Type:      "add_index",
+			TableName: "order",
+			Index:     &diff.IndexDiff{Name: "idx_order_code", Unique: true, Fields: []string{"code"}},
+		},
+		{
+			Type:      "drop_index",
+			TableName: "order",
+			Index:     &diff.IndexDiff{Name: "idx_order_old", Fields: []string{"code"}},
+		},
+	}
+
+	filePath, err := gen.GenerateMigration("create_order", diffs)
+	if err != nil {
+		t.Fatalf("GenerateMigration failed: %v", err)
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read migration file: %v", err)
+	}
+	contentStr := string(content)
+
+	fset := token.NewFileSet()
+	if _, err := parser.ParseFile(fset, filePath, content, parser.AllErrors); err != nil {
+		t.Fatalf("Generated migration is not valid Go: %v", err)
+	}
+
+	for _, want := range []string{
+		"if !db.Migrator().HasIndex(\"order\", \"idx_order_code\") {\n\t\tif err := db.Exec(\"CREATE UNIQUE INDEX `idx_order_code` ON `order` (`code`)\").Error; err != nil {",
+		"if db.Migrator().HasIndex(\"order\", \"idx_order_old\") {\n\t\tif err := db.Exec(\"DROP INDEX `idx_order_old` ON `order`\").Error; err != nil {",
+		"if db.Migrator().HasIndex(\"order\", \"idx_order_code\") {\n\t\tif err := db.Exec(\"DROP INDEX `idx_order_code` ON `order`\").Error; err != nil {",
+		"Code string `gorm:\"uniqueIndex;not null;size:255\"`",
+	} {
+		if !strings.Contains(contentStr, want) {
+			t.Errorf("MySQL migration should contain %s", want)
+		}
+	}
+	if strings.Contains(contentStr, "IF EXISTS") || strings.Contains(contentStr, "IF NOT EXISTS") || strings.Contains(contentStr, `\"`) {
+		t.Error("MySQL migration should not use PostgreSQL style index SQL")
+	}
+}
+
+func TestGenerateMigration_ModifyColumn(t *testing.T) {
+	diffs := []diff.Diff{
+		{
+			Type:      "modify_column",
+			TableName: "users",
+			Column:    &diff.ColumnDiff{Name: "age", Type: "bigint", OldType: "integer", Null: true},
+		},
+	}
+
+	generate := func(dialect string) string {
+		gen := NewGenerator(filepath.Join(t.TempDir(), "migrations"), "migrations")
+		gen.SetDialect(dialect)
+		filePath, err := gen.GenerateMigration("alter_users_age", diffs)
+		if err != nil {
+			t.Fatalf("GenerateMigration failed: %v", err)
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			t.Fatalf("Failed to read migration file: %v", err)
+		}
+		fset := token.NewFileSet()
+		if _, err := parser.ParseFile(fset, filePath, content, parser.AllErrors); err != nil {
+			t.Fatalf("Generated %s migration is not valid Go: %v", dialect, err)
+		}
+		return string(content)
+	}
+
+	postgres := generate("postgres")
+	if !strings.Contains(postgres, `ALTER TABLE \"users\" ALTER COLUMN \"age\" TYPE bigint USING \"age\"::bigint`) ||
+		!strings.Contains(postgres, `ALTER TABLE \"users\" ALTER COLUMN \"age\" TYPE integer USING \"age\"::integer`) {
+		t.Error("PostgreSQL migration should alter the column in Up and Down")
+	}
+	if strings.Contains(postgres, "Irreversible") {
+		t.Error("Widening a column should be reversible")
+	}
+	if strings.Contains(postgres, "AutoMigrate") || strings.Contains(postgres, "switch db.Dialector.Name()") {
+		t.Error("PostgreSQL migration should only contain PostgreSQL SQL")
+	}
+
+	// SQLite can't alter columns, so GORM rebuilds the table
+	sqlite := generate("sqlite")
+	if strings.Contains(sqlite, "ALTER COLUMN") || !strings.Contains(sqlite, "db.Table(\"users\").AutoMigrate(&UsersAge{})") {
+		t.Error("SQLite migration should rebuild the table with AutoMigrate")
+	}
+
+	// Without a dialect the SQL is chosen at runtime
+	runtime := generate("")
+	for _, want := range []string{
+		"\tswitch db.Dialector.Name() {\n\tcase \"sqlite\":\n\t\ttype UsersAge struct {",
+		"\tcase \"mysql\":\n\t\tif err := db.Exec(\"ALTER TABLE `users` MODIFY COLUMN `age` bigint NULL\").Error; err != nil {",
+		"\tdefault:\n\t\tif err := db.Exec(\"ALTER TABLE \\\"users\\\" ALTER COLUMN \\\"age\\\" TYPE bigint",
+	} {
+		if !strings.Contains(runtime, want) {
+			t.Errorf("Runtime switched migration should contain %q", want)
+		}
+	}
+}
+
+func TestGenerateMigration_RuntimeIndexes(t *testing.T) {
+	gen := NewGenerator(filepath.Join(t.TempDir(), "migrations"), "migrations")
+	diffs := []diff.Diff{
+		{Type: "add_index", TableName: "users", Index: &diff.IndexDiff{Name: "idx_email", Fields: []string{"email"}}},
+	}
+	filePath, err := gen.GenerateMigration("add_email_index", diffs)
+	if err != nil {
+		t.Fatalf("GenerateMigration failed: %v", err)
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read migration file: %v", err)
+	}
+	contentStr := string(content)
+
+	// SQLite shares PostgreSQL's index SQL, so only MySQL gets a case
+	if strings.Count(contentStr, "switch db.Dialector.Name()") != 2 || strings.Contains(contentStr, `case "sqlite"`) {
+		t.Error("Expected Up and Down to switch between MySQL and the default index SQL")
+	}
+	if !strings.Contains(contentStr, "\tcase \"mysql\":\n\t\tif !db.Migrator().HasIndex(\"users\", \"idx_email\") {") {
+		t.Error("Expected the MySQL case to check for the index first")
+	}
+}
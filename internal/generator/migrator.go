@@ -0,0 +1,828 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MigratorMainContent returns the main.go source for a migrator binary
+// The same source is used by the temporary migrator (migrate), the production
+// binary (build) and GenerateMigrator, so all of them accept the same commands and flags.
+// checksums (version -> source checksum) are embedded so applied migrations can be verified
+// without the migration sources being present, and createdTables (version -> tables the
+// migration creates) so --fake-initial works without simulating compiled migrations.
+// buildLabel, such as a git commit, is recorded with every migration the binary applies.
+// databaseName selects the database under databases: in goosegorm.yml whose settings the
+// migrator uses; the top-level settings are used for "default" or "".
+// withMySQL compiles in the MySQL driver, which the project then has to require; migrators
+// built without it refuse mysql:// URLs.
+func MigratorMainContent(migrationsImportPath string, checksums map[string]string, createdTables map[string][]string, buildLabel, databaseName string, withMySQL bool) string {
+	mysqlImports, mysqlConnect := "", mysqlUnsupported
+	if withMySQL {
+		mysqlImports, mysqlConnect = mysqlDriverImports, mysqlDriverConnect
+	}
+	return fmt.Sprintf(`package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pankajredekar/goosegorm"
+%s	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	// Import migrations to trigger their init() functions (which register migrations and hooks)
+	_ "%s"
+)
+
+// migrationChecksums are the checksums of the migration sources this migrator was built from
+var migrationChecksums = map[string]string{
+%s}
+
+// migrationTables are the tables each migration creates, used by migrate --fake-initial
+var migrationTables = map[string][]string{
+%s}
+
+// buildLabel identifies the build of this migrator in the migration history
+var buildLabel = %q
+
+// databaseName is the database in goosegorm.yml whose migrations this migrator runs
+var databaseName = %q
+
+// protectedEnvironment is set to the environment when goosegorm.yml marks it protected
+var protectedEnvironment string
+
+// assumeYes is set by --yes to skip confirmations
+var assumeYes bool
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println("Usage: goosegorm <command> [args...] [flags]")
+		fmt.Println("Commands: migrate, rollback, redo, reset, show, sqlmigrate, verify, force")
+		os.Exit(1)
+	}
+
+	command := os.Args[1]
+	configPath := "goosegorm.yml"
+
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	noLock := fs.Bool("no-lock", false, "Do not acquire the migration lock")
+	lockTimeoutFlag := fs.String("lock-timeout", "", "How long to wait for the migration lock (e.g. 30s)")
+	to := fs.String("to", "", "Target version to migrate or roll back to (\"zero\" unapplies everything)")
+	down := fs.Bool("down", false, "Show the SQL of the Down method (sqlmigrate)")
+	ignoreChecksums := fs.Bool("ignore-checksums", false, "Migrate even if applied migrations changed or are missing")
+	repair := fs.Bool("repair", false, "Re-stamp checksums of changed migrations (verify)")
+	allowOutOfOrder := fs.Bool("allow-out-of-order", false, "Apply pending migrations older than the latest applied one")
+	fake := fs.String("fake", "", "Record this migration as applied without running it (migrate)")
+	fakeInitial := fs.Bool("fake-initial", false, "Record create-table migrations as applied if their tables already exist (migrate)")
+	force := fs.Bool("force", false, "Skip irreversible migrations when rolling back, marking them as unapplied")
+	state := fs.String("state", "", "State to record for the migration: applied or unapplied (force)")
+	yes := fs.Bool("yes", false, "Do not ask for confirmation (reset)")
+	tenants := fs.Bool("tenants", false, "Run against every tenant schema (migrate, show)")
+	concurrency := fs.Int("concurrency", 0, "How many tenant schemas to migrate at once (overrides tenants.concurrency)")
+	continueOnError := fs.Bool("continue-on-error", false, "Keep migrating the other tenant schemas after one fails")
+	envFlag := fs.String("env", "", "Environment in goosegorm.yml whose overrides apply (default: $GOOSEGORM_ENV)")
+	args := parseArgs(fs, os.Args[2:])
+	assumeYes = *yes
+
+	environment := *envFlag
+	if environment == "" {
+		environment = os.Getenv("GOOSEGORM_ENV")
+	}
+
+	// Load the config as the CLI does, with the environment's overrides and environment variables
+	if _, err := os.Stat(configPath); err != nil {
+		log.Fatalf("goosegorm.yml not found. Run 'goosegorm init' first")
+	}
+	cfg, err := goosegorm.LoadConfig(configPath, environment, databaseName)
+	if err != nil {
+		log.Fatalf("Failed to load goosegorm.yml: %%v", err)
+	}
+	if cfg.DatabaseURL == "" {
+		log.Fatalf("database_url is not set in goosegorm.yml or GOOSEGORM_DATABASE_URL")
+	}
+	if cfg.Protected {
+		protectedEnvironment = cfg.Env
+	}
+	if *lockTimeoutFlag != "" {
+		cfg.LockTimeout = *lockTimeoutFlag
+	}
+
+	// Connect to database
+	db, err := connectDB(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %%v", err)
+	}
+
+	// Get the global registry (migrations register themselves via init())
+	registry := goosegorm.GetGlobalRegistry()
+	registry.SetChecksums(migrationChecksums)
+	registry.SetCreatedTables(migrationTables)
+
+	lockTimeout, err := goosegorm.ParseLockTimeout(cfg.LockTimeout)
+	if err != nil {
+		log.Fatalf("Invalid lock timeout: %%v", err)
+	}
+
+	var migrationTimeout time.Duration
+	if cfg.MigrationTimeout != "" {
+		migrationTimeout, err = time.ParseDuration(cfg.MigrationTimeout)
+		if err != nil {
+			log.Fatalf("Invalid migration timeout: %%v", err)
+		}
+	}
+
+	// newRunner sets up the versioner and runner of a database, or of a tenant schema of it
+	newRunner := func(db *gorm.DB, schema string) (*goosegorm.Versioner, *goosegorm.Runner, error) {
+		// Initialize versioner using public API
+		ver := goosegorm.NewVersioner(db, cfg.MigrationTable)
+		ver.SetExecutionInfo(goosegorm.CurrentExecutionInfo(buildLabel))
+		if err := ver.Initialize(); err != nil {
+			return nil, nil, err
+		}
+
+		// Create runner using public API
+		run := goosegorm.NewRunner(db, registry, ver)
+		run.SetAllowOutOfOrder(*allowOutOfOrder)
+		run.SetForce(*force)
+		run.SetFakeInitial(*fakeInitial)
+
+		// Attach hooks the project registered with goosegorm.RegisterHook/RegisterListener
+		goosegorm.AttachRegisteredHooks(run)
+
+		// Serialize migrate/rollback across processes unless disabled; tenants are locked separately
+		if !*noLock {
+			lockName := cfg.MigrationTable
+			if schema != "" {
+				lockName = schema + "." + cfg.MigrationTable
+			}
+			run.SetLocker(goosegorm.NewLocker(db, lockName), lockTimeout)
+		}
+
+		if migrationTimeout > 0 {
+			run.SetMigrationTimeout(migrationTimeout)
+		}
+		return ver, run, nil
+	}
+
+	// Stop between migrations on SIGINT/SIGTERM; the running migration is cancelled
+	// and, if transactional, rolled back
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Schema-per-tenant databases run the migrations against each tenant schema
+	if *tenants {
+		if command != "migrate" && command != "show" {
+			log.Fatalf("--tenants can only be used with migrate and show")
+		}
+		if *to != "" || *fake != "" {
+			log.Fatalf("--to and --fake cannot be used with --tenants")
+		}
+		if db.Dialector.Name() != "postgres" {
+			log.Fatalf("--tenants requires a PostgreSQL database")
+		}
+
+		schemas, err := goosegorm.TenantSchemas(db, cfg.Tenants.Schemas, cfg.Tenants.Query)
+		if err != nil {
+			log.Fatalf("Failed to list tenant schemas: %%v", err)
+		}
+		if len(schemas) == 0 {
+			log.Fatalf("No tenant schemas; set tenants.schemas or tenants.query in goosegorm.yml")
+		}
+
+		n := *concurrency
+		if n == 0 {
+			n = cfg.Tenants.Concurrency
+		}
+		if command == "show" {
+			showTenants(ctx, cfg.DatabaseURL, schemas, n, registry, newRunner)
+		} else {
+			migrateTenants(ctx, db, cfg.DatabaseURL, schemas, n, *continueOnError, !*ignoreChecksums, newRunner)
+		}
+		return
+	}
+
+	ver, run, err := newRunner(db, "")
+	if err != nil {
+		log.Fatalf("Failed to initialize version table: %%v", err)
+	}
+
+	switch command {
+	case "migrate":
+		if !*ignoreChecksums {
+			verifyChecksums(run)
+		}
+
+		if *fake != "" {
+			if err := run.Fake(*fake); err != nil {
+				log.Fatalf("Failed to fake migration: %%v", err)
+			}
+			fmt.Printf("Faked migration %%s\n", *fake)
+			return
+		}
+
+		if *fakeInitial {
+			fakes, err := run.GetFakeInitialMigrations()
+			if err != nil {
+				log.Fatalf("Failed to find initial migrations: %%v", err)
+			}
+			for _, m := range fakes {
+				fmt.Printf("Faking %%s_%%s (its tables already exist)\n", m.Version(), m.Name())
+			}
+		}
+
+		if *to != "" {
+			migrateTo(ctx, run, *to, false)
+			return
+		}
+
+		// Get pending migrations
+		pending, err := run.GetPendingMigrations()
+		if err != nil {
+			log.Fatalf("Failed to get pending migrations: %%v", err)
+		}
+
+		if len(pending) == 0 {
+			fmt.Println("No pending migrations")
+			return
+		}
+
+		fmt.Printf("Applying %%d migration(s)...\n", len(pending))
+
+		// Apply migrations
+		if err := run.MigrateContext(ctx); err != nil {
+			log.Fatalf("Failed to apply migrations: %%v", err)
+		}
+
+		fmt.Printf("Applied %%d migration(s)\n", len(pending))
+
+	case "rollback":
+		if *to != "" {
+			migrateTo(ctx, run, *to, true)
+			return
+		}
+
+		n := 1
+		if len(args) > 0 {
+			n, err = strconv.Atoi(args[0])
+			if err != nil {
+				log.Fatalf("Invalid number: %%v", err)
+			}
+		}
+
+		// Get applied count
+		appliedCount, err := ver.GetAppliedCount()
+		if err != nil {
+			log.Fatalf("Failed to get applied count: %%v", err)
+		}
+
+		if appliedCount == 0 {
+			fmt.Println("No migrations to rollback")
+			return
+		}
+
+		if int64(n) > appliedCount {
+			n = int(appliedCount)
+		}
+
+		confirm(fmt.Sprintf("This rolls back %%d migration(s).", n), false)
+		fmt.Printf("Rolling back %%d migration(s)...\n", n)
+
+		// Rollback
+		if err := run.RollbackContext(ctx, n); err != nil {
+			log.Fatalf("Failed to rollback: %%v", err)
+		}
+
+		fmt.Printf("Rolled back %%d migration(s)\n", n)
+
+	case "redo":
+		n := 1
+		if len(args) > 0 {
+			n, err = strconv.Atoi(args[0])
+			if err != nil {
+				log.Fatalf("Invalid number: %%v", err)
+			}
+		}
+
+		appliedCount, err := ver.GetAppliedCount()
+		if err != nil {
+			log.Fatalf("Failed to get applied count: %%v", err)
+		}
+		if appliedCount == 0 {
+			fmt.Println("No migrations to redo")
+			return
+		}
+		if int64(n) > appliedCount {
+			n = int(appliedCount)
+		}
+
+		confirm(fmt.Sprintf("This rolls back %%d migration(s) and applies them again.", n), false)
+		fmt.Printf("Redoing %%d migration(s)...\n", n)
+		if err := run.RedoContext(ctx, n); err != nil {
+			log.Fatalf("Failed to redo: %%v", err)
+		}
+		fmt.Printf("Redid %%d migration(s)\n", n)
+
+	case "reset":
+		confirm("This rolls back every applied migration, losing the data they hold, and applies all migrations again.", true)
+
+		fmt.Println("Resetting the database...")
+		if err := run.ResetContext(ctx); err != nil {
+			log.Fatalf("Failed to reset: %%v", err)
+		}
+		fmt.Println("Reset complete")
+
+	case "show":
+		// Get applied migrations
+		applied, err := run.GetAppliedMigrations()
+		if err != nil {
+			log.Fatalf("Failed to get applied migrations: %%v", err)
+		}
+
+		// Get pending migrations
+		pending, err := run.GetPendingMigrations()
+		if err != nil {
+			log.Fatalf("Failed to get pending migrations: %%v", err)
+		}
+
+		fmt.Println("\n" + strings.Repeat("=", 60))
+		fmt.Println("Migration Status")
+		fmt.Println(strings.Repeat("=", 60))
+
+		// Execution details of the applied migrations
+		records, err := ver.GetAppliedRecords()
+		if err != nil {
+			log.Fatalf("Failed to get migration history: %%v", err)
+		}
+		details := make(map[string]string)
+		for _, record := range records {
+			details[record.Version] = record.Details()
+		}
+
+		if len(applied) > 0 {
+			fmt.Println("\n✓ Applied Migrations:")
+			for _, m := range applied {
+				fmt.Printf("  %%s - %%s\n", m.Version(), m.Name())
+				if d, ok := details[m.Version()]; ok {
+					fmt.Printf("      %%s\n", d)
+				}
+			}
+		} else {
+			fmt.Println("\n✓ Applied Migrations: (none)")
+		}
+
+		// Migrations that failed part way through block migrate and rollback until forced
+		dirty, err := ver.GetDirtyRecords()
+		if err != nil {
+			log.Fatalf("Failed to get dirty migrations: %%v", err)
+		}
+		isDirty := make(map[string]bool)
+		for _, record := range dirty {
+			isDirty[record.Version] = true
+		}
+		if len(dirty) > 0 {
+			fmt.Println("\n✗ Dirty Migrations (failed part way through; fix the database and run 'force <version> --state applied|unapplied'):")
+			for _, record := range dirty {
+				fmt.Printf("  %%s - %%s\n", record.Version, record.Name)
+				fmt.Printf("      %%s\n", record.Details())
+			}
+		}
+
+		// Pending migrations older than the latest applied one need --allow-out-of-order
+		outOfOrder, err := run.GetOutOfOrderMigrations()
+		if err != nil {
+			log.Fatalf("Failed to check migration order: %%v", err)
+		}
+		older := make(map[string]bool)
+		for _, m := range outOfOrder {
+			older[m.Version()] = true
+		}
+
+		if len(pending) > 0 {
+			fmt.Println("\n○ Pending Migrations:")
+			for _, m := range pending {
+				if isDirty[m.Version()] {
+					fmt.Printf("  %%s - %%s (dirty)\n", m.Version(), m.Name())
+				} else if older[m.Version()] {
+					fmt.Printf("  %%s - %%s (out of order)\n", m.Version(), m.Name())
+				} else {
+					fmt.Printf("  %%s - %%s\n", m.Version(), m.Name())
+				}
+			}
+		} else {
+			fmt.Println("\n○ Pending Migrations: (none)")
+		}
+
+		fmt.Println()
+
+	case "sqlmigrate":
+		if len(args) == 0 {
+			log.Fatalf("Usage: sqlmigrate <version> [--down]")
+		}
+		version := args[0]
+
+		direction := goosegorm.DirectionUp
+		if *down {
+			direction = goosegorm.DirectionDown
+		}
+
+		statements, err := run.SQLFor(version, direction)
+		for _, stmt := range statements {
+			fmt.Println(strings.TrimRight(stmt, ";") + ";")
+		}
+		if err != nil {
+			log.Fatalf("Failed to render SQL: %%v", err)
+		}
+		if len(statements) == 0 {
+			fmt.Printf("-- %%s (%%s) executes no SQL against the database as it is now\n", version, direction)
+		}
+
+	case "verify":
+		issues, err := run.Verify()
+		if err != nil {
+			log.Fatalf("Failed to verify migrations: %%v", err)
+		}
+
+		if len(issues) == 0 {
+			fmt.Println("All applied migrations match their source files")
+			return
+		}
+
+		if !*repair {
+			printChecksumIssues(issues)
+			fmt.Println("Run 'verify --repair' to accept changed migrations")
+			os.Exit(1)
+		}
+
+		repaired, err := run.RepairChecksums()
+		if err != nil {
+			log.Fatalf("Failed to repair checksums: %%v", err)
+		}
+		for _, version := range repaired {
+			fmt.Printf("Re-stamped checksum of %%s\n", version)
+		}
+
+		var missing []goosegorm.ChecksumIssue
+		for _, issue := range issues {
+			if issue.Problem == goosegorm.ChecksumMissing {
+				missing = append(missing, issue)
+			}
+		}
+		if len(missing) > 0 {
+			printChecksumIssues(missing)
+			os.Exit(1)
+		}
+
+	case "force":
+		if len(args) == 0 || (*state != "applied" && *state != "unapplied") {
+			log.Fatalf("Usage: force <version> --state applied|unapplied")
+		}
+		version := args[0]
+
+		if err := run.ForceState(version, *state == "applied"); err != nil {
+			log.Fatalf("Failed to force migration state: %%v", err)
+		}
+		fmt.Printf("Marked %%s as %%s\n", version, *state)
+
+	default:
+		fmt.Printf("Unknown command: %%s\n", command)
+		fmt.Println("Commands: migrate, rollback, redo, reset, show, sqlmigrate, verify, force")
+		os.Exit(1)
+	}
+}
+
+// verifyChecksums refuses to continue if applied migrations changed or went missing
+func verifyChecksums(run *goosegorm.Runner) {
+	issues, err := run.Verify()
+	if err != nil {
+		log.Fatalf("Failed to verify migrations: %%v", err)
+	}
+	if len(issues) == 0 {
+		return
+	}
+
+	printChecksumIssues(issues)
+	log.Fatalf("Refusing to migrate. Review the changes and run 'verify --repair' to accept them, or pass --ignore-checksums")
+}
+
+func printChecksumIssues(issues []goosegorm.ChecksumIssue) {
+	fmt.Println("Applied migrations do not match their source files:")
+	for _, issue := range issues {
+		fmt.Printf("  %%s\n", issue)
+	}
+}
+
+// migrateTo moves the database to the target version, only rolling back when rollbackOnly is set
+func migrateTo(ctx context.Context, run *goosegorm.Runner, target string, rollbackOnly bool) {
+	plan, err := run.PlanTo(target)
+	if err != nil {
+		log.Fatalf("Failed to plan migrations to %%s: %%v", target, err)
+	}
+
+	if len(plan.Migrations) == 0 {
+		fmt.Printf("Already at %%s\n", target)
+		return
+	}
+
+	if plan.Direction == goosegorm.DirectionUp {
+		if rollbackOnly {
+			log.Fatalf("%%s is ahead of the applied migrations; use migrate --to to apply it", target)
+		}
+		fmt.Printf("Applying %%d migration(s) to reach %%s...\n", len(plan.Migrations), target)
+	} else {
+		confirm(fmt.Sprintf("This rolls back %%d migration(s) to reach %%s.", len(plan.Migrations), target), false)
+		fmt.Printf("Rolling back %%d migration(s) to reach %%s...\n", len(plan.Migrations), target)
+	}
+
+	if rollbackOnly {
+		err = run.RollbackToContext(ctx, target)
+	} else {
+		err = run.MigrateToContext(ctx, target)
+	}
+	if err != nil {
+		log.Fatalf("Failed to migrate to %%s: %%v", target, err)
+	}
+
+	fmt.Printf("Now at %%s\n", target)
+}
+
+// confirm asks the operator to confirm a destructive command, exiting unless they do
+// Unless always is set, only protected environments ask. --yes skips the question.
+func confirm(warning string, always bool) {
+	if assumeYes || (!always && protectedEnvironment == "") {
+		return
+	}
+	if protectedEnvironment != "" {
+		warning = fmt.Sprintf("Environment %%s is protected. %%s", protectedEnvironment, warning)
+	}
+
+	fmt.Printf("%%s Continue? [y/N] ", warning)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer != "y" && answer != "yes" {
+		fmt.Println("Cancelled")
+		os.Exit(1)
+	}
+}
+
+// runnerFactory sets up the versioner and runner of a database, or of a tenant schema of it
+type runnerFactory func(db *gorm.DB, schema string) (*goosegorm.Versioner, *goosegorm.Runner, error)
+
+// connectTenant connects to the database with the tenant schema as the search_path
+// The returned function closes the connections.
+func connectTenant(databaseURL, schema string) (*gorm.DB, func(), error) {
+	tenantURL, err := goosegorm.TenantURL(databaseURL, schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := connectDB(tenantURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect: %%w", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, func() { sqlDB.Close() }, nil
+}
+
+// migrateTenants applies the pending migrations to each tenant schema, each with its own migration table
+func migrateTenants(ctx context.Context, db *gorm.DB, databaseURL string, schemas []string, concurrency int, continueOnError, checkChecksums bool, newRunner runnerFactory) {
+	if concurrency <= 0 {
+		concurrency = goosegorm.DefaultTenantConcurrency
+	}
+	fmt.Printf("Migrating %%d tenant schema(s), %%d at a time...\n", len(schemas), concurrency)
+
+	results := goosegorm.RunTenants(ctx, schemas, concurrency, continueOnError, func(ctx context.Context, schema string) (int, error) {
+		if err := goosegorm.EnsureTenantSchema(db, schema); err != nil {
+			return 0, err
+		}
+		tenantDB, closeDB, err := connectTenant(databaseURL, schema)
+		if err != nil {
+			return 0, err
+		}
+		defer closeDB()
+
+		_, run, err := newRunner(tenantDB, schema)
+		if err != nil {
+			return 0, fmt.Errorf("failed to initialize version table: %%w", err)
+		}
+
+		if checkChecksums {
+			issues, err := run.Verify()
+			if err != nil {
+				return 0, fmt.Errorf("failed to verify migrations: %%w", err)
+			}
+			if len(issues) > 0 {
+				return 0, fmt.Errorf("%%d applied migration(s) do not match their source files; pass --ignore-checksums to migrate anyway", len(issues))
+			}
+		}
+
+		pending, err := run.GetPendingMigrations()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get pending migrations: %%w", err)
+		}
+		if err := run.MigrateContext(ctx); err != nil {
+			return 0, err
+		}
+		return len(pending), nil
+	})
+
+	var failed, skipped int
+	for _, result := range results {
+		switch {
+		case result.Skipped:
+			skipped++
+			fmt.Printf("  - %%s: skipped\n", result.Schema)
+		case result.Err != nil:
+			failed++
+			fmt.Printf("  ✗ %%s: %%v\n", result.Schema, result.Err)
+		case result.Applied == 0:
+			fmt.Printf("  ✓ %%s: no pending migrations\n", result.Schema)
+		default:
+			fmt.Printf("  ✓ %%s: applied %%d migration(s) in %%s\n", result.Schema, result.Applied, result.Duration.Round(time.Millisecond))
+		}
+	}
+
+	fmt.Printf("Tenants: %%d succeeded, %%d failed, %%d skipped\n", len(results)-failed-skipped, failed, skipped)
+	if failed > 0 || skipped > 0 {
+		os.Exit(1)
+	}
+}
+
+// showTenants prints which migrations are applied in each tenant schema
+func showTenants(ctx context.Context, databaseURL string, schemas []string, concurrency int, registry *goosegorm.Registry, newRunner runnerFactory) {
+	migrations, err := registry.GetAllMigrations()
+	if err != nil {
+		log.Fatalf("Failed to order migrations: %%v", err)
+	}
+
+	var mu sync.Mutex
+	status := make(map[string]map[string]string) // schema -> version -> mark
+
+	results := goosegorm.RunTenants(ctx, schemas, concurrency, true, func(ctx context.Context, schema string) (int, error) {
+		tenantDB, closeDB, err := connectTenant(databaseURL, schema)
+		if err != nil {
+			return 0, err
+		}
+		defer closeDB()
+
+		ver, _, err := newRunner(tenantDB, schema)
+		if err != nil {
+			return 0, fmt.Errorf("failed to initialize version table: %%w", err)
+		}
+		applied, err := ver.GetAppliedVersions()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get applied migrations: %%w", err)
+		}
+		dirty, err := ver.GetDirtyRecords()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get dirty migrations: %%w", err)
+		}
+
+		marks := make(map[string]string)
+		for _, version := range applied {
+			marks[version] = "✓"
+		}
+		for _, record := range dirty {
+			marks[record.Version] = "✗"
+		}
+		mu.Lock()
+		status[schema] = marks
+		mu.Unlock()
+		return 0, nil
+	})
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	fmt.Printf("Migration Status (%%d tenant schemas)\n", len(schemas))
+	fmt.Println(strings.Repeat("=", 60))
+	fmt.Println("✓ applied  ○ pending  ✗ dirty  ? unknown")
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Version\t%%s\tName\n", strings.Join(schemas, "\t"))
+	for _, m := range migrations {
+		cells := make([]string, len(schemas))
+		for i, schema := range schemas {
+			marks, ok := status[schema]
+			switch {
+			case !ok:
+				cells[i] = "?"
+			case marks[m.Version()] != "":
+				cells[i] = marks[m.Version()]
+			default:
+				cells[i] = "○"
+			}
+		}
+		fmt.Fprintf(w, "%%s\t%%s\t%%s\n", m.Version(), strings.Join(cells, "\t"), m.Name())
+	}
+	w.Flush()
+
+	failed := false
+	for _, result := range results {
+		if result.Err != nil {
+			if !failed {
+				fmt.Println()
+			}
+			failed = true
+			fmt.Printf("✗ %%s: %%v\n", result.Schema, result.Err)
+		}
+	}
+	fmt.Println()
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// parseArgs parses flags that may appear before or after positional arguments
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func connectDB(databaseURL string) (*gorm.DB, error) {
+	if strings.Contains(databaseURL, "postgres://") || strings.Contains(databaseURL, "postgresql://") {
+		return gorm.Open(postgres.Open(databaseURL), &gorm.Config{})
+	} else if strings.Contains(databaseURL, "sqlite://") {
+		path := strings.TrimPrefix(databaseURL, "sqlite://")
+		return gorm.Open(sqlite.Open(path), &gorm.Config{})
+	} else if strings.Contains(databaseURL, "mysql://") {
+%s	}
+	return nil, fmt.Errorf("unsupported database URL: %%s", databaseURL)
+}
+`, mysqlImports, migrationsImportPath, checksumEntries(checksums), createdTableEntries(createdTables), buildLabel, databaseName, mysqlConnect)
+}
+
+// mysqlDriverImports are the imports of a migrator built with MySQL support
+const mysqlDriverImports = "\t\"github.com/pankajredekar/goosegorm/mysqldsn\"\n\t\"gorm.io/driver/mysql\"\n"
+
+// mysqlDriverConnect opens a mysql:// URL in a migrator built with MySQL support
+const mysqlDriverConnect = `		dsn, err := mysqldsn.FromURL(databaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return gorm.Open(mysql.Open(dsn), &gorm.Config{})
+`
+
+// mysqlUnsupported rejects mysql:// URLs in a migrator built without MySQL support
+const mysqlUnsupported = `		return nil, fmt.Errorf("this migrator was built without MySQL support; set a mysql:// database_url in goosegorm.yml and rebuild it")
+`
+
+// createdTableEntries renders the entries of the embedded created tables map, sorted by version
+func createdTableEntries(createdTables map[string][]string) string {
+	versions := make([]string, 0, len(createdTables))
+	for version := range createdTables {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+
+	var sb strings.Builder
+	for _, version := range versions {
+		tables := createdTables[version]
+		if len(tables) == 0 {
+			continue
+		}
+		quoted := make([]string, len(tables))
+		for i, table := range tables {
+			quoted[i] = fmt.Sprintf("%q", table)
+		}
+		sb.WriteString(fmt.Sprintf("\t%q: {%s},\n", version, strings.Join(quoted, ", ")))
+	}
+	return sb.String()
+}
+
+// checksumEntries renders the entries of the embedded checksum map, sorted by version
+func checksumEntries(checksums map[string]string) string {
+	versions := make([]string, 0, len(checksums))
+	for version := range checksums {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+
+	var sb strings.Builder
+	for _, version := range versions {
+		sb.WriteString(fmt.Sprintf("\t%q: %q,\n", version, checksums[version]))
+	}
+	return sb.String()
+}
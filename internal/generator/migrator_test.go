@@ -0,0 +1,203 @@
+package generator
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestMigratorMainContent_ParsesAsGo(t *testing.T) {
+	content := MigratorMainContent("example.com/app/migrations", nil, nil, "", "", false)
+
+	fset := token.NewFileSet()
+	if _, err := parser.ParseFile(fset, "main.go", content, parser.AllErrors); err != nil {
+		t.Fatalf("Generated migrator is not valid Go: %v", err)
+	}
+
+	if !strings.Contains(content, `_ "example.com/app/migrations"`) {
+		t.Error("Generated migrator should import the migrations package")
+	}
+}
+
+func TestMigratorMainContent_LockFlags(t *testing.T) {
+	content := MigratorMainContent("example.com/app/migrations", nil, nil, "", "", false)
+
+	for _, want := range []string{`"no-lock"`, `"lock-timeout"`, "goosegorm.ParseLockTimeout(cfg.LockTimeout)", "run.SetLocker("} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Generated migrator should contain %s", want)
+		}
+	}
+}
+
+func TestMigratorMainContent_EmbedsChecksums(t *testing.T) {
+	content := MigratorMainContent("example.com/app/migrations", map[string]string{
+		"20250102000000": "bbb",
+		"20250101000000": "aaa",
+	}, nil, "", "", false)
+
+	fset := token.NewFileSet()
+	if _, err := parser.ParseFile(fset, "main.go", content, parser.AllErrors); err != nil {
+		t.Fatalf("Generated migrator is not valid Go: %v", err)
+	}
+
+	first := strings.Index(content, `"20250101000000": "aaa",`)
+	second := strings.Index(content, `"20250102000000": "bbb",`)
+	if first < 0 || second < 0 {
+		t.Fatal("Generated migrator should embed the checksums")
+	}
+	if first > second {
+		t.Error("Checksums should be sorted by version")
+	}
+	if !strings.Contains(content, "registry.SetChecksums(migrationChecksums)") {
+		t.Error("Generated migrator should register the checksums")
+	}
+}
+
+func TestMigratorMainContent_Cancellation(t *testing.T) {
+	content := MigratorMainContent("example.com/app/migrations", nil, nil, "", "", false)
+
+	for _, want := range []string{"time.ParseDuration(cfg.MigrationTimeout)", "run.SetMigrationTimeout(", "signal.NotifyContext(", "run.MigrateContext(ctx)", "run.RollbackContext(ctx, n)"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Generated migrator should contain %s", want)
+		}
+	}
+}
+
+func TestMigratorMainContent_AttachesHooks(t *testing.T) {
+	content := MigratorMainContent("example.com/app/migrations", nil, nil, "", "", false)
+
+	if !strings.Contains(content, "goosegorm.AttachRegisteredHooks(run)") {
+		t.Error("Generated migrator should attach registered hooks")
+	}
+}
+
+func TestMigratorMainContent_EmbedsCreatedTables(t *testing.T) {
+	content := MigratorMainContent("example.com/app/migrations", nil, map[string][]string{
+		"20250102000000": {"orders"},
+		"20250101000000": {"category", "product"},
+		"20250103000000": nil,
+	}, "", "", false)
+
+	fset := token.NewFileSet()
+	if _, err := parser.ParseFile(fset, "main.go", content, parser.AllErrors); err != nil {
+		t.Fatalf("Generated migrator is not valid Go: %v", err)
+	}
+
+	first := strings.Index(content, `"20250101000000": {"category", "product"},`)
+	second := strings.Index(content, `"20250102000000": {"orders"},`)
+	if first == -1 || second == -1 || first > second {
+		t.Errorf("Expected created tables embedded in version order:\n%s", content)
+	}
+	if strings.Contains(content, `"20250103000000"`) {
+		t.Error("Migrations that create no tables should be left out")
+	}
+	if !strings.Contains(content, "registry.SetCreatedTables(migrationTables)") {
+		t.Error("Migrator should register the created tables")
+	}
+	if !strings.Contains(content, "run.SetFakeInitial(*fakeInitial)") {
+		t.Error("Migrator should support --fake-initial")
+	}
+}
+
+func TestMigratorMainContent_EmbedsBuildLabel(t *testing.T) {
+	content := MigratorMainContent("example.com/app/migrations", nil, nil, "abc1234", "", false)
+
+	if !strings.Contains(content, `var buildLabel = "abc1234"`) {
+		t.Error("Generated migrator should embed the build label")
+	}
+	if !strings.Contains(content, "ver.SetExecutionInfo(goosegorm.CurrentExecutionInfo(buildLabel))") {
+		t.Error("Generated migrator should record the build label with applied migrations")
+	}
+}
+
+func TestMigratorMainContent_Force(t *testing.T) {
+	content := MigratorMainContent("example.com/app/migrations", nil, nil, "", "", false)
+
+	for _, want := range []string{`case "force":`, "run.ForceState(version, *state == \"applied\")", "ver.GetDirtyRecords()"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Generated migrator should contain %s", want)
+		}
+	}
+}
+
+func TestMigratorMainContent_RedoReset(t *testing.T) {
+	content := MigratorMainContent("example.com/app/migrations", nil, nil, "", "", false)
+
+	for _, want := range []string{`case "redo":`, "run.RedoContext(ctx, n)", `case "reset":`, "losing the data they hold, and applies all migrations again.\", true)", "run.ResetContext(ctx)"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Generated migrator should contain %s", want)
+		}
+	}
+}
+
+func TestMigratorMainContent_EmbedsDatabaseName(t *testing.T) {
+	content := MigratorMainContent("example.com/app/migrations", nil, nil, "", "analytics", false)
+
+	fset := token.NewFileSet()
+	if _, err := parser.ParseFile(fset, "main.go", content, parser.AllErrors); err != nil {
+		t.Fatalf("Generated migrator is not valid Go: %v", err)
+	}
+	if !strings.Contains(content, `var databaseName = "analytics"`) {
+		t.Error("Generated migrator should embed the database name")
+	}
+	if !strings.Contains(content, "goosegorm.LoadConfig(configPath, environment, databaseName)") {
+		t.Error("Generated migrator should load the settings of its database")
+	}
+}
+
+func TestMigratorMainContent_Tenants(t *testing.T) {
+	content := MigratorMainContent("example.com/app/migrations", nil, nil, "", "", false)
+
+	for _, want := range []string{`"tenants"`, `"concurrency"`, `"continue-on-error"`, "cfg.Tenants.Schemas", "goosegorm.TenantSchemas(", "goosegorm.RunTenants(", "showTenants(ctx,"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Generated migrator should contain %s", want)
+		}
+	}
+}
+
+func TestMigratorMainContent_Environments(t *testing.T) {
+	content := MigratorMainContent("example.com/app/migrations", nil, nil, "", "", false)
+
+	for _, want := range []string{`"env"`, `os.Getenv("GOOSEGORM_ENV")`, "if cfg.Protected {", "func confirm(warning string, always bool) {"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Generated migrator should contain %s", want)
+		}
+	}
+	// Rolling back asks for confirmation in protected environments
+	if strings.Count(content, "confirm(fmt.Sprintf(") != 3 {
+		t.Error("Expected rollback, redo and migrate --to backwards to ask for confirmation")
+	}
+}
+
+func TestMigratorMainContent_LoadsConfigLikeTheCLI(t *testing.T) {
+	content := MigratorMainContent("example.com/app/migrations", nil, nil, "", "", false)
+
+	if !strings.Contains(content, "goosegorm.LoadConfig(configPath, environment, databaseName)") {
+		t.Error("Generated migrator should load goosegorm.yml with goosegorm.LoadConfig")
+	}
+	if strings.Contains(content, "strings.Split(string(configData)") {
+		t.Error("Generated migrator should not parse goosegorm.yml line by line")
+	}
+}
+
+func TestMigratorMainContent_MySQL(t *testing.T) {
+	content := MigratorMainContent("example.com/app/migrations", nil, nil, "", "", true)
+	if _, err := parser.ParseFile(token.NewFileSet(), "main.go", content, parser.AllErrors); err != nil {
+		t.Fatalf("Generated migrator is not valid Go: %v", err)
+	}
+	for _, want := range []string{`"gorm.io/driver/mysql"`, `"github.com/pankajredekar/goosegorm/mysqldsn"`, "mysqldsn.FromURL(databaseURL)", "gorm.Open(mysql.Open(dsn), &gorm.Config{})"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Generated migrator should contain %s", want)
+		}
+	}
+
+	// Projects without MySQL don't have to require its driver
+	content = MigratorMainContent("example.com/app/migrations", nil, nil, "", "", false)
+	if strings.Contains(content, "mysql\"") || strings.Contains(content, "mysqldsn.") {
+		t.Error("Generated migrator should not import the MySQL driver unless asked to")
+	}
+	if !strings.Contains(content, "built without MySQL support") {
+		t.Error("Generated migrator should reject mysql:// URLs")
+	}
+}
@@ -0,0 +1,215 @@
+package inspectdb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Table represents an introspected database table
+type Table struct {
+	Name    string
+	Columns []Column
+	Indexes []Index
+}
+
+// Column represents an introspected column
+type Column struct {
+	Name     string
+	DBType   string // Database type name, e.g. varchar
+	GoType   string
+	Nullable bool
+	PK       bool
+	Unique   bool // Unique constraint on the column alone
+}
+
+// Index represents an introspected index, excluding primary keys
+type Index struct {
+	Name    string
+	Unique  bool
+	Columns []string
+}
+
+// Inspect introspects the given tables, or every table if none are given
+// Tables listed in skip (such as the migration tables) and SQLite's internal tables are left out.
+func Inspect(db *gorm.DB, tables []string, skip []string) ([]Table, error) {
+	migrator := db.Migrator()
+
+	if len(tables) == 0 {
+		all, err := migrator.GetTables()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tables: %w", err)
+		}
+		skipped := make(map[string]bool)
+		for _, name := range skip {
+			skipped[name] = true
+		}
+		for _, name := range all {
+			if !skipped[name] && !strings.HasPrefix(name, "sqlite_") {
+				tables = append(tables, name)
+			}
+		}
+	} else {
+		for _, name := range tables {
+			if !migrator.HasTable(name) {
+				return nil, fmt.Errorf("table %s does not exist", name)
+			}
+		}
+	}
+	sort.Strings(tables)
+
+	result := make([]Table, 0, len(tables))
+	for _, name := range tables {
+		table, err := inspectTable(db, name)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, table)
+	}
+	return result, nil
+}
+
+// inspectTable introspects the columns and indexes of a table
+func inspectTable(db *gorm.DB, name string) (Table, error) {
+	table := Table{Name: name}
+	dialect := db.Dialector.Name()
+
+	columnTypes, err := db.Migrator().ColumnTypes(name)
+	if err != nil {
+		return table, fmt.Errorf("failed to get columns of %s: %w", name, err)
+	}
+	for _, ct := range columnTypes {
+		col := Column{
+			Name:   ct.Name(),
+			DBType: ct.DatabaseTypeName(),
+		}
+		if pk, ok := ct.PrimaryKey(); ok {
+			col.PK = pk
+		}
+		if nullable, ok := ct.Nullable(); ok {
+			col.Nullable = nullable && !col.PK
+		}
+		if unique, ok := ct.Unique(); ok {
+			col.Unique = unique && !col.PK
+		}
+		col.GoType = goType(dialect, col.DBType)
+		// MySQL has no boolean type; BOOLEAN columns are tinyint(1)
+		if full, ok := ct.ColumnType(); ok && dialect == "mysql" && strings.EqualFold(full, "tinyint(1)") {
+			col.GoType = "bool"
+		}
+		table.Columns = append(table.Columns, col)
+	}
+
+	indexes, err := inspectIndexes(db, name)
+	if err != nil {
+		return table, fmt.Errorf("failed to get indexes of %s: %w", name, err)
+	}
+	for _, idx := range indexes {
+		// SQLite backs UNIQUE constraints with internal indexes that can't be created by name
+		if strings.HasPrefix(idx.Name, "sqlite_autoindex_") {
+			if len(idx.Columns) == 1 {
+				table.markUnique(idx.Columns[0])
+				continue
+			}
+			idx.Name = "idx_" + name + "_" + strings.Join(idx.Columns, "_")
+		}
+		table.Indexes = append(table.Indexes, idx)
+	}
+	sort.Slice(table.Indexes, func(i, j int) bool {
+		return table.Indexes[i].Name < table.Indexes[j].Name
+	})
+
+	return table, nil
+}
+
+// markUnique marks a column as having a unique constraint
+func (t *Table) markUnique(column string) {
+	for i := range t.Columns {
+		if t.Columns[i].Name == column && !t.Columns[i].PK {
+			t.Columns[i].Unique = true
+		}
+	}
+}
+
+// inspectIndexes returns the indexes of a table, excluding the primary key
+// Drivers that don't support Migrator().GetIndexes fall back to querying the database directly.
+func inspectIndexes(db *gorm.DB, table string) ([]Index, error) {
+	gormIndexes, err := db.Migrator().GetIndexes(table)
+	if err != nil {
+		if db.Dialector.Name() == "sqlite" {
+			return sqliteIndexes(db, table)
+		}
+		return nil, err
+	}
+
+	var indexes []Index
+	for _, idx := range gormIndexes {
+		if pk, ok := idx.PrimaryKey(); ok && pk {
+			continue
+		}
+		unique, _ := idx.Unique()
+		indexes = append(indexes, Index{Name: idx.Name(), Unique: unique, Columns: idx.Columns()})
+	}
+	return indexes, nil
+}
+
+// sqliteIndexes reads the indexes of a table from SQLite's index pragmas
+func sqliteIndexes(db *gorm.DB, table string) ([]Index, error) {
+	var list []struct {
+		Name   string
+		Unique bool
+		Origin string
+	}
+	if err := db.Raw(`SELECT name, "unique", origin FROM pragma_index_list(?)`, table).Scan(&list).Error; err != nil {
+		return nil, err
+	}
+
+	var indexes []Index
+	for _, entry := range list {
+		if entry.Origin == "pk" {
+			continue
+		}
+		var columns []string
+		if err := db.Raw("SELECT name FROM pragma_index_info(?) ORDER BY seqno", entry.Name).Scan(&columns).Error; err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, Index{Name: entry.Name, Unique: entry.Unique, Columns: columns})
+	}
+	return indexes, nil
+}
+
+// goType maps a database type to the Go type of the model field
+func goType(dialect, dbType string) string {
+	t := strings.ToLower(strings.TrimSpace(dbType))
+	if i := strings.Index(t, "("); i != -1 {
+		t = strings.TrimSpace(t[:i])
+	}
+	t = strings.TrimSpace(strings.TrimSuffix(t, "unsigned"))
+
+	switch t {
+	case "bool", "boolean":
+		return "bool"
+	case "tinyint":
+		return "int8"
+	case "smallint", "int2", "smallserial":
+		return "int16"
+	case "integer", "int", "int4", "mediumint", "serial":
+		// SQLite integers are always 64-bit
+		if dialect == "sqlite" {
+			return "int64"
+		}
+		return "int32"
+	case "bigint", "int8", "bigserial":
+		return "int64"
+	case "real", "float", "double", "double precision", "float4", "float8", "numeric", "decimal":
+		return "float64"
+	case "date", "datetime", "timestamp", "timestamptz", "timestamp with time zone", "timestamp without time zone":
+		return "time.Time"
+	case "blob", "bytea", "binary", "varbinary":
+		return "[]byte"
+	default:
+		return "string"
+	}
+}
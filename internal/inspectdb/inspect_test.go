@@ -0,0 +1,178 @@
+package inspectdb
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pankajredekar/goosegorm/internal/diff"
+	"github.com/pankajredekar/goosegorm/internal/modelreflect"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+
+	statements := []string{
+		"CREATE TABLE users (id integer PRIMARY KEY AUTOINCREMENT, email varchar(255) NOT NULL UNIQUE, display_name text, created_at datetime NOT NULL, score real)",
+		"CREATE TABLE blog_posts (id integer PRIMARY KEY AUTOINCREMENT, user_id integer NOT NULL, title varchar(200) NOT NULL, published boolean NOT NULL)",
+		"CREATE INDEX idx_blog_posts_published ON blog_posts (published)",
+		"CREATE UNIQUE INDEX idx_blog_posts_user_title ON blog_posts (user_id, title)",
+		"CREATE TABLE _goosegorm_migrations (version varchar(255) PRIMARY KEY)",
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("Failed to create schema: %v", err)
+		}
+	}
+	return db
+}
+
+func findColumn(t *testing.T, table Table, name string) Column {
+	for _, col := range table.Columns {
+		if col.Name == name {
+			return col
+		}
+	}
+	t.Fatalf("Column %s not found in %s", name, table.Name)
+	return Column{}
+}
+
+func TestInspect(t *testing.T) {
+	db := setupTestDB(t)
+
+	tables, err := Inspect(db, nil, []string{"_goosegorm_migrations"})
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+
+	if len(tables) != 2 || tables[0].Name != "blog_posts" || tables[1].Name != "users" {
+		t.Fatalf("Expected tables [blog_posts users], got %+v", tables)
+	}
+
+	users := tables[1]
+	if id := findColumn(t, users, "id"); !id.PK || id.GoType != "int64" {
+		t.Errorf("Expected id to be an int64 primary key, got %+v", id)
+	}
+	if email := findColumn(t, users, "email"); !email.Unique || email.Nullable || email.GoType != "string" {
+		t.Errorf("Expected email to be a unique, not null string, got %+v", email)
+	}
+	if name := findColumn(t, users, "display_name"); !name.Nullable {
+		t.Errorf("Expected display_name to be nullable, got %+v", name)
+	}
+	if created := findColumn(t, users, "created_at"); created.GoType != "time.Time" {
+		t.Errorf("Expected created_at to be time.Time, got %s", created.GoType)
+	}
+	if score := findColumn(t, users, "score"); score.GoType != "float64" {
+		t.Errorf("Expected score to be float64, got %s", score.GoType)
+	}
+	if len(users.Indexes) != 0 {
+		t.Errorf("Expected the unique constraint on email not to be reported as an index, got %+v", users.Indexes)
+	}
+
+	posts := tables[0]
+	if published := findColumn(t, posts, "published"); published.GoType != "bool" {
+		t.Errorf("Expected published to be bool, got %s", published.GoType)
+	}
+	if len(posts.Indexes) != 2 {
+		t.Fatalf("Expected 2 indexes on blog_posts, got %+v", posts.Indexes)
+	}
+	composite := posts.Indexes[1]
+	if composite.Name != "idx_blog_posts_user_title" || !composite.Unique || strings.Join(composite.Columns, ",") != "user_id,title" {
+		t.Errorf("Unexpected composite index: %+v", composite)
+	}
+
+	if _, err := Inspect(db, []string{"missing"}, nil); err == nil {
+		t.Error("Expected an error for a table that does not exist")
+	}
+}
+
+func TestGenerateModelRoundTrip(t *testing.T) {
+	db := setupTestDB(t)
+
+	tables, err := Inspect(db, nil, []string{"_goosegorm_migrations"})
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+
+	modelsDir := t.TempDir()
+	for _, table := range tables {
+		content, err := GenerateModel("models", table, true, "")
+		if err != nil {
+			t.Fatalf("GenerateModel failed: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(modelsDir, table.Name+".go"), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write model: %v", err)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(modelsDir, "blog_posts.go"))
+	if err != nil {
+		t.Fatalf("Failed to read model: %v", err)
+	}
+	for _, expected := range []string{
+		"type BlogPosts struct {",
+		// user_id becomes UserId, as the model parser would read UserID as user_i_d
+		"UserId    int64",
+		"`gorm:\"index:idx_blog_posts_user_title,unique,priority:1;not null\"`",
+		"func (m BlogPosts) TableName() string { return \"blog_posts\" }",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected model to contain %q, got:\n%s", expected, content)
+		}
+	}
+
+	models, err := modelreflect.ParseModelsFromDir(modelsDir, nil)
+	if err != nil {
+		t.Fatalf("ParseModelsFromDir failed: %v", err)
+	}
+	if len(models) != 2 {
+		t.Fatalf("Expected 2 models, got %d", len(models))
+	}
+
+	diffs, err := diff.CompareSchema(Schema(tables), models)
+	if err != nil {
+		t.Fatalf("CompareSchema failed: %v", err)
+	}
+	for _, d := range diffs {
+		t.Errorf("Generated models do not match the database: %s on %s", d.Type, d.TableName)
+	}
+}
+
+func TestGenerateModelUnmanaged(t *testing.T) {
+	table := Table{
+		Name: "legacy_accounts",
+		Columns: []Column{
+			{Name: "id", GoType: "int64", PK: true},
+			{Name: "balance", GoType: "float64", Nullable: true},
+		},
+	}
+
+	content, err := GenerateModel("models", table, false, "analytics")
+	if err != nil {
+		t.Fatalf("GenerateModel failed: %v", err)
+	}
+	if !strings.Contains(content, "// goosegorm:\"managed:false\"\n// goosegorm:\"database:analytics\"\ntype LegacyAccounts struct {") {
+		t.Errorf("Expected an unmanaged model of the analytics database, got:\n%s", content)
+	}
+	if !strings.Contains(content, "Balance *float64") {
+		t.Errorf("Expected a nullable column to use a pointer type, got:\n%s", content)
+	}
+
+	modelsDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(modelsDir, "legacy_accounts.go"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write model: %v", err)
+	}
+	models, err := modelreflect.ParseModelsFromDir(modelsDir, nil)
+	if err != nil {
+		t.Fatalf("ParseModelsFromDir failed: %v", err)
+	}
+	if len(models) != 1 || models[0].Managed || models[0].GetTableName() != "legacy_accounts" || models[0].Database != "analytics" {
+		t.Errorf("Expected an unmanaged legacy_accounts model of the analytics database, got %+v", models)
+	}
+}
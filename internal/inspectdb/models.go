@@ -0,0 +1,187 @@
+package inspectdb
+
+import (
+	"fmt"
+	"go/format"
+	"strings"
+
+	"github.com/pankajredekar/goosegorm/internal/schema"
+)
+
+// schemaTypes maps the Go types of generated fields to the column types makemigrations derives from them
+var schemaTypes = map[string]string{
+	"string":    "string",
+	"bool":      "bool",
+	"int8":      "tinyint",
+	"int16":     "smallint",
+	"int32":     "integer",
+	"int64":     "bigint",
+	"float64":   "float",
+	"time.Time": "timestamp",
+	"[]byte":    "string",
+}
+
+// GenerateModel returns the source of a model file for a table
+// Unmanaged models are marked with goosegorm:"managed:false" so makemigrations leaves their table alone.
+// A non-empty database annotates the model goosegorm:"database:<name>" so it is migrated with that database.
+func GenerateModel(packageName string, table Table, managed bool, database string) (string, error) {
+	structName := ModelName(table.Name)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("package %s\n\n", packageName))
+
+	for _, col := range table.Columns {
+		if col.GoType == "time.Time" {
+			sb.WriteString("import \"time\"\n\n")
+			break
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("// %s is generated by goosegorm inspectdb from the %s table\n", structName, table.Name))
+	if !managed {
+		sb.WriteString("// goosegorm:\"managed:false\"\n")
+	}
+	if database != "" {
+		sb.WriteString(fmt.Sprintf("// goosegorm:\"database:%s\"\n", database))
+	}
+	sb.WriteString(fmt.Sprintf("type %s struct {\n", structName))
+	for _, col := range table.Columns {
+		fieldType := col.GoType
+		if col.Nullable && fieldType != "[]byte" {
+			fieldType = "*" + fieldType
+		}
+		sb.WriteString(fmt.Sprintf("\t%s %s `gorm:\"%s\"`\n", fieldName(col.Name), fieldType, table.gormTag(col)))
+	}
+	sb.WriteString("}\n\n")
+
+	sb.WriteString(fmt.Sprintf("func (m %s) TableName() string { return %q }\n", structName, table.Name))
+
+	source, err := format.Source([]byte(sb.String()))
+	if err != nil {
+		return "", fmt.Errorf("failed to format model %s: %w", structName, err)
+	}
+	return string(source), nil
+}
+
+// gormTag returns the gorm tag of a column's field
+// Tag parts are written in the order the model parser reads them: "not null" contains a space,
+// so it goes last.
+func (t Table) gormTag(col Column) string {
+	var parts []string
+	if col.PK {
+		parts = append(parts, "primaryKey")
+	}
+	if toSnakeCase(fieldName(col.Name)) != col.Name {
+		parts = append(parts, "column:"+col.Name)
+	}
+
+	inUniqueIndex := false
+	for _, idx := range t.Indexes {
+		for i, name := range idx.Columns {
+			if name != col.Name {
+				continue
+			}
+			part := "index:" + idx.Name
+			if idx.Unique {
+				part += ",unique"
+				inUniqueIndex = true
+			}
+			if len(idx.Columns) > 1 {
+				part += fmt.Sprintf(",priority:%d", i+1)
+			}
+			parts = append(parts, part)
+		}
+	}
+
+	if col.Unique && !inUniqueIndex {
+		parts = append(parts, "unique")
+	}
+	if !col.Nullable && !col.PK {
+		parts = append(parts, "not null")
+	}
+	return strings.Join(parts, ";")
+}
+
+// Schema returns the tables as the schema makemigrations expects for the generated models
+// Column nullability is not included, as the model parser doesn't read it from gorm tags.
+func Schema(tables []Table) *schema.SchemaState {
+	builder := schema.NewSchemaBuilder()
+	for _, table := range tables {
+		tb := builder.CreateTable(table.Name)
+		for _, col := range table.Columns {
+			unique := col.Unique
+			for _, idx := range table.Indexes {
+				if idx.Unique && containsString(idx.Columns, col.Name) {
+					unique = true
+				}
+			}
+			tb.AddColumnWithOptions(col.Name, schemaTypes[col.GoType], false, col.PK, unique)
+		}
+		for _, idx := range table.Indexes {
+			tb.AddIndex(idx.Name)
+		}
+	}
+	return builder.Schema
+}
+
+// ModelName returns the struct name of a table's model
+func ModelName(table string) string {
+	return fieldName(table)
+}
+
+// fieldName converts a snake_case name to CamelCase, spelling id as ID
+func fieldName(name string) string {
+	if name == "id" {
+		return "ID"
+	}
+
+	var sb strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '-' || r == ' ' || r == '.'
+	}) {
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	result := sb.String()
+	if result == "" || (result[0] >= '0' && result[0] <= '9') {
+		result = "X" + result
+	}
+	return result
+}
+
+func toSnakeCase(s string) string {
+	// Special case: If all uppercase letters, convert to all lowercase (not snake_case)
+	if isAllUppercase(s) {
+		return strings.ToLower(s)
+	}
+
+	var result strings.Builder
+	for i, r := range s {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			result.WriteRune('_')
+		}
+		result.WriteRune(r)
+	}
+	return strings.ToLower(result.String())
+}
+
+// isAllUppercase checks if a string contains only uppercase letters
+func isAllUppercase(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package loader
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Checksum returns the checksum of a migration source file
+// Line endings are normalized so a CRLF checkout doesn't count as a change.
+func Checksum(content []byte) string {
+	normalized := bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+	sum := sha256.Sum256(normalized)
+	return hex.EncodeToString(sum[:])
+}
+
+// ComputeChecksums returns the source checksum of every migration in the directory, keyed by version
+func ComputeChecksums(migrationsDir string) (map[string]string, error) {
+	checksums := make(map[string]string)
+
+	entries, err := os.ReadDir(migrationsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") || strings.HasSuffix(entry.Name(), "_test.go") {
+			continue
+		}
+
+		path := filepath.Join(migrationsDir, entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		file, err := parser.ParseFile(token.NewFileSet(), path, content, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		sum := Checksum(content)
+		for _, version := range migrationVersions(file) {
+			checksums[version] = sum
+		}
+	}
+
+	return checksums, nil
+}
+
+// migrationVersions returns the versions of the migration structs declared in a file
+func migrationVersions(file *ast.File) []string {
+	var versions []string
+	for _, typeName := range extractMigrationsFromFile(file) {
+		if version := extractStringReturnValue(file, typeName, "Version"); version != "" {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
@@ -0,0 +1,54 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const checksumMigrationSource = `package migrations
+
+import "gorm.io/gorm"
+
+type AddUsers struct{}
+
+func (m AddUsers) Version() string { return "20250101000000" }
+func (m AddUsers) Name() string    { return "add_users" }
+func (m AddUsers) Up(db *gorm.DB) error   { return nil }
+func (m AddUsers) Down(db *gorm.DB) error { return nil }
+`
+
+func TestChecksum_NormalizesLineEndings(t *testing.T) {
+	lf := Checksum([]byte("line one\nline two\n"))
+	crlf := Checksum([]byte("line one\r\nline two\r\n"))
+	if lf != crlf {
+		t.Error("CRLF and LF sources should have the same checksum")
+	}
+	if lf == Checksum([]byte("line one\nline 2\n")) {
+		t.Error("Different sources should have different checksums")
+	}
+}
+
+func TestComputeChecksums(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "20250101000000_add_users.go")
+	if err := os.WriteFile(path, []byte(checksumMigrationSource), 0644); err != nil {
+		t.Fatalf("Failed to write migration: %v", err)
+	}
+	// Test files are not migrations
+	if err := os.WriteFile(filepath.Join(dir, "migrations_test.go"), []byte("package migrations\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	checksums, err := ComputeChecksums(dir)
+	if err != nil {
+		t.Fatalf("ComputeChecksums failed: %v", err)
+	}
+
+	if len(checksums) != 1 {
+		t.Fatalf("Expected 1 checksum, got %d", len(checksums))
+	}
+	if checksums["20250101000000"] != Checksum([]byte(checksumMigrationSource)) {
+		t.Errorf("Unexpected checksum for 20250101000000: %s", checksums["20250101000000"])
+	}
+}
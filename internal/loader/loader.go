@@ -247,7 +247,11 @@ import (
 
 func main() {
 	registry := goosegorm.GetGlobalRegistry()
-	migrations := registry.GetAllMigrations()
+	migrations, err := registry.GetAllMigrations()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %%v\n", err)
+		os.Exit(1)
+	}
 	
 	// Output migrations as JSON
 	result := make([]map[string]string, len(migrations))
@@ -483,6 +487,11 @@ func LoadMigrationsFromAST(migrationsDir string, packageName string) (*runner.Re
 			return err
 		}
 
+		// Migrations are one package; subdirectories hold other databases' migrations
+		if info.IsDir() && path != migrationsDir {
+			return filepath.SkipDir
+		}
+
 		if !strings.HasSuffix(path, ".go") {
 			return nil
 		}
@@ -498,7 +507,11 @@ func LoadMigrationsFromAST(migrationsDir string, packageName string) (*runner.Re
 		migrations := extractMigrationsFromAST(file, fset)
 		for _, m := range migrations {
 			m.filePath = path // Store file path for context
-			registry.RegisterMigration(m)
+			if m.declaresDependencies {
+				registry.RegisterMigration(&dependentASTMigration{m})
+			} else {
+				registry.RegisterMigration(m)
+			}
 		}
 
 		return nil
@@ -519,11 +532,33 @@ type ASTMigration struct {
 	downCode *ast.BlockStmt
 	file     *ast.File // Store the file AST to extract struct definitions
 	filePath string    // Store file path for context
+
+	nonTransactional bool // Set when the migration declares NonTransactional() returning true
+
+	dependencies         []string // Versions returned by Dependencies()
+	declaresDependencies bool     // Set when the migration declares Dependencies()
+
+	replaces []string // Versions returned by Replaces(), set for squashed migrations
 }
 
+// dependentASTMigration is an ASTMigration that declares its dependencies
+// Migrations without Dependencies() must not implement runner.DependentMigration,
+// since the runner then orders them after the previous version.
+type dependentASTMigration struct {
+	*ASTMigration
+}
+
+func (m *dependentASTMigration) Dependencies() []string { return m.dependencies }
+
 func (m *ASTMigration) Version() string { return m.version }
 func (m *ASTMigration) Name() string    { return m.name }
 
+// Replaces returns the versions a squashed migration replaces, or nil for other migrations
+func (m *ASTMigration) Replaces() []string { return m.replaces }
+
+// NonTransactional reports whether the migration opted out of running inside a transaction
+func (m *ASTMigration) NonTransactional() bool { return m.nonTransactional }
+
 func (m *ASTMigration) Up(db *gorm.DB) error {
 	// Check if this is simulation mode by trying to recover SchemaBuilder
 	// During simulation, db is actually a *schema.SchemaBuilder passed as *gorm.DB via unsafe conversion
@@ -1417,11 +1452,17 @@ func extractMigrationsFromAST(file *ast.File, fset *token.FileSet) []*ASTMigrati
 			downBlock := extractMethodBody(file, ts.Name.Name, "Down")
 
 			migration := &ASTMigration{
-				version:  version,
-				name:     name,
-				upCode:   upBlock,
-				downCode: downBlock,
-				file:     file,
+				version:          version,
+				name:             name,
+				upCode:           upBlock,
+				downCode:         downBlock,
+				file:             file,
+				nonTransactional: extractBoolReturnValue(file, ts.Name.Name, "NonTransactional"),
+				replaces:         extractStringSliceReturnValue(file, ts.Name.Name, "Replaces"),
+			}
+			if extractMethodBody(file, ts.Name.Name, "Dependencies") != nil {
+				migration.declaresDependencies = true
+				migration.dependencies = extractStringSliceReturnValue(file, ts.Name.Name, "Dependencies")
 			}
 
 			migrations = append(migrations, migration)
@@ -1475,6 +1516,55 @@ func extractStringReturnValue(file *ast.File, typeName, methodName string) strin
 	return ""
 }
 
+// extractBoolReturnValue extracts the literal return value from a method that returns a bool
+// Returns false if the method is not declared or doesn't return a literal
+func extractBoolReturnValue(file *ast.File, typeName, methodName string) bool {
+	body := extractMethodBody(file, typeName, methodName)
+	if body == nil {
+		return false
+	}
+
+	for _, stmt := range body.List {
+		if ret, ok := stmt.(*ast.ReturnStmt); ok && len(ret.Results) > 0 {
+			if ident, ok := ret.Results[0].(*ast.Ident); ok {
+				return ident.Name == "true"
+			}
+		}
+	}
+
+	return false
+}
+
+// extractStringSliceReturnValue extracts the literal return value from a method that returns a []string
+// Returns nil if the method is not declared or returns nil
+func extractStringSliceReturnValue(file *ast.File, typeName, methodName string) []string {
+	body := extractMethodBody(file, typeName, methodName)
+	if body == nil {
+		return nil
+	}
+
+	for _, stmt := range body.List {
+		ret, ok := stmt.(*ast.ReturnStmt)
+		if !ok || len(ret.Results) == 0 {
+			continue
+		}
+		lit, ok := ret.Results[0].(*ast.CompositeLit)
+		if !ok {
+			return nil
+		}
+
+		var values []string
+		for _, elt := range lit.Elts {
+			if basic, ok := elt.(*ast.BasicLit); ok && basic.Kind == token.STRING {
+				values = append(values, strings.Trim(basic.Value, `"`))
+			}
+		}
+		return values
+	}
+
+	return nil
+}
+
 // extractMethodBody extracts the body block of a method
 func extractMethodBody(file *ast.File, typeName, methodName string) *ast.BlockStmt {
 	for _, decl := range file.Decls {
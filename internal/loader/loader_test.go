@@ -3,6 +3,7 @@ package loader
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/pankajredekar/goosegorm/internal/runner"
@@ -59,6 +60,16 @@ func init() {
 		t.Fatalf("Failed to write migration file: %v", err)
 	}
 
+	// Migrations of another database in a subdirectory are not loaded
+	otherDir := filepath.Join(migrationsDir, "analytics")
+	if err := os.MkdirAll(otherDir, 0755); err != nil {
+		t.Fatalf("Failed to create migrations directory: %v", err)
+	}
+	otherContent := strings.ReplaceAll(migrationContent, "20251106133644", "20251106133645")
+	if err := os.WriteFile(filepath.Join(otherDir, "0001_create_user.go"), []byte(otherContent), 0644); err != nil {
+		t.Fatalf("Failed to write migration file: %v", err)
+	}
+
 	// Load migrations
 	registry, err := LoadMigrationsFromAST(migrationsDir, "migrations")
 	if err != nil {
@@ -66,7 +77,10 @@ func init() {
 	}
 
 	// Check that migration was loaded
-	allMigrations := registry.GetAllMigrations()
+	allMigrations, err := registry.GetAllMigrations()
+	if err != nil {
+		t.Fatalf("GetAllMigrations failed: %v", err)
+	}
 	if len(allMigrations) != 1 {
 		t.Fatalf("Expected 1 migration, got %d", len(allMigrations))
 	}
@@ -266,7 +280,10 @@ func (m AddName) Down(db *gorm.DB) error {
 	}
 
 	// Verify both migrations loaded
-	allMigrations := registry.GetAllMigrations()
+	allMigrations, err := registry.GetAllMigrations()
+	if err != nil {
+		t.Fatalf("GetAllMigrations failed: %v", err)
+	}
 	if len(allMigrations) != 2 {
 		t.Fatalf("Expected 2 migrations, got %d", len(allMigrations))
 	}
@@ -521,3 +538,133 @@ func (m CreateUser) Down(db *gorm.DB) error {
 		}
 	}
 }
+
+func TestLoadMigrationsFromAST_Dependencies(t *testing.T) {
+	migrationsDir := t.TempDir()
+
+	files := map[string]string{
+		"001_first.go": `package migrations
+
+import "gorm.io/gorm"
+
+type First struct{}
+
+func (m First) Version() string          { return "001" }
+func (m First) Name() string             { return "first" }
+func (m First) Dependencies() []string   { return nil }
+func (m First) Up(db *gorm.DB) error     { return nil }
+func (m First) Down(db *gorm.DB) error   { return nil }
+`,
+		"002_second.go": `package migrations
+
+import "gorm.io/gorm"
+
+type Second struct{}
+
+func (m Second) Version() string          { return "002" }
+func (m Second) Name() string             { return "second" }
+func (m Second) Dependencies() []string   { return []string{"003"} }
+func (m Second) Up(db *gorm.DB) error     { return nil }
+func (m Second) Down(db *gorm.DB) error   { return nil }
+`,
+		"003_third.go": `package migrations
+
+import "gorm.io/gorm"
+
+type Third struct{}
+
+func (m Third) Version() string          { return "003" }
+func (m Third) Name() string             { return "third" }
+func (m Third) Dependencies() []string   { return []string{"001"} }
+func (m Third) Up(db *gorm.DB) error     { return nil }
+func (m Third) Down(db *gorm.DB) error   { return nil }
+`,
+		"004_fourth.go": `package migrations
+
+import "gorm.io/gorm"
+
+type Fourth struct{}
+
+func (m Fourth) Version() string        { return "004" }
+func (m Fourth) Name() string           { return "fourth" }
+func (m Fourth) Up(db *gorm.DB) error   { return nil }
+func (m Fourth) Down(db *gorm.DB) error { return nil }
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(migrationsDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	registry, err := LoadMigrationsFromAST(migrationsDir, "migrations")
+	if err != nil {
+		t.Fatalf("LoadMigrationsFromAST failed: %v", err)
+	}
+
+	second, _ := registry.GetMigration("002")
+	dm, ok := second.(runner.DependentMigration)
+	if !ok {
+		t.Fatal("Migration declaring Dependencies() should implement DependentMigration")
+	}
+	if deps := dm.Dependencies(); len(deps) != 1 || deps[0] != "003" {
+		t.Errorf("Expected dependencies [003], got %v", deps)
+	}
+
+	fourth, _ := registry.GetMigration("004")
+	if _, ok := fourth.(runner.DependentMigration); ok {
+		t.Error("Migration without Dependencies() should not implement DependentMigration")
+	}
+
+	// 002 waits for 003, and 004 follows 003 in version order
+	all, err := registry.GetAllMigrations()
+	if err != nil {
+		t.Fatalf("GetAllMigrations failed: %v", err)
+	}
+	var order []string
+	for _, m := range all {
+		order = append(order, m.Version())
+	}
+	if strings.Join(order, ",") != "001,003,002,004" {
+		t.Errorf("Expected order 001,003,002,004, got %v", order)
+	}
+}
+
+func TestLoadMigrationsFromAST_Replaces(t *testing.T) {
+	migrationsDir := t.TempDir()
+
+	content := `package migrations
+
+import "gorm.io/gorm"
+
+type Squashed struct{}
+
+func (m Squashed) Version() string { return "003" }
+func (m Squashed) Name() string    { return "squashed" }
+func (m Squashed) Replaces() []string {
+	return []string{
+		"001",
+		"002",
+	}
+}
+func (m Squashed) Up(db *gorm.DB) error   { return nil }
+func (m Squashed) Down(db *gorm.DB) error { return nil }
+`
+	if err := os.WriteFile(filepath.Join(migrationsDir, "003_squashed.go"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write migration: %v", err)
+	}
+
+	registry, err := LoadMigrationsFromAST(migrationsDir, "migrations")
+	if err != nil {
+		t.Fatalf("LoadMigrationsFromAST failed: %v", err)
+	}
+
+	squashed, _ := registry.GetMigration("003")
+	rm, ok := squashed.(runner.ReplacingMigration)
+	if !ok {
+		t.Fatal("AST migrations should implement ReplacingMigration")
+	}
+	if replaces := rm.Replaces(); strings.Join(replaces, ",") != "001,002" {
+		t.Errorf("Expected replaces [001 002], got %v", replaces)
+	}
+}
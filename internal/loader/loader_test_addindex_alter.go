@@ -97,7 +97,10 @@ func (m AddIndex) Down(db *gorm.DB) error {
 	}
 
 	// Verify both migrations loaded
-	allMigrations := registry.GetAllMigrations()
+	allMigrations, err := registry.GetAllMigrations()
+	if err != nil {
+		t.Fatalf("GetAllMigrations failed: %v", err)
+	}
 	if len(allMigrations) != 2 {
 		t.Fatalf("Expected 2 migrations, got %d", len(allMigrations))
 	}
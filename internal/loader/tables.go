@@ -0,0 +1,17 @@
+package loader
+
+import (
+	"github.com/pankajredekar/goosegorm/internal/runner"
+)
+
+// ComputeCreatedTables returns the tables each migration in the directory creates, keyed by version
+// The migrations are simulated from their source, so the result can be embedded in a migrator
+// binary whose compiled migrations can't be simulated.
+func ComputeCreatedTables(migrationsDir string, packageName string) (map[string][]string, error) {
+	registry, err := LoadMigrationsFromAST(migrationsDir, packageName)
+	if err != nil {
+		return nil, err
+	}
+
+	return runner.NewRunner(nil, registry, nil).CreatedTables()
+}
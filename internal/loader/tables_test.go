@@ -0,0 +1,66 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const createTablesMigrationSource = `package migrations
+
+import (
+	"gorm.io/gorm"
+	"github.com/pankajredekar/goosegorm"
+)
+
+type CreateShop struct{}
+
+func (m CreateShop) Version() string { return "20250101000000" }
+func (m CreateShop) Name() string { return "create_shop" }
+
+func (m CreateShop) Up(db *gorm.DB) error {
+	if sim, ok := any(db).(*goosegorm.SchemaBuilder); ok {
+		sim.CreateTable("product").AddColumn("id", "uint")
+		sim.CreateTable("category").AddColumn("id", "uint")
+		return nil
+	}
+	return nil
+}
+
+func (m CreateShop) Down(db *gorm.DB) error { return nil }
+
+type AddSku struct{}
+
+func (m AddSku) Version() string { return "20250102000000" }
+func (m AddSku) Name() string { return "add_sku" }
+
+func (m AddSku) Up(db *gorm.DB) error {
+	if sim, ok := any(db).(*goosegorm.SchemaBuilder); ok {
+		sim.AlterTable("product").AddColumn("sku", "string")
+		return nil
+	}
+	return nil
+}
+
+func (m AddSku) Down(db *gorm.DB) error { return nil }
+`
+
+func TestComputeCreatedTables(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "20250101000000_create_shop.go"), []byte(createTablesMigrationSource), 0644); err != nil {
+		t.Fatalf("Failed to write migration: %v", err)
+	}
+
+	tables, err := ComputeCreatedTables(dir, "migrations")
+	if err != nil {
+		t.Fatalf("ComputeCreatedTables failed: %v", err)
+	}
+
+	if got := tables["20250101000000"]; !reflect.DeepEqual(got, []string{"category", "product"}) {
+		t.Errorf("Expected [category product] for 20250101000000, got %v", got)
+	}
+	if got := tables["20250102000000"]; len(got) != 0 {
+		t.Errorf("Altering an existing table should create nothing, got %v", got)
+	}
+}
@@ -0,0 +1,210 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// DefaultTimeout is how long to wait for the migration lock when no timeout is configured
+const DefaultTimeout = 60 * time.Second
+
+// pollInterval is how often a waiting process retries acquiring the lock
+var pollInterval = 500 * time.Millisecond
+
+// Locker serializes migration runs across processes
+type Locker interface {
+	// Lock acquires the lock, waiting up to timeout for another holder to release it
+	Lock(timeout time.Duration) error
+	// Unlock releases the lock
+	Unlock() error
+}
+
+// HeldError is returned when the lock could not be acquired before the timeout
+type HeldError struct {
+	Holder  string
+	Timeout time.Duration
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("timed out after %s waiting for migration lock held by %s", e.Timeout, e.Holder)
+}
+
+// NewLocker creates a locker for the given database
+// PostgreSQL uses a session-level advisory lock, other databases use a lock-row table
+// named after the migration table
+func NewLocker(db *gorm.DB, migrationTable string) Locker {
+	if db.Dialector.Name() == "postgres" {
+		return &advisoryLocker{db: db, key: advisoryKey(migrationTable)}
+	}
+	return &tableLocker{db: db, table: migrationTable + "_lock", owner: Owner()}
+}
+
+// Owner identifies the current process as host:pid
+func Owner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// advisoryKey derives a stable advisory lock key from the migration table name
+func advisoryKey(name string) int64 {
+	h := fnv.New32a()
+	h.Write([]byte("goosegorm:" + name))
+	return int64(h.Sum32())
+}
+
+// advisoryLocker uses pg_try_advisory_lock on a dedicated connection
+// Advisory locks are bound to the session, so the connection is held until Unlock
+type advisoryLocker struct {
+	db   *gorm.DB
+	key  int64
+	conn *sql.Conn
+}
+
+func (l *advisoryLocker) Lock(timeout time.Duration) error {
+	sqlDB, err := l.db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database handle: %w", err)
+	}
+
+	ctx := context.Background()
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open lock connection: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		var acquired bool
+		if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&acquired); err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to acquire advisory lock: %w", err)
+		}
+		if acquired {
+			l.conn = conn
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			holder := l.holder(ctx, conn)
+			conn.Close()
+			return &HeldError{Holder: holder, Timeout: timeout}
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+func (l *advisoryLocker) Unlock() error {
+	if l.conn == nil {
+		return nil
+	}
+	defer func() {
+		l.conn.Close()
+		l.conn = nil
+	}()
+
+	if _, err := l.conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", l.key); err != nil {
+		return fmt.Errorf("failed to release advisory lock: %w", err)
+	}
+	return nil
+}
+
+// holder describes the backend currently holding the advisory lock
+func (l *advisoryLocker) holder(ctx context.Context, conn *sql.Conn) string {
+	var (
+		pid          int
+		appName      string
+		clientAddr   string
+		backendStart time.Time
+	)
+	row := conn.QueryRowContext(ctx, `SELECT a.pid, COALESCE(a.application_name, ''), COALESCE(a.client_addr::text, ''), a.backend_start
+		FROM pg_locks l JOIN pg_stat_activity a ON a.pid = l.pid
+		WHERE l.locktype = 'advisory' AND l.granted AND l.classid = 0 AND l.objid = $1
+		LIMIT 1`, l.key)
+	if err := row.Scan(&pid, &appName, &clientAddr, &backendStart); err != nil {
+		return "another process"
+	}
+
+	desc := fmt.Sprintf("pid %d", pid)
+	if appName != "" {
+		desc += fmt.Sprintf(" (%s)", appName)
+	}
+	if clientAddr != "" {
+		desc += fmt.Sprintf(" from %s", clientAddr)
+	}
+	return desc + fmt.Sprintf(", connected since %s", backendStart.Format(time.RFC3339))
+}
+
+// LockRecord is the single row held in the lock table while a migration run is active
+type LockRecord struct {
+	ID       int       `gorm:"primaryKey;autoIncrement:false;column:id"`
+	LockedBy string    `gorm:"column:locked_by;size:255"`
+	LockedAt time.Time `gorm:"column:locked_at"`
+}
+
+// tableLocker inserts a row with a fixed primary key; the insert fails while another process holds it
+type tableLocker struct {
+	db    *gorm.DB
+	table string
+	owner string
+}
+
+func (l *tableLocker) Lock(timeout time.Duration) error {
+	if err := l.db.Table(l.table).AutoMigrate(&LockRecord{}); err != nil {
+		return fmt.Errorf("failed to create lock table: %w", err)
+	}
+
+	// Failed inserts are expected while waiting, so keep them out of the log
+	quiet := l.db.Session(&gorm.Session{Logger: l.db.Logger.LogMode(logger.Silent)})
+
+	deadline := time.Now().Add(timeout)
+	for {
+		record := LockRecord{ID: 1, LockedBy: l.owner, LockedAt: time.Now()}
+		err := quiet.Table(l.table).Create(&record).Error
+		if err == nil {
+			return nil
+		}
+		// Only a conflict on the lock row means another process holds the lock
+		if !l.isConflict(err) {
+			return fmt.Errorf("failed to acquire lock: %w", err)
+		}
+		if !time.Now().Before(deadline) {
+			return &HeldError{Holder: l.holder(), Timeout: timeout}
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// isConflict reports whether an insert failed on the primary key of the lock row
+func (l *tableLocker) isConflict(err error) bool {
+	if translator, ok := l.db.Dialector.(gorm.ErrorTranslator); ok {
+		err = translator.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
+func (l *tableLocker) Unlock() error {
+	if err := l.db.Table(l.table).Where("id = ? AND locked_by = ?", 1, l.owner).Delete(&LockRecord{}).Error; err != nil {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+	return nil
+}
+
+// holder describes the process recorded in the lock row
+func (l *tableLocker) holder() string {
+	var record LockRecord
+	if err := l.db.Table(l.table).Where("id = ?", 1).First(&record).Error; err != nil {
+		return "another process"
+	}
+	return fmt.Sprintf("%s since %s (delete the row from %s if that process is gone)",
+		record.LockedBy, record.LockedAt.Format(time.RFC3339), l.table)
+}
@@ -0,0 +1,103 @@
+package lock
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "lock.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	return db
+}
+
+func TestNewLockerUsesTableForSQLite(t *testing.T) {
+	db := setupTestDB(t)
+	l := NewLocker(db, "_test_migrations")
+
+	tl, ok := l.(*tableLocker)
+	if !ok {
+		t.Fatalf("Expected table locker for sqlite, got %T", l)
+	}
+	if tl.table != "_test_migrations_lock" {
+		t.Errorf("Expected lock table '_test_migrations_lock', got '%s'", tl.table)
+	}
+}
+
+func TestTableLockerExcludesOtherOwners(t *testing.T) {
+	pollInterval = 10 * time.Millisecond
+	db := setupTestDB(t)
+
+	first := &tableLocker{db: db, table: "_test_lock", owner: "host-a:1"}
+	second := &tableLocker{db: db, table: "_test_lock", owner: "host-b:2"}
+
+	if err := first.Lock(time.Second); err != nil {
+		t.Fatalf("First Lock failed: %v", err)
+	}
+
+	err := second.Lock(50 * time.Millisecond)
+	var held *HeldError
+	if !errors.As(err, &held) {
+		t.Fatalf("Expected HeldError, got %v", err)
+	}
+	if held.Holder == "" || held.Holder[:len("host-a:1")] != "host-a:1" {
+		t.Errorf("Expected holder to report host-a:1, got '%s'", held.Holder)
+	}
+
+	// Unlocking from a non-owner must not release the lock
+	if err := second.Unlock(); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := second.Lock(0); err == nil {
+		t.Fatal("Lock should still be held by the first owner")
+	}
+
+	if err := first.Unlock(); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := second.Lock(time.Second); err != nil {
+		t.Fatalf("Lock after release failed: %v", err)
+	}
+}
+
+func TestAdvisoryKeyIsStable(t *testing.T) {
+	if advisoryKey("_goosegorm_migrations") != advisoryKey("_goosegorm_migrations") {
+		t.Error("advisory key should be deterministic")
+	}
+	if advisoryKey("a") == advisoryKey("b") {
+		t.Error("different tables should use different advisory keys")
+	}
+}
+
+func TestTableLockerReturnsOtherErrors(t *testing.T) {
+	pollInterval = 10 * time.Millisecond
+	db := setupTestDB(t)
+
+	if err := db.Table("_test_lock").AutoMigrate(&LockRecord{}); err != nil {
+		t.Fatalf("Failed to create lock table: %v", err)
+	}
+	if err := db.Exec(`CREATE TRIGGER reject_lock BEFORE INSERT ON _test_lock BEGIN SELECT RAISE(ABORT, 'read only'); END`).Error; err != nil {
+		t.Fatalf("Failed to create trigger: %v", err)
+	}
+
+	l := &tableLocker{db: db, table: "_test_lock", owner: "host-a:1"}
+	start := time.Now()
+	err := l.Lock(5 * time.Second)
+	if err == nil {
+		t.Fatal("Expected Lock to fail")
+	}
+	var held *HeldError
+	if errors.As(err, &held) {
+		t.Fatalf("Expected the insert error, got HeldError: %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Expected Lock to fail without waiting for the timeout, took %s", time.Since(start))
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"regexp"
 	"strings"
 )
 
@@ -13,6 +14,7 @@ type ParsedModel struct {
 	Name       string
 	Package    string
 	Managed    bool
+	Database   string // Database from a goosegorm:"database:<name>" annotation, empty if not annotated
 	Fields     []Field
 	File       string
 	StructNode *ast.StructType
@@ -35,6 +37,9 @@ type IndexInfo struct {
 	Priority int
 }
 
+// databaseAnnotation assigns a model to one of several configured databases
+var databaseAnnotation = regexp.MustCompile(`goosegorm:"database:([\w-]+)"`)
+
 // ParseModelsFromDir parses all Go files in the directory and extracts model structs
 func ParseModelsFromDir(dir string, ignoreModels []string) ([]ParsedModel, error) {
 	ignoreMap := make(map[string]bool)
@@ -98,6 +103,7 @@ func parseFileModels(fset *token.FileSet, file *ast.File, pkgName, fileName stri
 
 		// Check for goosegorm tag in comments
 		managed := true
+		database := ""
 		if gd.Doc != nil {
 			for _, c := range gd.Doc.List {
 				comment := c.Text
@@ -105,6 +111,8 @@ func parseFileModels(fset *token.FileSet, file *ast.File, pkgName, fileName stri
 					managed = false
 				} else if strings.Contains(comment, `goosegorm:"managed:true"`) {
 					managed = true
+				} else if match := databaseAnnotation.FindStringSubmatch(comment); match != nil {
+					database = match[1]
 				}
 			}
 		}
@@ -147,6 +155,7 @@ func parseFileModels(fset *token.FileSet, file *ast.File, pkgName, fileName stri
 				Name:       modelName,
 				Package:    pkgName,
 				Managed:    managed,
+				Database:   database,
 				Fields:     fields,
 				File:       fileName,
 				StructNode: st,
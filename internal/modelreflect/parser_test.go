@@ -65,6 +65,38 @@ type Legacy struct {
 	}
 }
 
+func TestParseModelsWithDatabaseTag(t *testing.T) {
+	modelsDir := t.TempDir()
+
+	content := `package models
+
+type User struct {
+	ID uint
+}
+
+// PageView is stored in the analytics database
+// goosegorm:"database:analytics"
+type PageView struct {
+	ID uint
+}
+`
+	if err := os.WriteFile(filepath.Join(modelsDir, "models.go"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write model file: %v", err)
+	}
+
+	models, err := ParseModelsFromDir(modelsDir, nil)
+	if err != nil {
+		t.Fatalf("ParseModelsFromDir failed: %v", err)
+	}
+
+	if user := findModel(models, "User"); user == nil || user.Database != "" {
+		t.Errorf("Expected User without a database, got %+v", user)
+	}
+	if view := findModel(models, "PageView"); view == nil || view.Database != "analytics" || !view.Managed {
+		t.Errorf("Expected a managed PageView in the analytics database, got %+v", view)
+	}
+}
+
 func TestParseModelsWithManagedTag(t *testing.T) {
 	tmpDir := t.TempDir()
 	modelsDir := filepath.Join(tmpDir, "models")
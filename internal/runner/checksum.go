@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"fmt"
+)
+
+// Checksum problems reported by Verify
+const (
+	ChecksumChanged = "changed"
+	ChecksumMissing = "missing"
+)
+
+// ChecksumIssue describes an applied migration whose source no longer matches what was applied
+type ChecksumIssue struct {
+	Version  string
+	Name     string
+	Problem  string // ChecksumChanged or ChecksumMissing
+	Recorded string
+	Current  string
+}
+
+func (i ChecksumIssue) String() string {
+	if i.Problem == ChecksumMissing {
+		return fmt.Sprintf("%s - %s: migration file is missing", i.Version, i.Name)
+	}
+	return fmt.Sprintf("%s - %s: migration file changed after it was applied (recorded %s, now %s)",
+		i.Version, i.Name, shortChecksum(i.Recorded), shortChecksum(i.Current))
+}
+
+func shortChecksum(sum string) string {
+	if len(sum) > 12 {
+		return sum[:12]
+	}
+	return sum
+}
+
+// Verify compares the checksums recorded for applied migrations with the registry
+// Applied migrations without a recorded checksum (applied before checksums existed),
+// migrations whose current checksum is unknown and deleted migrations replaced by a
+// squashed migration are not reported.
+func (r *Runner) Verify() ([]ChecksumIssue, error) {
+	records, err := r.versioner.GetAppliedRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	// Migrations replaced by a squashed migration may be deleted
+	replaced := r.registry.replacedVersions()
+
+	var issues []ChecksumIssue
+	for _, rec := range records {
+		if _, ok := r.registry.GetMigration(rec.Version); !ok {
+			if replaced[rec.Version] {
+				continue
+			}
+			issues = append(issues, ChecksumIssue{
+				Version:  rec.Version,
+				Name:     rec.Name,
+				Problem:  ChecksumMissing,
+				Recorded: rec.Checksum,
+			})
+			continue
+		}
+
+		current := r.registry.GetChecksum(rec.Version)
+		if rec.Checksum == "" || current == "" || rec.Checksum == current {
+			continue
+		}
+		issues = append(issues, ChecksumIssue{
+			Version:  rec.Version,
+			Name:     rec.Name,
+			Problem:  ChecksumChanged,
+			Recorded: rec.Checksum,
+			Current:  current,
+		})
+	}
+
+	return issues, nil
+}
+
+// RepairChecksums re-stamps the recorded checksum of every applied migration with its current checksum
+// It is used once a change to an applied migration has been reviewed and accepted.
+// Returns the versions that were updated.
+func (r *Runner) RepairChecksums() ([]string, error) {
+	records, err := r.versioner.GetAppliedRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	var repaired []string
+	for _, rec := range records {
+		current := r.registry.GetChecksum(rec.Version)
+		if current == "" || current == rec.Checksum {
+			continue
+		}
+		if err := r.versioner.SetChecksum(rec.Version, current); err != nil {
+			return repaired, fmt.Errorf("failed to repair checksum of %s: %w", rec.Version, err)
+		}
+		repaired = append(repaired, rec.Version)
+	}
+
+	return repaired, nil
+}
@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/pankajredekar/goosegorm/internal/versioner"
+)
+
+func setupChecksumRunner(t *testing.T) (*Runner, *Registry, *versioner.Versioner) {
+	db := setupTestDB(t)
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	registry := NewRegistry()
+	registry.RegisterMigration(TestMigration{version: "20250101000000", name: "first"})
+	registry.RegisterMigration(TestMigration{version: "20250102000000", name: "second"})
+	registry.SetChecksums(map[string]string{
+		"20250101000000": "aaa",
+		"20250102000000": "bbb",
+	})
+
+	runner := NewRunner(db, registry, ver)
+	if err := runner.Migrate(); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	return runner, registry, ver
+}
+
+func TestMigrateRecordsChecksums(t *testing.T) {
+	_, _, ver := setupChecksumRunner(t)
+
+	records, err := ver.GetAppliedRecords()
+	if err != nil {
+		t.Fatalf("GetAppliedRecords failed: %v", err)
+	}
+	if len(records) != 2 || records[0].Checksum != "aaa" || records[1].Checksum != "bbb" {
+		t.Errorf("Expected recorded checksums aaa and bbb, got %+v", records)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	runner, registry, ver := setupChecksumRunner(t)
+
+	issues, err := runner.Verify()
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if len(issues) != 0 {
+		t.Fatalf("Expected no issues, got %v", issues)
+	}
+
+	// Edit the first migration and record a migration whose file is gone
+	registry.SetChecksums(map[string]string{
+		"20250101000000": "changed",
+		"20250102000000": "bbb",
+	})
+	if err := ver.RecordApplied("20250103000000", "deleted"); err != nil {
+		t.Fatalf("RecordApplied failed: %v", err)
+	}
+
+	issues, err = runner.Verify()
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("Expected 2 issues, got %v", issues)
+	}
+	if issues[0].Version != "20250101000000" || issues[0].Problem != ChecksumChanged {
+		t.Errorf("Expected 20250101000000 to be changed, got %v", issues[0])
+	}
+	if issues[1].Version != "20250103000000" || issues[1].Problem != ChecksumMissing {
+		t.Errorf("Expected 20250103000000 to be missing, got %v", issues[1])
+	}
+}
+
+func TestRepairChecksums(t *testing.T) {
+	runner, registry, _ := setupChecksumRunner(t)
+
+	registry.SetChecksums(map[string]string{
+		"20250101000000": "changed",
+		"20250102000000": "bbb",
+	})
+
+	repaired, err := runner.RepairChecksums()
+	if err != nil {
+		t.Fatalf("RepairChecksums failed: %v", err)
+	}
+	if len(repaired) != 1 || repaired[0] != "20250101000000" {
+		t.Errorf("Expected only 20250101000000 to be repaired, got %v", repaired)
+	}
+
+	issues, err := runner.Verify()
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues after repair, got %v", issues)
+	}
+}
@@ -0,0 +1,100 @@
+package runner
+
+import (
+	"database/sql"
+	"unsafe"
+
+	"github.com/pankajredekar/goosegorm/internal/schema"
+	"gorm.io/gorm"
+)
+
+// DataFunc is the Go code of a data migration
+// With a batch size it is called repeatedly and must return the number of rows it processed;
+// the operation stops once a call processes fewer rows than the batch size.
+// Without one it is called once with batchSize 0 and the row count is ignored.
+type DataFunc func(db *gorm.DB, batchSize int) (rows int64, err error)
+
+// DataOperation is a data change made by a migration, such as a backfill
+// Migrations call its Up and Down from their own Up and Down. Simulation skips it,
+// since data changes don't affect the schema.
+type DataOperation struct {
+	upFunc    DataFunc
+	downFunc  DataFunc
+	upSQL     string
+	downSQL   string
+	batchSize int
+}
+
+// RunGo creates a data operation from Go functions
+// down may be nil if nothing needs to be undone.
+func RunGo(up, down DataFunc) *DataOperation {
+	return &DataOperation{upFunc: up, downFunc: down}
+}
+
+// RunSQL creates a data operation from SQL statements
+// downSQL may be empty if nothing needs to be undone. With a batch size, the statements
+// can reference it as @batch_size (e.g. in a LIMIT).
+func RunSQL(upSQL, downSQL string) *DataOperation {
+	return &DataOperation{upSQL: upSQL, downSQL: downSQL}
+}
+
+// WithBatchSize returns a copy of the operation that runs in batches of size rows
+// Each batch runs in its own transaction, or in a savepoint if the migration itself runs
+// in a transaction; make the migration NonTransactional to commit batches independently.
+func (op *DataOperation) WithBatchSize(size int) *DataOperation {
+	batched := *op
+	batched.batchSize = size
+	return &batched
+}
+
+// Up applies the data change
+func (op *DataOperation) Up(db *gorm.DB) error {
+	return op.run(db, op.upFunc, op.upSQL)
+}
+
+// Down reverts the data change
+func (op *DataOperation) Down(db *gorm.DB) error {
+	return op.run(db, op.downFunc, op.downSQL)
+}
+
+func (op *DataOperation) run(db *gorm.DB, fn DataFunc, statement string) error {
+	if _, simulated := schema.SimulationFor(unsafe.Pointer(db)); simulated {
+		return nil
+	}
+	if fn == nil && statement == "" {
+		return nil
+	}
+
+	step := func(tx *gorm.DB) (int64, error) {
+		if fn != nil {
+			return fn(tx, op.batchSize)
+		}
+		var result *gorm.DB
+		if op.batchSize > 0 {
+			result = tx.Exec(statement, sql.Named("batch_size", op.batchSize))
+		} else {
+			result = tx.Exec(statement)
+		}
+		return result.RowsAffected, result.Error
+	}
+
+	if op.batchSize <= 0 {
+		_, err := step(db)
+		return err
+	}
+
+	for {
+		var rows int64
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			rows, err = step(tx)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if rows < int64(op.batchSize) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package runner
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/pankajredekar/goosegorm/internal/schema"
+	"gorm.io/gorm"
+)
+
+func setupItems(t *testing.T, db *gorm.DB, count int) {
+	if err := db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY, done INTEGER NOT NULL DEFAULT 0)").Error; err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	for i := 1; i <= count; i++ {
+		if err := db.Exec("INSERT INTO items (id) VALUES (?)", i).Error; err != nil {
+			t.Fatalf("Failed to insert row: %v", err)
+		}
+	}
+}
+
+func countDone(t *testing.T, db *gorm.DB) int64 {
+	var count int64
+	if err := db.Table("items").Where("done = 1").Count(&count).Error; err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	return count
+}
+
+func TestRunSQL(t *testing.T) {
+	db := setupTestDB(t)
+	setupItems(t, db, 3)
+
+	op := RunSQL("UPDATE items SET done = 1", "UPDATE items SET done = 0")
+	if err := op.Up(db); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if got := countDone(t, db); got != 3 {
+		t.Errorf("Expected 3 rows updated, got %d", got)
+	}
+
+	if err := op.Down(db); err != nil {
+		t.Fatalf("Down failed: %v", err)
+	}
+	if got := countDone(t, db); got != 0 {
+		t.Errorf("Expected 0 rows left updated, got %d", got)
+	}
+}
+
+func TestRunSQLWithBatchSize(t *testing.T) {
+	db := setupTestDB(t)
+	setupItems(t, db, 5)
+
+	op := RunSQL("UPDATE items SET done = 1 WHERE id IN (SELECT id FROM items WHERE done = 0 LIMIT @batch_size)", "").
+		WithBatchSize(2)
+	if err := op.Up(db); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if got := countDone(t, db); got != 5 {
+		t.Errorf("Expected all 5 rows updated, got %d", got)
+	}
+
+	// No down statement is a no-op
+	if err := op.Down(db); err != nil {
+		t.Fatalf("Down failed: %v", err)
+	}
+	if got := countDone(t, db); got != 5 {
+		t.Errorf("Expected Down to leave rows untouched, got %d updated", got)
+	}
+}
+
+func TestRunGoWithBatchSize(t *testing.T) {
+	db := setupTestDB(t)
+	setupItems(t, db, 5)
+
+	var calls, sizes []int
+	op := RunGo(func(db *gorm.DB, batchSize int) (int64, error) {
+		calls = append(calls, len(calls)+1)
+		sizes = append(sizes, batchSize)
+		result := db.Exec("UPDATE items SET done = 1 WHERE id IN (SELECT id FROM items WHERE done = 0 LIMIT ?)", batchSize)
+		return result.RowsAffected, result.Error
+	}, nil).WithBatchSize(2)
+
+	if err := op.Up(db); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	// Batches of 2, 2 and 1 rows
+	if len(calls) != 3 {
+		t.Errorf("Expected 3 calls, got %d", len(calls))
+	}
+	for _, size := range sizes {
+		if size != 2 {
+			t.Errorf("Expected batch size 2, got %d", size)
+		}
+	}
+	if got := countDone(t, db); got != 5 {
+		t.Errorf("Expected all 5 rows updated, got %d", got)
+	}
+}
+
+func TestRunGoWithoutBatchSize(t *testing.T) {
+	db := setupTestDB(t)
+	setupItems(t, db, 4)
+
+	calls := 0
+	op := RunGo(func(db *gorm.DB, batchSize int) (int64, error) {
+		calls++
+		if batchSize != 0 {
+			t.Errorf("Expected batch size 0, got %d", batchSize)
+		}
+		result := db.Exec("UPDATE items SET done = 1 WHERE id <= 2")
+		return result.RowsAffected, result.Error
+	}, nil)
+
+	if err := op.Up(db); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected a single call, got %d", calls)
+	}
+	if got := countDone(t, db); got != 2 {
+		t.Errorf("Expected 2 rows updated, got %d", got)
+	}
+}
+
+func TestSimulateSchemaSkipsDataOperations(t *testing.T) {
+	db := setupTestDB(t)
+	registry := NewRegistry()
+
+	ran := false
+	backfill := RunGo(func(db *gorm.DB, batchSize int) (int64, error) {
+		ran = true
+		return 0, nil
+	}, nil)
+
+	registry.RegisterMigration(TestMigration{
+		version: "20250101000000",
+		name:    "create_users",
+		upFunc: func(db *gorm.DB) error {
+			if sim, ok := schema.SimulationFor(unsafe.Pointer(db)); ok {
+				sim.CreateTable("users").AddColumn("id", "uint")
+				return nil
+			}
+			return db.Exec("CREATE TABLE users (id INTEGER)").Error
+		},
+	})
+	registry.RegisterMigration(TestMigration{
+		version: "20250102000000",
+		name:    "backfill_users",
+		upFunc:  backfill.Up,
+	})
+
+	run := NewRunner(db, registry, nil)
+	builder, err := run.SimulateSchema()
+	if err != nil {
+		t.Fatalf("SimulateSchema failed: %v", err)
+	}
+	if ran {
+		t.Error("Data operation should not run during simulation")
+	}
+	if !builder.TableExists("users") {
+		t.Error("Table 'users' should exist in the simulated schema")
+	}
+	if _, ok := schema.SimulationFor(unsafe.Pointer(builder)); ok {
+		t.Error("Builder should no longer be registered after simulation")
+	}
+}
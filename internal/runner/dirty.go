@@ -0,0 +1,82 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pankajredekar/goosegorm/internal/dialect"
+	"gorm.io/gorm"
+)
+
+// ErrDirty is matched by errors.Is when migrations are refused because one failed part way through
+var ErrDirty = errors.New("database is dirty")
+
+// DirtyError is returned when a migration failed part way through, leaving the database in an unknown state
+// Nothing runs until an operator fixes the database and resolves the record with ForceState.
+type DirtyError struct {
+	Versions []string
+}
+
+func (e *DirtyError) Error() string {
+	return fmt.Sprintf("migration(s) %s failed part way through and may have left the database half changed; fix the database by hand, then run 'force <version> --state applied|unapplied'",
+		strings.Join(e.Versions, ", "))
+}
+
+// Unwrap lets errors.Is match ErrDirty
+func (e *DirtyError) Unwrap() error {
+	return ErrDirty
+}
+
+// checkDirty returns a *DirtyError if a migration failed part way through
+func (r *Runner) checkDirty() error {
+	records, err := r.versioner.GetDirtyRecords()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	versions := make([]string, len(records))
+	for i, record := range records {
+		versions[i] = record.Version
+	}
+	return &DirtyError{Versions: versions}
+}
+
+// rolledBack reports whether a failure of the migration undid all of its changes
+// That is the case for transactional migrations on databases whose DDL is transactional;
+// elsewhere statements that ran before the failure stay committed.
+func rolledBack(db *gorm.DB, m Migration) bool {
+	if !isTransactional(m) {
+		return false
+	}
+	d := dialect.For(db.Dialector.Name())
+	return d != nil && d.Features().TransactionalDDL
+}
+
+// ForceState records a migration as applied or unapplied without running it, clearing its dirty state
+// Use it after fixing a database that a failed migration left half changed.
+func (r *Runner) ForceState(version string, applied bool) error {
+	return r.withLock(func() error {
+		if !applied {
+			if err := r.versioner.RemoveApplied(version); err != nil {
+				return fmt.Errorf("failed to mark migration %s unapplied: %w", version, err)
+			}
+			return nil
+		}
+
+		m, ok := r.registry.GetMigration(version)
+		if !ok {
+			return fmt.Errorf("migration %s not found in registry", version)
+		}
+		if err := r.versioner.RemoveApplied(version); err != nil {
+			return fmt.Errorf("failed to mark migration %s applied: %w", version, err)
+		}
+		if err := r.versioner.RecordAppliedWithChecksum(m.Version(), m.Name(), r.registry.GetChecksum(m.Version())); err != nil {
+			return fmt.Errorf("failed to mark migration %s applied: %w", version, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,191 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pankajredekar/goosegorm/internal/versioner"
+	"gorm.io/gorm"
+)
+
+// halfFailing creates a table and then fails, outside of a transaction
+func halfFailing(version string) Migration {
+	return NonTransactionalTestMigration{TestMigration{
+		version: version,
+		name:    "half_failing",
+		upFunc: func(db *gorm.DB) error {
+			if err := db.Exec("CREATE TABLE half_done (id INTEGER)").Error; err != nil {
+				return err
+			}
+			return db.Exec("INSERT INTO missing_table VALUES (1)").Error
+		},
+		downFunc: func(db *gorm.DB) error {
+			if err := db.Exec("DROP TABLE half_done").Error; err != nil {
+				return err
+			}
+			return db.Exec("DROP TABLE missing_table").Error
+		},
+	}}
+}
+
+func dirtyVersions(t *testing.T, ver *versioner.Versioner) []string {
+	records, err := ver.GetDirtyRecords()
+	if err != nil {
+		t.Fatalf("GetDirtyRecords failed: %v", err)
+	}
+	versions := make([]string, len(records))
+	for i, record := range records {
+		versions[i] = record.Version
+	}
+	return versions
+}
+
+func TestMigrateFailureLeavesDirtyRecord(t *testing.T) {
+	db := setupTestDB(t)
+	registry := NewRegistry()
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	run := NewRunner(db, registry, ver)
+
+	registry.RegisterMigration(halfFailing("20250101000000"))
+	registry.RegisterMigration(TestMigration{version: "20250102000000", name: "second"})
+
+	if err := run.Migrate(); err == nil {
+		t.Fatal("Migrate should fail")
+	}
+
+	if dirty := dirtyVersions(t, ver); len(dirty) != 1 || dirty[0] != "20250101000000" {
+		t.Fatalf("Expected 20250101000000 to be dirty, got %v", dirty)
+	}
+	if applied, _ := ver.IsApplied("20250101000000"); applied {
+		t.Error("A dirty migration should not count as applied")
+	}
+
+	// Nothing runs until the record is resolved
+	err := run.Migrate()
+	var dirtyErr *DirtyError
+	if !errors.As(err, &dirtyErr) || !errors.Is(err, ErrDirty) {
+		t.Fatalf("Expected a *DirtyError, got %v", err)
+	}
+	if dirtyErr.Versions[0] != "20250101000000" {
+		t.Errorf("Expected the dirty version in the error, got %v", dirtyErr.Versions)
+	}
+	if err := run.Rollback(1); !errors.Is(err, ErrDirty) {
+		t.Errorf("Expected rollback to be refused too, got %v", err)
+	}
+	if err := run.Fake("20250102000000"); !errors.Is(err, ErrDirty) {
+		t.Errorf("Expected fake to be refused too, got %v", err)
+	}
+
+	// The operator finishes the migration by hand and marks it applied
+	if err := db.Exec("CREATE TABLE missing_table (id INTEGER)").Error; err != nil {
+		t.Fatalf("Failed to fix database: %v", err)
+	}
+	if err := run.ForceState("20250101000000", true); err != nil {
+		t.Fatalf("ForceState failed: %v", err)
+	}
+	if dirty := dirtyVersions(t, ver); len(dirty) != 0 {
+		t.Fatalf("Expected no dirty migrations, got %v", dirty)
+	}
+
+	if err := run.Migrate(); err != nil {
+		t.Fatalf("Migrate failed after forcing: %v", err)
+	}
+	versions, err := ver.GetAppliedVersions()
+	if err != nil {
+		t.Fatalf("GetAppliedVersions failed: %v", err)
+	}
+	if len(versions) != 2 {
+		t.Errorf("Expected both migrations applied, got %v", versions)
+	}
+}
+
+func TestForceStateUnapplied(t *testing.T) {
+	db := setupTestDB(t)
+	registry := NewRegistry()
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	run := NewRunner(db, registry, ver)
+	registry.RegisterMigration(halfFailing("20250101000000"))
+
+	if err := run.Migrate(); err == nil {
+		t.Fatal("Migrate should fail")
+	}
+
+	// The operator undoes the half applied changes and marks the migration unapplied
+	if err := db.Exec("DROP TABLE half_done").Error; err != nil {
+		t.Fatalf("Failed to fix database: %v", err)
+	}
+	if err := run.ForceState("20250101000000", false); err != nil {
+		t.Fatalf("ForceState failed: %v", err)
+	}
+
+	pending, err := run.GetPendingMigrations()
+	if err != nil {
+		t.Fatalf("GetPendingMigrations failed: %v", err)
+	}
+	if len(pending) != 1 || len(dirtyVersions(t, ver)) != 0 {
+		t.Errorf("Expected a clean, pending migration, got pending %v", pending)
+	}
+
+	if err := run.ForceState("20250109000000", true); err == nil {
+		t.Error("Expected an error forcing an unknown migration applied")
+	}
+}
+
+func TestRollbackFailure(t *testing.T) {
+	db := setupTestDB(t)
+	registry := NewRegistry()
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	run := NewRunner(db, registry, ver)
+
+	// The first migration's Down fails within its transaction, the second's outside of one
+	transactional := TestMigration{
+		version: "20250101000000",
+		name:    "transactional",
+		downFunc: func(db *gorm.DB) error {
+			return db.Exec("DROP TABLE missing_table").Error
+		},
+	}
+	registry.RegisterMigration(transactional)
+	registry.RegisterMigration(NonTransactionalTestMigration{TestMigration{
+		version: "20250102000000",
+		name:    "non_transactional",
+		downFunc: func(db *gorm.DB) error {
+			return db.Exec("DROP TABLE missing_table").Error
+		},
+	}})
+
+	if err := run.Migrate(); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	if err := run.Rollback(1); err == nil {
+		t.Fatal("Rollback should fail")
+	}
+	if dirty := dirtyVersions(t, ver); len(dirty) != 1 || dirty[0] != "20250102000000" {
+		t.Fatalf("Expected 20250102000000 to be dirty, got %v", dirty)
+	}
+
+	if err := run.ForceState("20250102000000", false); err != nil {
+		t.Fatalf("ForceState failed: %v", err)
+	}
+	if err := run.Rollback(1); err == nil {
+		t.Fatal("Rollback should fail")
+	}
+
+	// The failed transaction undid everything, so the migration is still cleanly applied
+	if dirty := dirtyVersions(t, ver); len(dirty) != 0 {
+		t.Errorf("Expected no dirty migrations, got %v", dirty)
+	}
+	if applied, _ := ver.IsApplied("20250101000000"); !applied {
+		t.Error("Expected the migration to still be applied")
+	}
+}
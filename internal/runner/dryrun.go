@@ -0,0 +1,169 @@
+package runner
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqlCapture is a GORM logger that records every statement it traces
+type sqlCapture struct {
+	logger.Interface
+	statements []string
+}
+
+func (c *sqlCapture) LogMode(logger.LogLevel) logger.Interface { return c }
+
+func (c *sqlCapture) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	if sql == "" || isIntrospectionSQL(sql) {
+		return
+	}
+	c.statements = append(c.statements, sql)
+}
+
+// isIntrospectionSQL reports whether a statement only reads schema metadata
+// GORM's Migrator runs these for real even in dry-run mode, so they are not part of the migration
+func isIntrospectionSQL(sql string) bool {
+	upper := strings.ToUpper(strings.TrimSpace(sql))
+	for _, prefix := range []string{"SELECT", "PRAGMA", "SHOW"} {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// SQLFor returns the SQL statements a migration would execute in the given direction, without running them
+// The migration runs against a session whose connection pool traces statements without executing them,
+// so they are rendered for the connected dialect. GORM's Migrator still inspects the database to decide
+// what to emit, so AutoMigrate renders nothing for a table that already exists as declared.
+func (r *Runner) SQLFor(version string, direction Direction) ([]string, error) {
+	m, ok := r.registry.GetMigration(version)
+	if !ok {
+		return nil, fmt.Errorf("migration %s not found in registry", version)
+	}
+
+	empty := sql.OpenDB(emptyConnector{})
+	defer empty.Close()
+
+	capture := &sqlCapture{Interface: logger.Discard}
+	// A session with a context has its own statement, so replacing its pool leaves r.db alone
+	dry := r.db.Session(&gorm.Session{Context: context.Background(), Logger: capture})
+	dry.Statement.ConnPool = &dryRunPool{db: r.db.Statement.ConnPool, empty: empty}
+
+	err := runDry(func() error {
+		if direction == DirectionDown {
+			return m.Down(dry)
+		}
+		return m.Up(dry)
+	})
+	if err != nil {
+		return capture.statements, fmt.Errorf("failed to render SQL for migration %s: %w", version, err)
+	}
+
+	return capture.statements, nil
+}
+
+// runDry runs fn and converts panics into errors
+// Some Migrator operations cannot run without the results of the statements they execute.
+func runDry(fn func() error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("operation cannot be rendered in dry-run mode: %v", p)
+		}
+	}()
+
+	return fn()
+}
+
+// dryRunPool is the connection pool of SQLFor's session
+// Introspection queries read the database, everything else goes to a database that does nothing.
+// GORM's own DryRun mode isn't used because its AutoMigrate prints the statements to stdout.
+type dryRunPool struct {
+	db    gorm.ConnPool
+	empty *sql.DB
+}
+
+func (p *dryRunPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.empty.PrepareContext(ctx, query)
+}
+
+func (p *dryRunPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return p.empty.ExecContext(ctx, query, args...)
+}
+
+func (p *dryRunPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if isIntrospectionSQL(query) {
+		return p.db.QueryContext(ctx, query, args...)
+	}
+	return p.empty.QueryContext(ctx, query, args...)
+}
+
+func (p *dryRunPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if isIntrospectionSQL(query) {
+		return p.db.QueryRowContext(ctx, query, args...)
+	}
+	return p.empty.QueryRowContext(ctx, query, args...)
+}
+
+// BeginTx lets migrations and Migrator operations use transactions, which commit nothing
+func (p *dryRunPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return &dryRunTx{p}, nil
+}
+
+// GetDBConn keeps db.Connection, which MySQL's DropTable uses, away from the database
+func (p *dryRunPool) GetDBConn() (*sql.DB, error) {
+	return p.empty, nil
+}
+
+// dryRunTx is a transaction of a dryRunPool
+type dryRunTx struct {
+	*dryRunPool
+}
+
+func (dryRunTx) Commit() error   { return nil }
+func (dryRunTx) Rollback() error { return nil }
+
+// emptyConnector is a database/sql driver whose statements do nothing and whose queries return no rows
+type emptyConnector struct{}
+
+func (emptyConnector) Connect(context.Context) (driver.Conn, error) { return emptyConn{}, nil }
+func (c emptyConnector) Driver() driver.Driver                      { return c }
+func (emptyConnector) Open(string) (driver.Conn, error)             { return emptyConn{}, nil }
+
+type emptyConn struct{}
+
+func (emptyConn) Prepare(string) (driver.Stmt, error) { return emptyStmt{}, nil }
+func (emptyConn) Close() error                        { return nil }
+func (emptyConn) Begin() (driver.Tx, error)           { return emptyConn{}, nil }
+func (emptyConn) Commit() error                       { return nil }
+func (emptyConn) Rollback() error                     { return nil }
+
+// CheckNamedValue accepts arguments of any type, as the statements never reach a database
+func (emptyConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+type emptyStmt struct{}
+
+func (emptyStmt) Close() error                               { return nil }
+func (emptyStmt) NumInput() int                              { return -1 }
+func (emptyStmt) Exec([]driver.Value) (driver.Result, error) { return emptyResult{}, nil }
+func (emptyStmt) Query([]driver.Value) (driver.Rows, error)  { return emptyRows{}, nil }
+
+type emptyResult struct{}
+
+func (emptyResult) LastInsertId() (int64, error) { return 0, nil }
+func (emptyResult) RowsAffected() (int64, error) { return 0, nil }
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string         { return nil }
+func (emptyRows) Close() error              { return nil }
+func (emptyRows) Next([]driver.Value) error { return io.EOF }
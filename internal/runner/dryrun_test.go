@@ -0,0 +1,123 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pankajredekar/goosegorm/internal/versioner"
+	"gorm.io/gorm"
+)
+
+func TestSQLFor(t *testing.T) {
+	db := setupTestDB(t)
+	registry := NewRegistry()
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	type Product struct {
+		ID   uint   `gorm:"primaryKey"`
+		Name string `gorm:"not null"`
+	}
+
+	registry.RegisterMigration(TestMigration{
+		version: "20250101000000",
+		name:    "create_product",
+		upFunc: func(db *gorm.DB) error {
+			if err := db.Table("product").AutoMigrate(&Product{}); err != nil {
+				return err
+			}
+			return db.Exec("CREATE INDEX idx_name ON product (name)").Error
+		},
+		downFunc: func(db *gorm.DB) error {
+			return db.Migrator().DropTable("product")
+		},
+	})
+
+	run := NewRunner(db, registry, ver)
+
+	up, err := run.SQLFor("20250101000000", DirectionUp)
+	if err != nil {
+		t.Fatalf("SQLFor up failed: %v", err)
+	}
+	if len(up) != 2 {
+		t.Fatalf("Expected 2 statements, got %d: %v", len(up), up)
+	}
+	if !strings.HasPrefix(up[0], "CREATE TABLE") || !strings.HasPrefix(up[1], "CREATE INDEX") {
+		t.Errorf("Unexpected statements: %v", up)
+	}
+
+	// Nothing should have been executed
+	if db.Migrator().HasTable("product") {
+		t.Error("SQLFor should not execute the migration")
+	}
+
+	down, err := run.SQLFor("20250101000000", DirectionDown)
+	if err != nil {
+		t.Fatalf("SQLFor down failed: %v", err)
+	}
+	if len(down) != 1 || !strings.HasPrefix(down[0], "DROP TABLE") {
+		t.Errorf("Unexpected down statements: %v", down)
+	}
+}
+
+func TestSQLForUnknownVersion(t *testing.T) {
+	db := setupTestDB(t)
+	run := NewRunner(db, NewRegistry(), versioner.NewVersioner(db, "_test_migrations"))
+
+	if _, err := run.SQLFor("20990101000000", DirectionUp); err == nil {
+		t.Error("SQLFor should fail for a version not in the registry")
+	}
+}
+
+type dryRunProduct struct {
+	ID   uint
+	Name string
+	SKU  string
+}
+
+func (dryRunProduct) TableName() string { return "product" }
+
+func TestSQLForDataAndTransactions(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.Exec("CREATE TABLE product (id integer PRIMARY KEY, name text, sku text)").Error; err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	registry := NewRegistry()
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	registry.RegisterMigration(TestMigration{
+		version: "20250101000000",
+		name:    "seed_product",
+		upFunc: func(db *gorm.DB) error {
+			return db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Create(&dryRunProduct{Name: "gear"}).Error; err != nil {
+					return err
+				}
+				return tx.Migrator().DropColumn(&dryRunProduct{}, "sku")
+			})
+		},
+	})
+
+	run := NewRunner(db, registry, ver)
+	up, err := run.SQLFor("20250101000000", DirectionUp)
+	if err != nil {
+		t.Fatalf("SQLFor failed: %v", err)
+	}
+	if len(up) < 2 || !strings.HasPrefix(up[0], "INSERT INTO") {
+		t.Fatalf("Expected the insert and the table rebuild of DropColumn, got %v", up)
+	}
+
+	// Nothing should have been executed
+	var count int64
+	if err := db.Table("product").Count(&count).Error; err != nil || count != 0 {
+		t.Errorf("SQLFor should not insert rows, got %d (%v)", count, err)
+	}
+	if !db.Migrator().HasColumn("product", "sku") {
+		t.Error("SQLFor should not drop columns")
+	}
+}
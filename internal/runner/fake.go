@@ -0,0 +1,157 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pankajredekar/goosegorm/internal/schema"
+	"gorm.io/gorm"
+)
+
+// Fake records a migration as applied without running it
+// Use it to adopt a database whose schema already contains the migration's changes.
+func (r *Runner) Fake(version string) error {
+	return r.withLock(func() error {
+		m, ok := r.registry.GetMigration(version)
+		if !ok {
+			return fmt.Errorf("migration %s not found in registry", version)
+		}
+		if err := r.checkDirty(); err != nil {
+			return err
+		}
+
+		applied, err := r.versioner.IsApplied(version)
+		if err != nil {
+			return fmt.Errorf("failed to check migration %s: %w", version, err)
+		}
+		if applied {
+			return fmt.Errorf("migration %s is already applied", version)
+		}
+
+		if err := r.versioner.RecordAppliedWithChecksum(m.Version(), m.Name(), r.registry.GetChecksum(m.Version())); err != nil {
+			return fmt.Errorf("failed to record migration %s: %w", version, err)
+		}
+		return nil
+	})
+}
+
+// SetFakeInitial controls whether migrating fakes create-table migrations whose tables all exist
+// Such migrations are recorded as applied without running their Up method.
+func (r *Runner) SetFakeInitial(fakeInitial bool) {
+	r.fakeInitial = fakeInitial
+}
+
+// fakedMigration records a migration as applied without running its Up method
+type fakedMigration struct {
+	Migration
+}
+
+func (fakedMigration) Up(*gorm.DB) error { return nil }
+
+// GetFakeInitialMigrations returns the pending migrations that SetFakeInitial would fake
+func (r *Runner) GetFakeInitialMigrations() ([]Migration, error) {
+	pending, err := r.GetPendingMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	fake, err := r.initialMigrations(pending)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	for _, m := range pending {
+		if fake[m.Version()] {
+			migrations = append(migrations, m)
+		}
+	}
+	return migrations, nil
+}
+
+// withFakeInitial replaces the create-table migrations whose tables all exist with faked ones
+func (r *Runner) withFakeInitial(migrations []Migration) ([]Migration, error) {
+	fake, err := r.initialMigrations(migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Migration, len(migrations))
+	for i, m := range migrations {
+		if fake[m.Version()] {
+			result[i] = fakedMigration{m}
+		} else {
+			result[i] = m
+		}
+	}
+	return result, nil
+}
+
+// initialMigrations returns the versions of the candidates that create tables which all exist already
+func (r *Runner) initialMigrations(candidates []Migration) (map[string]bool, error) {
+	created, err := r.CreatedTables()
+	if err != nil {
+		return nil, err
+	}
+
+	migrator := r.db.Migrator()
+	initial := make(map[string]bool)
+	for _, m := range candidates {
+		tables := created[m.Version()]
+		if len(tables) == 0 {
+			continue
+		}
+
+		exists := true
+		for _, table := range tables {
+			if !migrator.HasTable(table) {
+				exists = false
+				break
+			}
+		}
+		if exists {
+			initial[m.Version()] = true
+		}
+	}
+	return initial, nil
+}
+
+// CreatedTables returns the tables each migration creates, keyed by version
+// Tables set with Registry.SetCreatedTables are used as is; otherwise every migration
+// is simulated in order, which requires the migrations to support simulation.
+func (r *Runner) CreatedTables() (map[string][]string, error) {
+	if r.registry.createdTables != nil {
+		return r.registry.createdTables, nil
+	}
+
+	builder := schema.NewSchemaBuilder()
+	schema.BeginSimulation(builder)
+	defer schema.EndSimulation(builder)
+
+	migrations, err := r.registry.GetAllMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	created := make(map[string][]string)
+	for _, m := range migrations {
+		before := make(map[string]bool)
+		for name := range builder.Schema.Tables {
+			before[name] = true
+		}
+
+		if err := callMigrationUp(m, builder); err != nil {
+			return nil, fmt.Errorf("failed to simulate migration %s: %w", m.Version(), err)
+		}
+
+		var tables []string
+		for name := range builder.Schema.Tables {
+			if !before[name] {
+				tables = append(tables, name)
+			}
+		}
+		sort.Strings(tables)
+		created[m.Version()] = tables
+	}
+	return created, nil
+}
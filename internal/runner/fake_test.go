@@ -0,0 +1,145 @@
+package runner
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/pankajredekar/goosegorm/internal/schema"
+	"github.com/pankajredekar/goosegorm/internal/versioner"
+	"gorm.io/gorm"
+)
+
+// createTableMigration creates a table, recording whether its real Up ran
+func createTableMigration(version, table string, ran map[string]bool) TestMigration {
+	return TestMigration{
+		version: version,
+		name:    "create_" + table,
+		upFunc: func(db *gorm.DB) error {
+			if sim, ok := schema.SimulationFor(unsafe.Pointer(db)); ok {
+				sim.CreateTable(table).AddColumn("id", "uint")
+				return nil
+			}
+			ran[version] = true
+			return db.Exec("CREATE TABLE " + table + " (id INTEGER)").Error
+		},
+	}
+}
+
+func TestFake(t *testing.T) {
+	db := setupTestDB(t)
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	ran := make(map[string]bool)
+	registry := NewRegistry()
+	registry.RegisterMigration(createTableMigration("20250101000000", "users", ran))
+	registry.SetChecksums(map[string]string{"20250101000000": "abc"})
+	run := NewRunner(db, registry, ver)
+
+	if err := run.Fake("20250101000000"); err != nil {
+		t.Fatalf("Fake failed: %v", err)
+	}
+	if ran["20250101000000"] {
+		t.Error("Faked migration should not run")
+	}
+	if db.Migrator().HasTable("users") {
+		t.Error("Faked migration should not create its table")
+	}
+
+	records, err := ver.GetAppliedRecords()
+	if err != nil {
+		t.Fatalf("GetAppliedRecords failed: %v", err)
+	}
+	if len(records) != 1 || records[0].Version != "20250101000000" || records[0].Checksum != "abc" {
+		t.Errorf("Expected faked migration recorded with its checksum, got %+v", records)
+	}
+
+	if err := run.Fake("20250101000000"); err == nil {
+		t.Error("Faking an applied migration should fail")
+	}
+	if err := run.Fake("20259999999999"); err == nil {
+		t.Error("Faking an unknown migration should fail")
+	}
+}
+
+func TestMigrateFakeInitial(t *testing.T) {
+	db := setupTestDB(t)
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	// The users table predates goosegorm
+	if err := db.Exec("CREATE TABLE users (id INTEGER)").Error; err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	ran := make(map[string]bool)
+	registry := NewRegistry()
+	registry.RegisterMigration(createTableMigration("20250101000000", "users", ran))
+	registry.RegisterMigration(createTableMigration("20250102000000", "orders", ran))
+	registry.RegisterMigration(TestMigration{
+		version: "20250103000000",
+		name:    "backfill",
+		upFunc: func(db *gorm.DB) error {
+			if _, ok := schema.SimulationFor(unsafe.Pointer(db)); ok {
+				return nil
+			}
+			ran["20250103000000"] = true
+			return nil
+		},
+	})
+
+	run := NewRunner(db, registry, ver)
+	run.SetFakeInitial(true)
+
+	fakes, err := run.GetFakeInitialMigrations()
+	if err != nil {
+		t.Fatalf("GetFakeInitialMigrations failed: %v", err)
+	}
+	if len(fakes) != 1 || fakes[0].Version() != "20250101000000" {
+		t.Errorf("Expected only 20250101000000 to be faked, got %v", fakes)
+	}
+
+	if err := run.Migrate(); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	if ran["20250101000000"] {
+		t.Error("Migration whose tables exist should be faked")
+	}
+	if !ran["20250102000000"] || !ran["20250103000000"] {
+		t.Errorf("Other migrations should run, ran %v", ran)
+	}
+
+	count, err := ver.GetAppliedCount()
+	if err != nil {
+		t.Fatalf("GetAppliedCount failed: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 applied migrations, got %d", count)
+	}
+}
+
+func TestCreatedTablesUsesRegistry(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterMigration(TestMigration{
+		version: "20250101000000",
+		name:    "compiled",
+		upFunc: func(db *gorm.DB) error {
+			t.Error("Migrations should not be simulated when created tables are set")
+			return nil
+		},
+	})
+	registry.SetCreatedTables(map[string][]string{"20250101000000": {"users"}})
+
+	created, err := NewRunner(nil, registry, nil).CreatedTables()
+	if err != nil {
+		t.Fatalf("CreatedTables failed: %v", err)
+	}
+	if tables := created["20250101000000"]; len(tables) != 1 || tables[0] != "users" {
+		t.Errorf("Expected [users], got %v", tables)
+	}
+}
@@ -0,0 +1,254 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DependentMigration can be implemented by migrations that declare the migrations they depend on
+// Migrations that don't implement it depend on the migration before them in version order,
+// so projects without declared dependencies keep running strictly in version order.
+type DependentMigration interface {
+	Dependencies() []string
+}
+
+// MissingDependencyError is returned when a migration depends on a version that isn't registered
+type MissingDependencyError struct {
+	Version    string
+	Dependency string
+}
+
+func (e *MissingDependencyError) Error() string {
+	return fmt.Sprintf("migration %s depends on %s, which does not exist", e.Version, e.Dependency)
+}
+
+// CycleError is returned when migration dependencies form a cycle
+type CycleError struct {
+	Cycle []string // each version depends on the one before it; the first depends on the last
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("migration dependency cycle: %s -> %s", strings.Join(e.Cycle, " -> "), e.Cycle[0])
+}
+
+// graph is the dependency graph of the registered migrations
+type graph struct {
+	parents  map[string][]string
+	children map[string][]string
+	order    []Migration // topological order, ties broken by version
+
+	// Squashed migrations in use and the versions they replace
+	replaces   map[string][]string
+	replacedBy map[string]string
+}
+
+// dependenciesOf returns the declared dependencies of a migration and whether it declares any
+func dependenciesOf(m Migration) ([]string, bool) {
+	if dm, ok := m.(DependentMigration); ok {
+		return dm.Dependencies(), true
+	}
+	return nil, false
+}
+
+// buildGraph builds the dependency graph and orders it topologically
+// Squashed migrations are used in place of the migrations they replace.
+func (r *Registry) buildGraph() (*graph, error) {
+	return r.buildGraphFor(nil)
+}
+
+// buildGraphFor builds the dependency graph for a database with the given migrations applied
+// Squashed migrations whose replaced migrations are partly applied are left out in favor of those.
+func (r *Registry) buildGraphFor(applied map[string]bool) (*graph, error) {
+	replacedBy, unused, err := r.squashes(applied)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &graph{
+		parents:    make(map[string][]string),
+		children:   make(map[string][]string),
+		replaces:   make(map[string][]string),
+		replacedBy: replacedBy,
+	}
+
+	// A squashed migration takes the place of the last migration it replaces
+	position := make(map[string]string)
+	var nodes []Migration
+	for _, m := range r.migrationsByVersion() {
+		if _, ok := replacedBy[m.Version()]; ok {
+			continue
+		}
+		if _, ok := unused[m.Version()]; ok {
+			continue
+		}
+		position[m.Version()] = m.Version()
+		if replaces := replacesOf(m); len(replaces) > 0 {
+			g.replaces[m.Version()] = replaces
+			last := replaces[0]
+			for _, version := range replaces {
+				if version > last {
+					last = version
+				}
+			}
+			position[m.Version()] = last
+		}
+		nodes = append(nodes, m)
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return position[nodes[i].Version()] < position[nodes[j].Version()]
+	})
+
+	for i, m := range nodes {
+		deps, declared := dependenciesOf(m)
+		if !declared && i > 0 {
+			deps = []string{nodes[i-1].Version()}
+		}
+
+		seen := make(map[string]bool)
+		for _, dep := range deps {
+			// Dependencies on replaced migrations move to the squashed migration, and the other way round
+			targets := []string{dep}
+			if squashed, ok := replacedBy[dep]; ok {
+				targets = []string{squashed}
+			} else if replaces, ok := unused[dep]; ok {
+				targets = replaces
+			}
+
+			for _, target := range targets {
+				if target == m.Version() || seen[target] {
+					continue
+				}
+				seen[target] = true
+				if _, ok := r.migrations[target]; !ok {
+					return nil, &MissingDependencyError{Version: m.Version(), Dependency: target}
+				}
+				g.parents[m.Version()] = append(g.parents[m.Version()], target)
+				g.children[target] = append(g.children[target], m.Version())
+			}
+		}
+	}
+
+	// Kahn's algorithm, always taking the earliest ready migration
+	remaining := make(map[string]int)
+	var ready []string
+	for _, m := range nodes {
+		remaining[m.Version()] = len(g.parents[m.Version()])
+		if remaining[m.Version()] == 0 {
+			ready = append(ready, m.Version())
+		}
+	}
+
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool {
+			if position[ready[i]] != position[ready[j]] {
+				return position[ready[i]] < position[ready[j]]
+			}
+			return ready[i] < ready[j]
+		})
+		version := ready[0]
+		ready = ready[1:]
+		delete(remaining, version)
+		g.order = append(g.order, r.migrations[version])
+
+		for _, child := range g.children[version] {
+			remaining[child]--
+			if remaining[child] == 0 {
+				ready = append(ready, child)
+			}
+		}
+	}
+
+	if len(remaining) > 0 {
+		return nil, &CycleError{Cycle: g.findCycle(remaining)}
+	}
+
+	return g, nil
+}
+
+// findCycle returns a cycle among the versions Kahn's algorithm could not order
+// Each of them still has an unordered parent, so following parents must loop.
+func (g *graph) findCycle(remaining map[string]int) []string {
+	var start string
+	for version := range remaining {
+		if start == "" || version < start {
+			start = version
+		}
+	}
+
+	seen := make(map[string]int)
+	var path []string
+	for version := start; ; {
+		if i, ok := seen[version]; ok {
+			cycle := path[i:]
+			// path follows parents; reverse it so each version depends on the one before it
+			for l, r := 0, len(cycle)-1; l < r; l, r = l+1, r-1 {
+				cycle[l], cycle[r] = cycle[r], cycle[l]
+			}
+			return cycle
+		}
+		seen[version] = len(path)
+		path = append(path, version)
+
+		for _, parent := range g.parents[version] {
+			if _, ok := remaining[parent]; ok {
+				version = parent
+				break
+			}
+		}
+	}
+}
+
+// ancestors returns the version and every migration it transitively depends on
+func (g *graph) ancestors(version string) map[string]bool {
+	return g.walk(version, g.parents)
+}
+
+// descendants returns the version and every migration that transitively depends on it
+func (g *graph) descendants(version string) map[string]bool {
+	return g.walk(version, g.children)
+}
+
+func (g *graph) walk(version string, edges map[string][]string) map[string]bool {
+	seen := map[string]bool{version: true}
+	stack := []string{version}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, next := range edges[current] {
+			if !seen[next] {
+				seen[next] = true
+				stack = append(stack, next)
+			}
+		}
+	}
+	return seen
+}
+
+// leaves returns the versions no other migration depends on, sorted
+func (g *graph) leaves() []string {
+	var leaves []string
+	for _, m := range g.order {
+		if len(g.children[m.Version()]) == 0 {
+			leaves = append(leaves, m.Version())
+		}
+	}
+	sort.Strings(leaves)
+	return leaves
+}
+
+// Validate checks the migration dependency graph for missing dependencies and cycles
+func (r *Registry) Validate() error {
+	_, err := r.buildGraph()
+	return err
+}
+
+// GetLeaves returns the versions of the migrations no other migration depends on
+// A new migration depending on all of them runs after every existing migration.
+func (r *Registry) GetLeaves() ([]string, error) {
+	g, err := r.buildGraph()
+	if err != nil {
+		return nil, err
+	}
+	return g.leaves(), nil
+}
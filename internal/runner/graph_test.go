@@ -0,0 +1,171 @@
+package runner
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// DependentTestMigration implements DependentMigration
+type DependentTestMigration struct {
+	TestMigration
+	dependencies []string
+}
+
+func (m DependentTestMigration) Dependencies() []string { return m.dependencies }
+
+func dependent(version string, dependencies ...string) DependentTestMigration {
+	return DependentTestMigration{
+		TestMigration: TestMigration{version: version, name: "m" + version},
+		dependencies:  dependencies,
+	}
+}
+
+func versionsOf(migrations []Migration) []string {
+	versions := make([]string, len(migrations))
+	for i, m := range migrations {
+		versions[i] = m.Version()
+	}
+	return versions
+}
+
+func TestGetAllMigrationsDependencyOrder(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterMigration(TestMigration{version: "001", name: "first"})
+	registry.RegisterMigration(TestMigration{version: "002", name: "second"})
+	// 003 needs 005, which in turn only needs 001
+	registry.RegisterMigration(dependent("003", "002", "005"))
+	registry.RegisterMigration(dependent("004", "002"))
+	registry.RegisterMigration(dependent("005", "001"))
+
+	all, err := registry.GetAllMigrations()
+	if err != nil {
+		t.Fatalf("GetAllMigrations failed: %v", err)
+	}
+	expected := []string{"001", "002", "004", "005", "003"}
+	if got := versionsOf(all); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected order %v, got %v", expected, got)
+	}
+
+	leaves, err := registry.GetLeaves()
+	if err != nil {
+		t.Fatalf("GetLeaves failed: %v", err)
+	}
+	if !reflect.DeepEqual(leaves, []string{"003", "004"}) {
+		t.Errorf("Expected leaves [003 004], got %v", leaves)
+	}
+}
+
+func TestGetLeavesWithoutDependencies(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterMigration(TestMigration{version: "001", name: "first"})
+	registry.RegisterMigration(TestMigration{version: "002", name: "second"})
+
+	// Undeclared dependencies chain migrations in version order
+	leaves, err := registry.GetLeaves()
+	if err != nil {
+		t.Fatalf("GetLeaves failed: %v", err)
+	}
+	if !reflect.DeepEqual(leaves, []string{"002"}) {
+		t.Errorf("Expected leaves [002], got %v", leaves)
+	}
+}
+
+func TestValidateMissingDependency(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterMigration(dependent("001"))
+	registry.RegisterMigration(dependent("002", "000"))
+
+	var missing *MissingDependencyError
+	if err := registry.Validate(); !errors.As(err, &missing) {
+		t.Fatalf("Expected MissingDependencyError, got %v", err)
+	}
+	if missing.Version != "002" || missing.Dependency != "000" {
+		t.Errorf("Unexpected error details: %+v", missing)
+	}
+
+	if _, err := registry.GetAllMigrations(); !errors.As(err, &missing) {
+		t.Errorf("GetAllMigrations should return the MissingDependencyError, got %v", err)
+	}
+	if _, err := NewRunner(nil, registry, nil).SimulateSchema(); !errors.As(err, &missing) {
+		t.Errorf("SimulateSchema should return the MissingDependencyError, got %v", err)
+	}
+}
+
+func TestValidateCycle(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterMigration(dependent("001"))
+	registry.RegisterMigration(dependent("002", "001", "004"))
+	registry.RegisterMigration(dependent("003", "002"))
+	registry.RegisterMigration(dependent("004", "003"))
+
+	var cycle *CycleError
+	if err := registry.Validate(); !errors.As(err, &cycle) {
+		t.Fatalf("Expected CycleError, got %v", err)
+	}
+	if len(cycle.Cycle) != 3 {
+		t.Errorf("Expected a cycle of 3 migrations, got %v", cycle.Cycle)
+	}
+
+	run, _ := setupFileDBRunner(t)
+	run.registry = registry
+	if err := run.Migrate(); !errors.As(err, &cycle) {
+		t.Errorf("Migrate should refuse a cyclic graph, got %v", err)
+	}
+	if _, err := registry.GetAllMigrations(); !errors.As(err, &cycle) {
+		t.Errorf("GetAllMigrations should refuse a cyclic graph, got %v", err)
+	}
+	if _, err := run.SimulateSchema(); !errors.As(err, &cycle) {
+		t.Errorf("SimulateSchema should refuse a cyclic graph, got %v", err)
+	}
+}
+
+func TestDependencyGraphRunner(t *testing.T) {
+	run, ver := setupFileDBRunner(t,
+		dependent("001"),
+		dependent("002", "001"),
+		dependent("003", "001"),
+	)
+
+	// Team B's 002 is still pending after team A's 003 was applied; it depends only on 001
+	if err := run.MigrateTo("003"); err != nil {
+		t.Fatalf("MigrateTo failed: %v", err)
+	}
+	versions, err := ver.GetAppliedVersions()
+	if err != nil {
+		t.Fatalf("GetAppliedVersions failed: %v", err)
+	}
+	if !reflect.DeepEqual(versions, []string{"001", "003"}) {
+		t.Fatalf("Expected 003 and its dependency to be applied, got %v", versions)
+	}
+
+	// 002 depends only on 001 but is older than the applied 003, so it is still out of order
+	older, err := run.GetOutOfOrderMigrations()
+	if err != nil {
+		t.Fatalf("GetOutOfOrderMigrations failed: %v", err)
+	}
+	if !reflect.DeepEqual(versionsOf(older), []string{"002"}) {
+		t.Errorf("Expected 002 to be out of order, got %v", versionsOf(older))
+	}
+	var outOfOrderErr *OutOfOrderError
+	if err := run.Migrate(); !errors.As(err, &outOfOrderErr) {
+		t.Fatalf("Expected OutOfOrderError, got %v", err)
+	}
+	if !reflect.DeepEqual(outOfOrderErr.Versions, []string{"002"}) || outOfOrderErr.LatestApplied != "003" {
+		t.Errorf("Unexpected error details: %+v", outOfOrderErr)
+	}
+
+	run.SetAllowOutOfOrder(true)
+	if err := run.Migrate(); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	// Rolling back to 001 rolls back everything that depends on it
+	plan, err := run.PlanTo("001")
+	if err != nil {
+		t.Fatalf("PlanTo failed: %v", err)
+	}
+	if plan.Direction != DirectionDown || len(plan.Migrations) != 2 {
+		t.Errorf("Expected to roll back 002 and 003, got %s %v", plan.Direction, versionsOf(plan.Migrations))
+	}
+}
@@ -0,0 +1,140 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// EventType identifies a point in a migration run
+type EventType string
+
+const (
+	// EventBeforeAll fires once before the first migration of a run
+	EventBeforeAll EventType = "before_all"
+	// EventBeforeMigration fires before each migration
+	EventBeforeMigration EventType = "before_migration"
+	// EventAfterMigration fires after each migration that succeeded
+	EventAfterMigration EventType = "after_migration"
+	// EventMigrationFailed fires after each migration that failed
+	EventMigrationFailed EventType = "migration_failed"
+	// EventAfterAll fires once after the run, whether it succeeded or not
+	EventAfterAll EventType = "after_all"
+)
+
+// Event describes a point in a migration run
+// Version and Name are empty for EventBeforeAll and EventAfterAll.
+// Duration is set on every event after the work it describes; for EventAfterAll it covers the whole run.
+type Event struct {
+	Type      EventType
+	Version   string
+	Name      string
+	Direction Direction
+	Duration  time.Duration
+	Err       error
+}
+
+// Hook is called when a runner event fires
+// An error returned from a BeforeAll, BeforeMigration or AfterMigration hook stops the run;
+// errors from MigrationFailed and AfterAll hooks are added to the run's error.
+type Hook func(ctx context.Context, event Event) error
+
+type registeredHook struct {
+	eventType EventType // empty for listeners, which receive every event
+	hook      Hook
+}
+
+// On registers a hook for one event type
+func (r *Runner) On(eventType EventType, hook Hook) {
+	r.hooks = append(r.hooks, registeredHook{eventType: eventType, hook: hook})
+}
+
+// AddListener registers a hook that receives every event
+func (r *Runner) AddListener(hook Hook) {
+	r.hooks = append(r.hooks, registeredHook{hook: hook})
+}
+
+// emit calls the hooks registered for the event, in registration order
+func (r *Runner) emit(ctx context.Context, event Event) error {
+	for _, h := range r.hooks {
+		if h.eventType != "" && h.eventType != event.Type {
+			continue
+		}
+		if err := h.hook(ctx, event); err != nil {
+			return fmt.Errorf("%s hook failed: %w", event.Type, err)
+		}
+	}
+	return nil
+}
+
+// run executes migrations in the given direction, firing hooks around the run and each migration
+// Nothing fires when there are no migrations to run.
+func (r *Runner) run(ctx context.Context, direction Direction, migrations []Migration) (err error) {
+	if err := r.checkDirty(); err != nil {
+		return err
+	}
+	if len(migrations) == 0 {
+		return nil
+	}
+
+	// Refuse the whole rollback up front rather than stopping partway through it
+	if direction == DirectionDown {
+		if err := r.checkReversible(migrations); err != nil {
+			return err
+		}
+	}
+	if direction == DirectionUp && r.fakeInitial {
+		if migrations, err = r.withFakeInitial(migrations); err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	if err := r.emit(ctx, Event{Type: EventBeforeAll, Direction: direction}); err != nil {
+		return err
+	}
+	defer func() {
+		afterAll := Event{Type: EventAfterAll, Direction: direction, Duration: time.Since(start), Err: err}
+		if hookErr := r.emit(ctx, afterAll); hookErr != nil {
+			err = errors.Join(err, hookErr)
+		}
+	}()
+
+	for _, m := range migrations {
+		if err := checkStopped(ctx, m); err != nil {
+			return err
+		}
+
+		event := Event{Version: m.Version(), Name: m.Name(), Direction: direction}
+
+		event.Type = EventBeforeMigration
+		if err := r.emit(ctx, event); err != nil {
+			return err
+		}
+
+		migrationStart := time.Now()
+		if direction == DirectionDown {
+			err = r.revertMigration(ctx, m)
+		} else {
+			err = r.applyMigration(ctx, m)
+		}
+		event.Duration = time.Since(migrationStart)
+
+		if err != nil {
+			event.Type = EventMigrationFailed
+			event.Err = err
+			if hookErr := r.emit(ctx, event); hookErr != nil {
+				return errors.Join(err, hookErr)
+			}
+			return err
+		}
+
+		event.Type = EventAfterMigration
+		if err := r.emit(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/pankajredekar/goosegorm/internal/versioner"
+	"gorm.io/gorm"
+)
+
+func setupHookRunner(t *testing.T, migrations ...Migration) (*Runner, *[]string) {
+	db := setupTestDB(t)
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	registry := NewRegistry()
+	for _, m := range migrations {
+		registry.RegisterMigration(m)
+	}
+	run := NewRunner(db, registry, ver)
+
+	var events []string
+	run.AddListener(func(ctx context.Context, e Event) error {
+		events = append(events, fmt.Sprintf("%s %s %s", e.Type, e.Version, e.Direction))
+		return nil
+	})
+	return run, &events
+}
+
+func TestHooksFireAroundRun(t *testing.T) {
+	run, events := setupHookRunner(t,
+		TestMigration{version: "20250101000000", name: "first"},
+		TestMigration{version: "20250102000000", name: "second"},
+	)
+
+	if err := run.Migrate(); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if err := run.Rollback(1); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+
+	expected := []string{
+		"before_all  up",
+		"before_migration 20250101000000 up",
+		"after_migration 20250101000000 up",
+		"before_migration 20250102000000 up",
+		"after_migration 20250102000000 up",
+		"after_all  up",
+		"before_all  down",
+		"before_migration 20250102000000 down",
+		"after_migration 20250102000000 down",
+		"after_all  down",
+	}
+	if !reflect.DeepEqual(*events, expected) {
+		t.Errorf("Unexpected events:\n got %v\nwant %v", *events, expected)
+	}
+
+	// Nothing pending, nothing fires
+	*events = nil
+	if err := run.MigrateTo("20250101000000"); err != nil {
+		t.Fatalf("MigrateTo failed: %v", err)
+	}
+	if len(*events) != 0 {
+		t.Errorf("No events should fire without migrations to run, got %v", *events)
+	}
+}
+
+func TestHooksMigrationFailed(t *testing.T) {
+	upErr := errors.New("boom")
+	run, events := setupHookRunner(t,
+		TestMigration{version: "20250101000000", name: "failing", upFunc: func(db *gorm.DB) error { return upErr }},
+		TestMigration{version: "20250102000000", name: "second"},
+	)
+
+	var failed, afterAll Event
+	run.On(EventMigrationFailed, func(ctx context.Context, e Event) error {
+		failed = e
+		return nil
+	})
+	run.On(EventAfterAll, func(ctx context.Context, e Event) error {
+		afterAll = e
+		return nil
+	})
+
+	if err := run.Migrate(); !errors.Is(err, upErr) {
+		t.Fatalf("Expected migration error, got %v", err)
+	}
+
+	expected := []string{
+		"before_all  up",
+		"before_migration 20250101000000 up",
+		"migration_failed 20250101000000 up",
+		"after_all  up",
+	}
+	if !reflect.DeepEqual(*events, expected) {
+		t.Errorf("Unexpected events:\n got %v\nwant %v", *events, expected)
+	}
+	if failed.Name != "failing" || !errors.Is(failed.Err, upErr) {
+		t.Errorf("MigrationFailed event should carry the migration and its error, got %+v", failed)
+	}
+	if !errors.Is(afterAll.Err, upErr) {
+		t.Errorf("AfterAll event should carry the run error, got %+v", afterAll)
+	}
+}
+
+func TestBeforeMigrationHookAbortsRun(t *testing.T) {
+	ran := false
+	run, _ := setupHookRunner(t, TestMigration{
+		version: "20250101000000",
+		name:    "first",
+		upFunc: func(db *gorm.DB) error {
+			ran = true
+			return nil
+		},
+	})
+
+	hookErr := errors.New("workers still running")
+	run.On(EventBeforeMigration, func(ctx context.Context, e Event) error {
+		return hookErr
+	})
+
+	if err := run.Migrate(); !errors.Is(err, hookErr) {
+		t.Fatalf("Expected hook error, got %v", err)
+	}
+	if ran {
+		t.Error("Migration should not run when a BeforeMigration hook fails")
+	}
+}
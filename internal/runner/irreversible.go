@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrIrreversible is returned by the Down method of a migration that cannot be undone
+var ErrIrreversible = errors.New("migration is irreversible")
+
+// IrreversibleMigration can be implemented by migrations that cannot be rolled back,
+// such as ones that drop a table or make a lossy type change
+// Rollbacks that include such a migration are refused before anything runs.
+type IrreversibleMigration interface {
+	Irreversible() bool
+}
+
+// isIrreversible reports whether a migration is marked as irreversible
+func isIrreversible(m Migration) bool {
+	im, ok := m.(IrreversibleMigration)
+	return ok && im.Irreversible()
+}
+
+// IrreversibleError is returned when a rollback includes irreversible migrations
+type IrreversibleError struct {
+	Versions []string
+}
+
+func (e *IrreversibleError) Error() string {
+	return fmt.Sprintf("cannot roll back irreversible migration(s) %s; use --force to skip them and mark them as unapplied",
+		strings.Join(e.Versions, ", "))
+}
+
+// Unwrap lets errors.Is match ErrIrreversible
+func (e *IrreversibleError) Unwrap() error {
+	return ErrIrreversible
+}
+
+// SetForce controls whether rollbacks skip irreversible migrations instead of refusing
+// Skipped migrations are still recorded as unapplied, without running their Down method.
+func (r *Runner) SetForce(force bool) {
+	r.force = force
+}
+
+// checkReversible returns an *IrreversibleError if any of the migrations are irreversible
+func (r *Runner) checkReversible(migrations []Migration) error {
+	if r.force {
+		return nil
+	}
+
+	var versions []string
+	for _, m := range migrations {
+		if isIrreversible(m) {
+			versions = append(versions, m.Version())
+		}
+	}
+	if len(versions) > 0 {
+		return &IrreversibleError{Versions: versions}
+	}
+	return nil
+}
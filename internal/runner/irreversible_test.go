@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pankajredekar/goosegorm/internal/versioner"
+	"gorm.io/gorm"
+)
+
+// IrreversibleTestMigration is marked as impossible to roll back
+type IrreversibleTestMigration struct {
+	TestMigration
+}
+
+func (m IrreversibleTestMigration) Irreversible() bool { return true }
+
+func setupIrreversibleRunner(t *testing.T, downCalls *[]string) (*Runner, *versioner.Versioner) {
+	db := setupTestDB(t)
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	down := func(version string) func(*gorm.DB) error {
+		return func(*gorm.DB) error {
+			*downCalls = append(*downCalls, version)
+			return nil
+		}
+	}
+
+	registry := NewRegistry()
+	registry.RegisterMigration(TestMigration{version: "20250101000000", name: "first", downFunc: down("20250101000000")})
+	registry.RegisterMigration(IrreversibleTestMigration{TestMigration{version: "20250102000000", name: "drop_users", downFunc: down("20250102000000")}})
+	registry.RegisterMigration(TestMigration{version: "20250103000000", name: "third", downFunc: down("20250103000000")})
+
+	run := NewRunner(db, registry, ver)
+	if err := run.Migrate(); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	return run, ver
+}
+
+func TestRollbackRefusesIrreversible(t *testing.T) {
+	var downCalls []string
+	run, ver := setupIrreversibleRunner(t, &downCalls)
+
+	err := run.Rollback(2)
+	var irreversibleErr *IrreversibleError
+	if !errors.As(err, &irreversibleErr) {
+		t.Fatalf("Expected IrreversibleError, got %v", err)
+	}
+	if !errors.Is(err, ErrIrreversible) {
+		t.Error("IrreversibleError should match ErrIrreversible")
+	}
+	if len(irreversibleErr.Versions) != 1 || irreversibleErr.Versions[0] != "20250102000000" {
+		t.Errorf("Expected irreversible version 20250102000000, got %v", irreversibleErr.Versions)
+	}
+
+	// Nothing in the range is touched, including the reversible migration rolled back first
+	if len(downCalls) != 0 {
+		t.Errorf("No Down method should run, got %v", downCalls)
+	}
+	count, err := ver.GetAppliedCount()
+	if err != nil {
+		t.Fatalf("GetAppliedCount failed: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 applied migrations, got %d", count)
+	}
+
+	// A range that stops before the irreversible migration is fine
+	if err := run.Rollback(1); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+}
+
+func TestRollbackToRefusesIrreversible(t *testing.T) {
+	var downCalls []string
+	run, _ := setupIrreversibleRunner(t, &downCalls)
+
+	if err := run.RollbackTo("20250101000000"); !errors.Is(err, ErrIrreversible) {
+		t.Fatalf("Expected ErrIrreversible, got %v", err)
+	}
+	if len(downCalls) != 0 {
+		t.Errorf("No Down method should run, got %v", downCalls)
+	}
+}
+
+func TestRollbackForceSkipsIrreversible(t *testing.T) {
+	var downCalls []string
+	run, ver := setupIrreversibleRunner(t, &downCalls)
+	run.SetForce(true)
+
+	if err := run.Rollback(2); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+
+	if len(downCalls) != 1 || downCalls[0] != "20250103000000" {
+		t.Errorf("Expected only the reversible migration's Down to run, got %v", downCalls)
+	}
+	for _, version := range []string{"20250102000000", "20250103000000"} {
+		applied, err := ver.IsApplied(version)
+		if err != nil {
+			t.Fatalf("IsApplied failed: %v", err)
+		}
+		if applied {
+			t.Errorf("Migration %s should be recorded as unapplied", version)
+		}
+	}
+}
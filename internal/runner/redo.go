@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"context"
+)
+
+// Redo rolls back the last n applied migrations and applies them again
+func (r *Runner) Redo(n int) error {
+	return r.RedoContext(context.Background(), n)
+}
+
+// RedoContext rolls back the last n applied migrations and applies them again, holding the lock throughout
+// Migrations that are pending but were not rolled back stay pending.
+func (r *Runner) RedoContext(ctx context.Context, n int) error {
+	return r.withLock(func() error {
+		migrations, err := r.lastApplied(n)
+		if err != nil {
+			return err
+		}
+		if err := r.run(ctx, DirectionDown, migrations); err != nil {
+			return err
+		}
+
+		// Apply them again in the order they were applied
+		reapply := make([]Migration, len(migrations))
+		for i, m := range migrations {
+			reapply[len(migrations)-1-i] = m
+		}
+		return r.run(ctx, DirectionUp, reapply)
+	})
+}
+
+// Reset rolls back every applied migration and applies all migrations again
+func (r *Runner) Reset() error {
+	return r.ResetContext(context.Background())
+}
+
+// ResetContext rolls back every applied migration and applies all migrations again, holding the lock throughout
+func (r *Runner) ResetContext(ctx context.Context) error {
+	return r.withLock(func() error {
+		_, applied, err := r.state()
+		if err != nil {
+			return err
+		}
+		if len(applied) > 0 {
+			if err := r.rollback(ctx, len(applied)); err != nil {
+				return err
+			}
+		}
+		return r.migrate(ctx)
+	})
+}
@@ -0,0 +1,92 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pankajredekar/goosegorm/internal/versioner"
+	"gorm.io/gorm"
+)
+
+// setupRedoRunner registers three migrations that log their Up and Down calls, applying the first two
+func setupRedoRunner(t *testing.T) (*Runner, *versioner.Versioner, *[]string) {
+	db := setupTestDB(t)
+	registry := NewRegistry()
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	var calls []string
+	for _, version := range []string{"20250101000000", "20250102000000", "20250103000000"} {
+		registry.RegisterMigration(TestMigration{
+			version: version,
+			name:    "logged",
+			upFunc: func(db *gorm.DB) error {
+				calls = append(calls, "up "+version)
+				return nil
+			},
+			downFunc: func(db *gorm.DB) error {
+				calls = append(calls, "down "+version)
+				return nil
+			},
+		})
+	}
+
+	run := NewRunner(db, registry, ver)
+	if err := run.MigrateTo("20250102000000"); err != nil {
+		t.Fatalf("MigrateTo failed: %v", err)
+	}
+	calls = nil
+	return run, ver, &calls
+}
+
+func TestRedo(t *testing.T) {
+	run, ver, calls := setupRedoRunner(t)
+	locker := &recordingLocker{}
+	run.SetLocker(locker, 0)
+
+	if err := run.Redo(2); err != nil {
+		t.Fatalf("Redo failed: %v", err)
+	}
+
+	expected := "down 20250102000000, down 20250101000000, up 20250101000000, up 20250102000000"
+	if got := strings.Join(*calls, ", "); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+	if !locker.locked || !locker.unlocked {
+		t.Error("Redo should hold the migration lock")
+	}
+
+	// The pending migration was not part of the redo
+	if applied, _ := ver.IsApplied("20250103000000"); applied {
+		t.Error("Redo should not apply pending migrations")
+	}
+}
+
+func TestRedoNothingApplied(t *testing.T) {
+	run, _, _ := setupRedoRunner(t)
+	if err := run.RollbackTo(TargetZero); err != nil {
+		t.Fatalf("RollbackTo failed: %v", err)
+	}
+
+	if err := run.Redo(1); err == nil {
+		t.Error("Expected an error redoing with nothing applied")
+	}
+}
+
+func TestReset(t *testing.T) {
+	run, ver, calls := setupRedoRunner(t)
+
+	if err := run.Reset(); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+
+	expected := "down 20250102000000, down 20250101000000, up 20250101000000, up 20250102000000, up 20250103000000"
+	if got := strings.Join(*calls, ", "); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+	if count, _ := ver.GetAppliedCount(); count != 3 {
+		t.Errorf("Expected 3 applied migrations, got %d", count)
+	}
+}
@@ -1,11 +1,16 @@
 package runner
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"sort"
+	"strings"
+	"time"
 	"unsafe"
 
+	"github.com/pankajredekar/goosegorm/internal/lock"
 	"github.com/pankajredekar/goosegorm/internal/schema"
 	"github.com/pankajredekar/goosegorm/internal/versioner"
 	"gorm.io/gorm"
@@ -19,15 +24,88 @@ type Migration interface {
 	Down(db *gorm.DB) error
 }
 
+// NonTransactionalMigration can be implemented by migrations that must run
+// outside of a database transaction (e.g. CREATE INDEX CONCURRENTLY)
+type NonTransactionalMigration interface {
+	NonTransactional() bool
+}
+
+// isTransactional reports whether a migration should be wrapped in a transaction
+func isTransactional(m Migration) bool {
+	if nt, ok := m.(NonTransactionalMigration); ok {
+		return !nt.NonTransactional()
+	}
+	return true
+}
+
+// ContextMigration can be implemented by migrations that accept a context
+// When implemented, UpContext and DownContext are called instead of Up and Down,
+// with a context that is cancelled on shutdown or when the migration times out.
+type ContextMigration interface {
+	UpContext(ctx context.Context, db *gorm.DB) error
+	DownContext(ctx context.Context, db *gorm.DB) error
+}
+
+// TimeoutMigration can be implemented by migrations that declare their own timeout
+// A positive Timeout overrides the runner's default migration timeout.
+type TimeoutMigration interface {
+	Timeout() time.Duration
+}
+
+// runUp runs a migration's Up, preferring UpContext when available
+func runUp(ctx context.Context, m Migration, db *gorm.DB) error {
+	if cm, ok := m.(ContextMigration); ok {
+		return cm.UpContext(ctx, db)
+	}
+	return m.Up(db)
+}
+
+// runDown runs a migration's Down, preferring DownContext when available
+func runDown(ctx context.Context, m Migration, db *gorm.DB) error {
+	if cm, ok := m.(ContextMigration); ok {
+		return cm.DownContext(ctx, db)
+	}
+	return m.Down(db)
+}
+
 // Registry holds all registered migrations
 type Registry struct {
-	migrations map[string]Migration
+	migrations    map[string]Migration
+	checksums     map[string]string
+	createdTables map[string][]string
 }
 
 // NewRegistry creates a new migration registry
 func NewRegistry() *Registry {
 	return &Registry{
 		migrations: make(map[string]Migration),
+		checksums:  make(map[string]string),
+	}
+}
+
+// SetChecksums sets the source checksums of the registered migrations, keyed by version
+func (r *Registry) SetChecksums(checksums map[string]string) {
+	if r.checksums == nil {
+		r.checksums = make(map[string]string)
+	}
+	for version, sum := range checksums {
+		r.checksums[version] = sum
+	}
+}
+
+// GetChecksum returns the source checksum of a migration, or "" if unknown
+func (r *Registry) GetChecksum(version string) string {
+	return r.checksums[version]
+}
+
+// SetCreatedTables sets the tables each registered migration creates, keyed by version
+// Once set, the runner uses them instead of simulating the migrations (see Runner.CreatedTables).
+func (r *Registry) SetCreatedTables(tables map[string][]string) {
+	if r.createdTables == nil {
+		r.createdTables = make(map[string][]string)
+	}
+	for version, names := range tables {
+		r.createdTables[version] = names
 	}
 }
 
@@ -42,8 +120,18 @@ func (r *Registry) GetMigration(version string) (Migration, bool) {
 	return m, ok
 }
 
-// GetAllMigrations returns all migrations sorted by version
-func (r *Registry) GetAllMigrations() []Migration {
+// GetAllMigrations returns all migrations in dependency order, ties broken by version
+// It returns the error of an invalid dependency graph (see Validate).
+func (r *Registry) GetAllMigrations() ([]Migration, error) {
+	g, err := r.buildGraph()
+	if err != nil {
+		return nil, err
+	}
+	return g.order, nil
+}
+
+// migrationsByVersion returns all migrations sorted by version
+func (r *Registry) migrationsByVersion() []Migration {
 	var migrations []Migration
 	for _, m := range r.migrations {
 		migrations = append(migrations, m)
@@ -56,9 +144,17 @@ func (r *Registry) GetAllMigrations() []Migration {
 
 // Runner executes migrations
 type Runner struct {
-	db        *gorm.DB
-	registry  *Registry
-	versioner *versioner.Versioner
+	db          *gorm.DB
+	registry    *Registry
+	versioner   *versioner.Versioner
+	locker      lock.Locker
+	lockTimeout time.Duration
+	timeout     time.Duration
+	hooks       []registeredHook
+
+	allowOutOfOrder bool
+	force           bool
+	fakeInitial     bool
 }
 
 // NewRunner creates a new migration runner
@@ -70,6 +166,65 @@ func NewRunner(db *gorm.DB, registry *Registry, versioner *versioner.Versioner)
 	}
 }
 
+// SetLocker configures a cross-process lock that Migrate and Rollback hold while running
+// Passing a nil locker disables locking
+func (r *Runner) SetLocker(l lock.Locker, timeout time.Duration) {
+	r.locker = l
+	r.lockTimeout = timeout
+}
+
+// SetAllowOutOfOrder controls whether pending migrations older than the latest applied one may be applied
+// They are refused by default with an *OutOfOrderError.
+func (r *Runner) SetAllowOutOfOrder(allow bool) {
+	r.allowOutOfOrder = allow
+}
+
+// SetMigrationTimeout sets how long a single migration may run before it is cancelled
+// Zero disables the timeout; migrations implementing TimeoutMigration override it.
+func (r *Runner) SetMigrationTimeout(timeout time.Duration) {
+	r.timeout = timeout
+}
+
+// migrationContext derives the context a single migration runs with
+func (r *Runner) migrationContext(ctx context.Context, m Migration) (context.Context, context.CancelFunc, time.Duration) {
+	timeout := r.timeout
+	if tm, ok := m.(TimeoutMigration); ok && tm.Timeout() > 0 {
+		timeout = tm.Timeout()
+	}
+	if timeout <= 0 {
+		ctx, cancel := context.WithCancel(ctx)
+		return ctx, cancel, 0
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, timeout
+}
+
+// checkStopped returns an error if the run was cancelled before the next migration started
+func checkStopped(ctx context.Context, next Migration) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("stopped before migration %s: %w", next.Version(), err)
+	}
+	return nil
+}
+
+// withLock runs fn while holding the configured lock
+func (r *Runner) withLock(fn func() error) (err error) {
+	if r.locker == nil {
+		return fn()
+	}
+
+	if err := r.locker.Lock(r.lockTimeout); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if unlockErr := r.locker.Unlock(); unlockErr != nil && err == nil {
+			err = unlockErr
+		}
+	}()
+
+	return fn()
+}
+
 // RunUp executes a migration's Up method
 func (r *Runner) RunUp(m Migration) error {
 	return m.Up(r.db)
@@ -82,9 +237,21 @@ func (r *Runner) RunDown(m Migration) error {
 
 // Migrate applies all pending migrations
 func (r *Runner) Migrate() error {
-	applied, err := r.versioner.GetAppliedVersions()
+	return r.MigrateContext(context.Background())
+}
+
+// MigrateContext applies all pending migrations, stopping before the next migration once ctx is cancelled
+// The running migration sees the cancellation through its context and, if transactional, is rolled back.
+func (r *Runner) MigrateContext(ctx context.Context) error {
+	return r.withLock(func() error {
+		return r.migrate(ctx)
+	})
+}
+
+func (r *Runner) migrate(ctx context.Context) error {
+	g, applied, err := r.state()
 	if err != nil {
-		return fmt.Errorf("failed to get applied migrations: %w", err)
+		return err
 	}
 
 	appliedMap := make(map[string]bool)
@@ -92,67 +259,322 @@ func (r *Runner) Migrate() error {
 		appliedMap[v] = true
 	}
 
-	allMigrations := r.registry.GetAllMigrations()
 	var pending []Migration
-
-	for _, m := range allMigrations {
+	for _, m := range g.order {
 		if !appliedMap[m.Version()] {
 			pending = append(pending, m)
 		}
 	}
 
-	for _, m := range pending {
-		if err := r.RunUp(m); err != nil {
-			return fmt.Errorf("failed to apply migration %s: %w", m.Version(), err)
+	if err := r.checkOrder(g, applied, pending); err != nil {
+		return err
+	}
+
+	return r.run(ctx, DirectionUp, pending)
+}
+
+// applyMigration runs a migration's Up method and records it as applied.
+// Both steps share a single transaction unless the migration opts out.
+// The migration is marked dirty while it runs, and stays dirty if a failure may have left it half applied.
+func (r *Runner) applyMigration(ctx context.Context, m Migration) error {
+	ctx, cancel, timeout := r.migrationContext(ctx, m)
+	defer cancel()
+
+	if err := r.versioner.MarkDirty(m.Version(), m.Name()); err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", m.Version(), err)
+	}
+	err := r.apply(ctx, m, timeout)
+	if err != nil && rolledBack(r.db, m) {
+		if cleanErr := r.versioner.RemoveApplied(m.Version()); cleanErr != nil {
+			return errors.Join(err, cleanErr)
 		}
-		if err := r.versioner.RecordApplied(m.Version(), m.Name()); err != nil {
+	}
+	return err
+}
+
+func (r *Runner) apply(ctx context.Context, m Migration, timeout time.Duration) error {
+	apply := func(db *gorm.DB, ver *versioner.Versioner) error {
+		start := time.Now()
+		if err := runUp(ctx, m, db); err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", m.Version(), timeoutError(ctx, timeout, err))
+		}
+		if err := ver.RecordAppliedWithDuration(m.Version(), m.Name(), r.registry.GetChecksum(m.Version()), time.Since(start)); err != nil {
 			return fmt.Errorf("failed to record migration %s: %w", m.Version(), err)
 		}
+		return nil
 	}
 
-	return nil
+	db := r.db.WithContext(ctx)
+	if !isTransactional(m) {
+		return apply(db, r.versioner.WithDB(db))
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		return apply(tx, r.versioner.WithDB(tx))
+	})
+}
+
+// revertMigration runs a migration's Down method and removes its record.
+// Both steps share a single transaction unless the migration opts out.
+// The migration is marked dirty while it runs, and stays dirty if a failure may have left it half reverted.
+func (r *Runner) revertMigration(ctx context.Context, m Migration) error {
+	ctx, cancel, timeout := r.migrationContext(ctx, m)
+	defer cancel()
+
+	// A squashed migration may be recorded only through the migrations it replaces
+	recorded, err := r.versioner.IsApplied(m.Version())
+	if err != nil {
+		return fmt.Errorf("failed to check migration %s: %w", m.Version(), err)
+	}
+	if err := r.versioner.MarkDirty(m.Version(), m.Name()); err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", m.Version(), err)
+	}
+	err = r.revert(ctx, m, timeout)
+	if err != nil && rolledBack(r.db, m) {
+		restore := r.versioner.RemoveApplied
+		if recorded {
+			restore = r.versioner.MarkClean
+		}
+		if cleanErr := restore(m.Version()); cleanErr != nil {
+			return errors.Join(err, cleanErr)
+		}
+	}
+	return err
+}
+
+func (r *Runner) revert(ctx context.Context, m Migration, timeout time.Duration) error {
+	revert := func(db *gorm.DB, ver *versioner.Versioner) error {
+		// Forced rollbacks only forget irreversible migrations, their changes stay in place
+		if !isIrreversible(m) {
+			if err := runDown(ctx, m, db); err != nil {
+				return fmt.Errorf("failed to rollback migration %s: %w", m.Version(), timeoutError(ctx, timeout, err))
+			}
+		}
+		// A squashed migration also removes the records of the migrations it replaces
+		for _, version := range append([]string{m.Version()}, replacesOf(m)...) {
+			if err := ver.RemoveApplied(version); err != nil {
+				return fmt.Errorf("failed to remove migration record %s: %w", version, err)
+			}
+		}
+		return nil
+	}
+
+	db := r.db.WithContext(ctx)
+	if !isTransactional(m) {
+		return revert(db, r.versioner.WithDB(db))
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		return revert(tx, r.versioner.WithDB(tx))
+	})
+}
+
+// timeoutError explains a migration error caused by its timeout expiring
+func timeoutError(ctx context.Context, timeout time.Duration, err error) error {
+	if timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s: %w", timeout, err)
+	}
+	return err
 }
 
 // Rollback rolls back the last N migrations
 func (r *Runner) Rollback(n int) error {
-	applied, err := r.versioner.GetAppliedVersions()
+	return r.RollbackContext(context.Background(), n)
+}
+
+// RollbackContext rolls back the last N migrations, stopping before the next migration once ctx is cancelled
+func (r *Runner) RollbackContext(ctx context.Context, n int) error {
+	return r.withLock(func() error {
+		return r.rollback(ctx, n)
+	})
+}
+
+func (r *Runner) rollback(ctx context.Context, n int) error {
+	migrations, err := r.lastApplied(n)
+	if err != nil {
+		return err
+	}
+	return r.run(ctx, DirectionDown, migrations)
+}
+
+// lastApplied returns the last n applied migrations in the order they are rolled back
+func (r *Runner) lastApplied(n int) ([]Migration, error) {
+	// A dirty migration isn't counted as applied, so it could hide the last migration
+	if err := r.checkDirty(); err != nil {
+		return nil, err
+	}
+
+	_, applied, err := r.state()
 	if err != nil {
-		return fmt.Errorf("failed to get applied migrations: %w", err)
+		return nil, err
 	}
 
 	if len(applied) == 0 {
-		return fmt.Errorf("no migrations to rollback")
+		return nil, fmt.Errorf("no migrations to rollback")
 	}
 
 	if n > len(applied) {
 		n = len(applied)
 	}
 
-	// Rollback in reverse order
+	// Rollback in reverse apply order
+	var migrations []Migration
 	for i := len(applied) - 1; i >= len(applied)-n; i-- {
 		version := applied[i]
 		m, ok := r.registry.GetMigration(version)
 		if !ok {
-			return fmt.Errorf("migration %s not found in registry", version)
+			return nil, fmt.Errorf("migration %s not found in registry", version)
 		}
+		migrations = append(migrations, m)
+	}
+
+	return migrations, nil
+}
+
+// TargetZero is the target version that unapplies every migration
+const TargetZero = "zero"
+
+// Direction is the direction a migration plan moves the schema
+type Direction string
+
+const (
+	DirectionUp   Direction = "up"
+	DirectionDown Direction = "down"
+)
+
+// Plan describes the migrations needed to reach a target version, in execution order
+type Plan struct {
+	Target     string
+	Direction  Direction
+	Migrations []Migration
+}
 
-		if err := r.RunDown(m); err != nil {
-			return fmt.Errorf("failed to rollback migration %s: %w", version, err)
+// PlanTo plans the steps needed to reach the target version
+// The target and the migrations it depends on are applied; applied migrations that depend on it
+// are rolled back. Without declared dependencies this means every migration up to and including
+// the target is applied and every applied migration after it is rolled back.
+// Use TargetZero to plan unapplying everything.
+func (r *Runner) PlanTo(target string) (*Plan, error) {
+	if target != TargetZero {
+		if _, ok := r.registry.GetMigration(target); !ok {
+			return nil, fmt.Errorf("target migration %s not found in registry", target)
 		}
+	}
+
+	g, applied, err := r.state()
+	if err != nil {
+		return nil, err
+	}
 
-		if err := r.versioner.RemoveApplied(version); err != nil {
-			return fmt.Errorf("failed to remove migration record %s: %w", version, err)
+	appliedMap := make(map[string]bool)
+	for _, v := range applied {
+		appliedMap[v] = true
+	}
+
+	var ancestors, descendants map[string]bool
+	if target != TargetZero {
+		ancestors = g.ancestors(target)
+		descendants = g.descendants(target)
+		delete(descendants, target)
+	}
+
+	plan := &Plan{Target: target}
+
+	// Applied migrations after the target are rolled back, most recently applied first
+	for i := len(applied) - 1; i >= 0; i-- {
+		version := applied[i]
+		m, ok := r.registry.GetMigration(version)
+		if target != TargetZero {
+			// Versions missing from the registry can't be placed in the graph, fall back to version order
+			if (ok && !descendants[version]) || (!ok && version <= target) {
+				continue
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("migration %s not found in registry", version)
 		}
+		plan.Migrations = append(plan.Migrations, m)
+	}
+	if len(plan.Migrations) > 0 {
+		plan.Direction = DirectionDown
 	}
 
-	return nil
+	// Pending migrations the target depends on are applied in dependency order
+	var up []Migration
+	if target != TargetZero {
+		for _, m := range g.order {
+			if ancestors[m.Version()] && !appliedMap[m.Version()] {
+				up = append(up, m)
+			}
+		}
+	}
+
+	if len(up) > 0 {
+		if plan.Direction == DirectionDown {
+			return nil, fmt.Errorf("cannot reach %s: migrations both before and after it would need to change", target)
+		}
+		plan.Direction = DirectionUp
+		plan.Migrations = up
+	}
+
+	return plan, nil
+}
+
+// MigrateTo applies or rolls back migrations until the target version is the latest applied
+func (r *Runner) MigrateTo(target string) error {
+	return r.MigrateToContext(context.Background(), target)
+}
+
+// MigrateToContext is MigrateTo, stopping before the next migration once ctx is cancelled
+func (r *Runner) MigrateToContext(ctx context.Context, target string) error {
+	return r.withLock(func() error {
+		plan, err := r.PlanTo(target)
+		if err != nil {
+			return err
+		}
+		if plan.Direction == DirectionUp {
+			g, applied, err := r.state()
+			if err != nil {
+				return err
+			}
+			if err := r.checkOrder(g, applied, plan.Migrations); err != nil {
+				return err
+			}
+		}
+		return r.executePlan(ctx, plan)
+	})
+}
+
+// RollbackTo rolls back applied migrations newer than the target version
+// Unlike MigrateTo, it refuses to apply pending migrations.
+func (r *Runner) RollbackTo(target string) error {
+	return r.RollbackToContext(context.Background(), target)
+}
+
+// RollbackToContext is RollbackTo, stopping before the next migration once ctx is cancelled
+func (r *Runner) RollbackToContext(ctx context.Context, target string) error {
+	return r.withLock(func() error {
+		plan, err := r.PlanTo(target)
+		if err != nil {
+			return err
+		}
+		if plan.Direction == DirectionUp {
+			return fmt.Errorf("%s is ahead of the applied migrations; use migrate --to to apply it", target)
+		}
+		return r.executePlan(ctx, plan)
+	})
+}
+
+// executePlan runs each migration in the plan in the plan's direction
+func (r *Runner) executePlan(ctx context.Context, plan *Plan) error {
+	return r.run(ctx, plan.Direction, plan.Migrations)
 }
 
 // GetPendingMigrations returns migrations that haven't been applied
 func (r *Runner) GetPendingMigrations() ([]Migration, error) {
-	applied, err := r.versioner.GetAppliedVersions()
+	g, applied, err := r.state()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+		return nil, err
 	}
 
 	appliedMap := make(map[string]bool)
@@ -160,10 +582,9 @@ func (r *Runner) GetPendingMigrations() ([]Migration, error) {
 		appliedMap[v] = true
 	}
 
-	allMigrations := r.registry.GetAllMigrations()
 	var pending []Migration
 
-	for _, m := range allMigrations {
+	for _, m := range g.order {
 		if !appliedMap[m.Version()] {
 			pending = append(pending, m)
 		}
@@ -172,12 +593,93 @@ func (r *Runner) GetPendingMigrations() ([]Migration, error) {
 	return pending, nil
 }
 
+// OutOfOrderError is returned when pending migrations are older than the latest applied one,
+// or applied migrations depend on them, typically after merging branches that each added migrations.
+type OutOfOrderError struct {
+	Versions      []string
+	LatestApplied string
+}
+
+func (e *OutOfOrderError) Error() string {
+	return fmt.Sprintf("pending migration(s) %s should have run before already applied migrations (latest applied: %s); apply them with --allow-out-of-order",
+		strings.Join(e.Versions, ", "), e.LatestApplied)
+}
+
+// GetOutOfOrderMigrations returns pending migrations that are older than the latest applied one
+// or that applied migrations depend on
+func (r *Runner) GetOutOfOrderMigrations() ([]Migration, error) {
+	g, applied, err := r.state()
+	if err != nil {
+		return nil, err
+	}
+	pending, err := r.GetPendingMigrations()
+	if err != nil {
+		return nil, err
+	}
+	return outOfOrder(g, applied, pending), nil
+}
+
+// latestVersion returns the highest of the versions
+func latestVersion(versions []string) string {
+	var latest string
+	for _, version := range versions {
+		if version > latest {
+			latest = version
+		}
+	}
+	return latest
+}
+
+// outOfOrder returns the candidates older than the latest applied version or that applied
+// migrations (transitively) depend on
+// Declared dependencies don't make an older migration safe: it was written without the newer
+// applied ones in mind, just like a migration from a branch merged late.
+func outOfOrder(g *graph, applied []string, candidates []Migration) []Migration {
+	latest := latestVersion(applied)
+	required := make(map[string]bool)
+	for _, version := range applied {
+		if required[version] {
+			continue
+		}
+		for ancestor := range g.ancestors(version) {
+			required[ancestor] = true
+		}
+	}
+
+	var older []Migration
+	for _, m := range candidates {
+		if m.Version() < latest || required[m.Version()] {
+			older = append(older, m)
+		}
+	}
+	return older
+}
+
+// checkOrder refuses out-of-order candidates unless out-of-order is allowed
+func (r *Runner) checkOrder(g *graph, applied []string, candidates []Migration) error {
+	if r.allowOutOfOrder || len(applied) == 0 {
+		return nil
+	}
+
+	older := outOfOrder(g, applied, candidates)
+	if len(older) == 0 {
+		return nil
+	}
+
+	versions := make([]string, len(older))
+	for i, m := range older {
+		versions[i] = m.Version()
+	}
+	return &OutOfOrderError{Versions: versions, LatestApplied: latestVersion(applied)}
+}
+
 // GetAppliedMigrations returns migrations that have been applied
 func (r *Runner) GetAppliedMigrations() ([]Migration, error) {
-	applied, err := r.versioner.GetAppliedVersions()
+	_, applied, err := r.state()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+		return nil, err
 	}
+	sort.Strings(applied)
 
 	var migrations []Migration
 	for _, v := range applied {
@@ -191,10 +693,44 @@ func (r *Runner) GetAppliedMigrations() ([]Migration, error) {
 
 // SimulateSchema simulates all migrations to build up the schema state
 func (r *Runner) SimulateSchema() (*schema.SchemaBuilder, error) {
+	migrations, err := r.registry.GetAllMigrations()
+	if err != nil {
+		return nil, err
+	}
+	return simulate(migrations)
+}
+
+// SimulateAppliedSchema simulates only the applied migrations, in dependency order
+// The result is the schema the database should have.
+func (r *Runner) SimulateAppliedSchema() (*schema.SchemaBuilder, error) {
+	g, applied, err := r.state()
+	if err != nil {
+		return nil, err
+	}
+
+	appliedSet := make(map[string]bool)
+	for _, v := range applied {
+		appliedSet[v] = true
+	}
+
+	var migrations []Migration
+	for _, m := range g.order {
+		if appliedSet[m.Version()] {
+			migrations = append(migrations, m)
+		}
+	}
+	return simulate(migrations)
+}
+
+// simulate runs the migrations against an empty simulated schema
+func simulate(migrations []Migration) (*schema.SchemaBuilder, error) {
 	builder := schema.NewSchemaBuilder()
-	allMigrations := r.registry.GetAllMigrations()
 
-	for _, m := range allMigrations {
+	// Let migrations (and data operations) recognize the builder they receive as a *gorm.DB
+	schema.BeginSimulation(builder)
+	defer schema.EndSimulation(builder)
+
+	for _, m := range migrations {
 		// Pass the SchemaBuilder directly - migrations will check the type
 		// using type assertion: if sim, ok := any(db).(*goosegorm.SchemaBuilder); ok
 		if err := callMigrationUp(m, builder); err != nil {
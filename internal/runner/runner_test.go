@@ -1,8 +1,14 @@
 package runner
 
 import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/pankajredekar/goosegorm/internal/lock"
 	"github.com/pankajredekar/goosegorm/internal/schema"
 	"github.com/pankajredekar/goosegorm/internal/versioner"
 	"gorm.io/driver/sqlite"
@@ -77,7 +83,10 @@ func TestGetAllMigrations(t *testing.T) {
 		registry.RegisterMigration(m)
 	}
 
-	all := registry.GetAllMigrations()
+	all, err := registry.GetAllMigrations()
+	if err != nil {
+		t.Fatalf("GetAllMigrations failed: %v", err)
+	}
 	if len(all) != 3 {
 		t.Errorf("Expected 3 migrations, got %d", len(all))
 	}
@@ -136,6 +145,43 @@ func TestMigrate(t *testing.T) {
 	}
 }
 
+func TestMigrateRecordsExecutionDetails(t *testing.T) {
+	db := setupTestDB(t)
+	registry := NewRegistry()
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	ver.SetExecutionInfo(versioner.ExecutionInfo{ToolVersion: "0.6.0", Hostname: "web-1", ExecutedBy: "deploy", BuildLabel: "abc1234"})
+
+	registry.RegisterMigration(TestMigration{
+		version: "20250101000000",
+		name:    "slow",
+		upFunc: func(db *gorm.DB) error {
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		},
+	})
+
+	if err := NewRunner(db, registry, ver).Migrate(); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	records, err := ver.GetAppliedRecords()
+	if err != nil {
+		t.Fatalf("GetAppliedRecords failed: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 record, got %d", len(records))
+	}
+	if records[0].DurationMs < 20 {
+		t.Errorf("Expected a duration of at least 20ms, got %dms", records[0].DurationMs)
+	}
+	if records[0].BuildLabel != "abc1234" || records[0].ExecutedBy != "deploy" {
+		t.Errorf("Expected the execution info to be recorded, got %+v", records[0])
+	}
+}
+
 func TestGetPendingMigrations(t *testing.T) {
 	db := setupTestDB(t)
 	registry := NewRegistry()
@@ -238,3 +284,461 @@ func TestSimulateSchema(t *testing.T) {
 		t.Errorf("Expected 2 columns, got %d", len(table.Columns))
 	}
 }
+
+func TestSimulateAppliedSchema(t *testing.T) {
+	db := setupTestDB(t)
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	ran := make(map[string]bool)
+	registry := NewRegistry()
+	registry.RegisterMigration(createTableMigration("20250101000000", "users", ran))
+	registry.RegisterMigration(createTableMigration("20250102000000", "orders", ran))
+	if err := ver.RecordApplied("20250101000000", "create_users"); err != nil {
+		t.Fatalf("RecordApplied failed: %v", err)
+	}
+
+	run := NewRunner(db, registry, ver)
+	builder, err := run.SimulateAppliedSchema()
+	if err != nil {
+		t.Fatalf("SimulateAppliedSchema failed: %v", err)
+	}
+	if !builder.TableExists("users") {
+		t.Error("Table 'users' of the applied migration should exist")
+	}
+	if builder.TableExists("orders") {
+		t.Error("Table 'orders' of the pending migration should not exist")
+	}
+	if len(ran) != 0 {
+		t.Error("Migrations should only be simulated")
+	}
+}
+
+// NonTransactionalTestMigration opts out of the per-migration transaction
+type NonTransactionalTestMigration struct {
+	TestMigration
+}
+
+func (m NonTransactionalTestMigration) NonTransactional() bool { return true }
+
+func TestMigrateFailureRollsBackTransaction(t *testing.T) {
+	db := setupTestDB(t)
+	registry := NewRegistry()
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	run := NewRunner(db, registry, ver)
+
+	registry.RegisterMigration(TestMigration{
+		version: "20250101000000",
+		name:    "failing",
+		upFunc: func(db *gorm.DB) error {
+			if err := db.Exec("CREATE TABLE half_done (id INTEGER)").Error; err != nil {
+				return err
+			}
+			return db.Exec("INSERT INTO missing_table VALUES (1)").Error
+		},
+	})
+
+	if err := run.Migrate(); err == nil {
+		t.Fatal("Migrate should fail")
+	}
+
+	if db.Migrator().HasTable("half_done") {
+		t.Error("Table created by failed migration should be rolled back")
+	}
+
+	applied, err := ver.IsApplied("20250101000000")
+	if err != nil {
+		t.Fatalf("IsApplied failed: %v", err)
+	}
+	if applied {
+		t.Error("Failed migration should not be recorded as applied")
+	}
+}
+
+func TestMigrateNonTransactional(t *testing.T) {
+	db := setupTestDB(t)
+	registry := NewRegistry()
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	run := NewRunner(db, registry, ver)
+
+	registry.RegisterMigration(NonTransactionalTestMigration{TestMigration{
+		version: "20250101000000",
+		name:    "non_transactional",
+		upFunc: func(db *gorm.DB) error {
+			if err := db.Exec("CREATE TABLE outside_tx (id INTEGER)").Error; err != nil {
+				return err
+			}
+			return db.Exec("INSERT INTO missing_table VALUES (1)").Error
+		},
+	}})
+
+	if err := run.Migrate(); err == nil {
+		t.Fatal("Migrate should fail")
+	}
+
+	// Without a transaction, statements executed before the failure persist
+	if !db.Migrator().HasTable("outside_tx") {
+		t.Error("Non-transactional migration should not be rolled back")
+	}
+}
+
+// recordingLocker records lock calls and can simulate a held lock
+type recordingLocker struct {
+	locked   bool
+	unlocked bool
+	err      error
+}
+
+func (l *recordingLocker) Lock(timeout time.Duration) error {
+	if l.err != nil {
+		return l.err
+	}
+	l.locked = true
+	return nil
+}
+
+func (l *recordingLocker) Unlock() error {
+	l.unlocked = true
+	return nil
+}
+
+func TestMigrateHoldsLock(t *testing.T) {
+	db := setupTestDB(t)
+	registry := NewRegistry()
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	run := NewRunner(db, registry, ver)
+	locker := &recordingLocker{}
+	run.SetLocker(locker, time.Second)
+
+	ran := false
+	registry.RegisterMigration(TestMigration{
+		version: "20250101000000",
+		name:    "locked",
+		upFunc: func(db *gorm.DB) error {
+			ran = true
+			if !locker.locked || locker.unlocked {
+				t.Error("Migration should run while the lock is held")
+			}
+			return nil
+		},
+	})
+
+	if err := run.Migrate(); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if !ran {
+		t.Error("Migration should have run")
+	}
+	if !locker.unlocked {
+		t.Error("Lock should be released after Migrate")
+	}
+}
+
+func TestMigrateFailsWhenLockHeld(t *testing.T) {
+	db := setupTestDB(t)
+	registry := NewRegistry()
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	run := NewRunner(db, registry, ver)
+	run.SetLocker(&recordingLocker{err: &lock.HeldError{Holder: "host:1", Timeout: time.Second}}, time.Second)
+	registry.RegisterMigration(TestMigration{version: "20250101000000", name: "blocked"})
+
+	err := run.Migrate()
+	var held *lock.HeldError
+	if !errors.As(err, &held) {
+		t.Fatalf("Expected HeldError, got %v", err)
+	}
+
+	applied, err := ver.IsApplied("20250101000000")
+	if err != nil {
+		t.Fatalf("IsApplied failed: %v", err)
+	}
+	if applied {
+		t.Error("Migration should not be applied without the lock")
+	}
+}
+
+func setupPlanRunner(t *testing.T, appliedCount int) (*Runner, *versioner.Versioner) {
+	db := setupTestDB(t)
+	registry := NewRegistry()
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	versions := []string{"20250101000000", "20250102000000", "20250103000000"}
+	for i, v := range versions {
+		m := TestMigration{version: v, name: "m" + v}
+		registry.RegisterMigration(m)
+		if i < appliedCount {
+			if err := ver.RecordApplied(m.Version(), m.Name()); err != nil {
+				t.Fatalf("RecordApplied failed: %v", err)
+			}
+		}
+	}
+
+	return NewRunner(db, registry, ver), ver
+}
+
+func TestPlanTo(t *testing.T) {
+	tests := []struct {
+		name      string
+		applied   int
+		target    string
+		direction Direction
+		versions  []string
+	}{
+		{"forward", 1, "20250102000000", DirectionUp, []string{"20250102000000"}},
+		{"backward", 3, "20250101000000", DirectionDown, []string{"20250103000000", "20250102000000"}},
+		{"zero", 2, TargetZero, DirectionDown, []string{"20250102000000", "20250101000000"}},
+		{"already there", 2, "20250102000000", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, _ := setupPlanRunner(t, tt.applied)
+
+			plan, err := run.PlanTo(tt.target)
+			if err != nil {
+				t.Fatalf("PlanTo failed: %v", err)
+			}
+			if plan.Direction != tt.direction {
+				t.Errorf("Expected direction '%s', got '%s'", tt.direction, plan.Direction)
+			}
+			if len(plan.Migrations) != len(tt.versions) {
+				t.Fatalf("Expected %d migrations, got %d", len(tt.versions), len(plan.Migrations))
+			}
+			for i, v := range tt.versions {
+				if plan.Migrations[i].Version() != v {
+					t.Errorf("Expected step %d to be '%s', got '%s'", i, v, plan.Migrations[i].Version())
+				}
+			}
+		})
+	}
+}
+
+func TestPlanToUnknownTarget(t *testing.T) {
+	run, _ := setupPlanRunner(t, 1)
+	if _, err := run.PlanTo("20990101000000"); err == nil {
+		t.Error("PlanTo should fail for a version not in the registry")
+	}
+}
+
+func TestMigrateTo(t *testing.T) {
+	run, ver := setupPlanRunner(t, 0)
+
+	if err := run.MigrateTo("20250102000000"); err != nil {
+		t.Fatalf("MigrateTo failed: %v", err)
+	}
+	latest, err := ver.GetLatestVersion()
+	if err != nil {
+		t.Fatalf("GetLatestVersion failed: %v", err)
+	}
+	if latest != "20250102000000" {
+		t.Errorf("Expected latest version '20250102000000', got '%s'", latest)
+	}
+
+	if err := run.RollbackTo(TargetZero); err != nil {
+		t.Fatalf("RollbackTo failed: %v", err)
+	}
+	count, err := ver.GetAppliedCount()
+	if err != nil {
+		t.Fatalf("GetAppliedCount failed: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected no applied migrations, got %d", count)
+	}
+
+	if err := run.RollbackTo("20250103000000"); err == nil {
+		t.Error("RollbackTo should refuse to apply pending migrations")
+	}
+}
+
+// ContextTestMigration implements ContextMigration and TimeoutMigration
+type ContextTestMigration struct {
+	TestMigration
+	timeout       time.Duration
+	upContextFunc func(context.Context, *gorm.DB) error
+}
+
+func (m ContextTestMigration) UpContext(ctx context.Context, db *gorm.DB) error {
+	return m.upContextFunc(ctx, db)
+}
+func (m ContextTestMigration) DownContext(ctx context.Context, db *gorm.DB) error { return nil }
+func (m ContextTestMigration) Timeout() time.Duration                             { return m.timeout }
+
+func setupFileDBRunner(t *testing.T, migrations ...Migration) (*Runner, *versioner.Versioner) {
+	// Cancelling a query discards its connection, which would drop an in-memory database
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	registry := NewRegistry()
+	for _, m := range migrations {
+		registry.RegisterMigration(m)
+	}
+	return NewRunner(db, registry, ver), ver
+}
+
+func TestMigrateContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	secondRan := false
+	run, ver := setupFileDBRunner(t,
+		ContextTestMigration{
+			TestMigration: TestMigration{version: "20250101000000", name: "first"},
+			upContextFunc: func(ctx context.Context, db *gorm.DB) error {
+				// Shutdown arrives while the first migration is running
+				cancel()
+				return nil
+			},
+		},
+		TestMigration{
+			version: "20250102000000",
+			name:    "second",
+			upFunc: func(db *gorm.DB) error {
+				secondRan = true
+				return nil
+			},
+		},
+	)
+
+	err := run.MigrateContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if secondRan {
+		t.Error("No migration should start after cancellation")
+	}
+
+	count, err := ver.GetAppliedCount()
+	if err != nil {
+		t.Fatalf("GetAppliedCount failed: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Cancelled migration should be rolled back, got %d applied", count)
+	}
+}
+
+func TestMigrationTimeout(t *testing.T) {
+	waitForCancel := func(ctx context.Context, db *gorm.DB) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			return nil
+		}
+	}
+
+	tests := []struct {
+		name            string
+		runnerTimeout   time.Duration
+		declaredTimeout time.Duration
+	}{
+		{name: "runner default", runnerTimeout: 20 * time.Millisecond},
+		{name: "declared on migration", runnerTimeout: time.Hour, declaredTimeout: 20 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, ver := setupFileDBRunner(t, ContextTestMigration{
+				TestMigration: TestMigration{version: "20250101000000", name: "slow"},
+				timeout:       tt.declaredTimeout,
+				upContextFunc: waitForCancel,
+			})
+			run.SetMigrationTimeout(tt.runnerTimeout)
+
+			err := run.Migrate()
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+			}
+			if !strings.Contains(err.Error(), "timed out after 20ms") {
+				t.Errorf("Error should name the timeout, got %v", err)
+			}
+
+			applied, err := ver.IsApplied("20250101000000")
+			if err != nil {
+				t.Fatalf("IsApplied failed: %v", err)
+			}
+			if applied {
+				t.Error("Timed out migration should not be recorded as applied")
+			}
+		})
+	}
+}
+
+func TestMigrateOutOfOrder(t *testing.T) {
+	run, ver := setupFileDBRunner(t,
+		TestMigration{version: "20250101000000", name: "first"},
+		TestMigration{version: "20250103000000", name: "third"},
+	)
+	if err := run.Migrate(); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	// A branch merged with an older migration
+	run.registry.RegisterMigration(TestMigration{version: "20250102000000", name: "second"})
+
+	older, err := run.GetOutOfOrderMigrations()
+	if err != nil {
+		t.Fatalf("GetOutOfOrderMigrations failed: %v", err)
+	}
+	if len(older) != 1 || older[0].Version() != "20250102000000" {
+		t.Errorf("Expected 20250102000000 to be out of order, got %v", older)
+	}
+
+	var orderErr *OutOfOrderError
+	if err := run.Migrate(); !errors.As(err, &orderErr) {
+		t.Fatalf("Expected OutOfOrderError, got %v", err)
+	}
+	if orderErr.LatestApplied != "20250103000000" || len(orderErr.Versions) != 1 {
+		t.Errorf("Unexpected error details: %+v", orderErr)
+	}
+	if err := run.MigrateTo("20250103000000"); !errors.As(err, &orderErr) {
+		t.Fatalf("MigrateTo should refuse out-of-order migrations, got %v", err)
+	}
+
+	run.SetAllowOutOfOrder(true)
+	if err := run.Migrate(); err != nil {
+		t.Fatalf("Migrate with out-of-order allowed failed: %v", err)
+	}
+
+	// Rollback follows apply order, so the out-of-order migration is rolled back first
+	if err := run.db.Table("_test_migrations").Where("version = ?", "20250102000000").
+		Update("applied_at", time.Now().Add(time.Hour)).Error; err != nil {
+		t.Fatalf("Failed to set applied_at: %v", err)
+	}
+	if err := run.Rollback(1); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+
+	versions, err := ver.GetAppliedVersions()
+	if err != nil {
+		t.Fatalf("GetAppliedVersions failed: %v", err)
+	}
+	if len(versions) != 2 || versions[0] != "20250101000000" || versions[1] != "20250103000000" {
+		t.Errorf("Expected the out-of-order migration to be rolled back, got %v", versions)
+	}
+}
@@ -0,0 +1,197 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ReplacingMigration can be implemented by a squashed migration to declare the migrations it replaces
+// While the replaced migrations are still registered, the squashed migration is used instead of them
+// unless they are only partly applied. Either way it counts as applied once all of them are applied,
+// so their files can be deleted after every database has caught up.
+type ReplacingMigration interface {
+	Replaces() []string
+}
+
+// replacesOf returns the versions a migration replaces, or nil if it isn't a squashed migration
+func replacesOf(m Migration) []string {
+	if rm, ok := m.(ReplacingMigration); ok {
+		return rm.Replaces()
+	}
+	return nil
+}
+
+// PartialSquashError is returned when a squashed migration can't be used because some of the
+// migrations it replaces are applied, and the others are no longer registered
+type PartialSquashError struct {
+	Version string
+	Missing []string
+}
+
+func (e *PartialSquashError) Error() string {
+	return fmt.Sprintf("squashed migration %s cannot be used: some of the migrations it replaces are applied, and %s no longer exist; restore them to finish applying them",
+		e.Version, strings.Join(e.Missing, ", "))
+}
+
+// squashes decides, for each squashed migration, whether it is used in place of the migrations it replaces
+// A squashed migration is not used while the migrations it replaces are partly applied; applied may be
+// nil, in which case every squashed migration is used. Returns the squashed migration each replaced
+// version resolves to, and the replaced versions of the squashed migrations that are not used.
+func (r *Registry) squashes(applied map[string]bool) (replacedBy map[string]string, unused map[string][]string, err error) {
+	replacedBy = make(map[string]string)
+	unused = make(map[string][]string)
+
+	for _, m := range r.migrationsByVersion() {
+		replaces := replacesOf(m)
+		if len(replaces) == 0 {
+			continue
+		}
+
+		appliedCount := 0
+		for _, version := range replaces {
+			if applied[version] {
+				appliedCount++
+			}
+		}
+
+		if !applied[m.Version()] && appliedCount > 0 && appliedCount < len(replaces) {
+			var missing []string
+			for _, version := range replaces {
+				if _, ok := r.migrations[version]; !ok {
+					missing = append(missing, version)
+				}
+			}
+			if len(missing) > 0 {
+				return nil, nil, &PartialSquashError{Version: m.Version(), Missing: missing}
+			}
+			unused[m.Version()] = replaces
+			continue
+		}
+
+		for _, version := range replaces {
+			replacedBy[version] = m.Version()
+		}
+	}
+
+	return replacedBy, unused, nil
+}
+
+// replacedVersions returns every version replaced by a registered squashed migration
+func (r *Registry) replacedVersions() map[string]bool {
+	replaced := make(map[string]bool)
+	for _, m := range r.migrations {
+		for _, version := range replacesOf(m) {
+			replaced[version] = true
+		}
+	}
+	return replaced
+}
+
+// resolveApplied maps applied versions, in apply order, onto the graph
+// The migrations replaced by a squashed migration in use resolve to it once all of them are applied.
+func (g *graph) resolveApplied(applied []string) []string {
+	remaining := make(map[string]int)
+	for squashed, replaces := range g.replaces {
+		remaining[squashed] = len(replaces)
+	}
+
+	var resolved []string
+	added := make(map[string]bool)
+	add := func(version string) {
+		if !added[version] {
+			added[version] = true
+			resolved = append(resolved, version)
+		}
+	}
+
+	for _, version := range applied {
+		squashed, ok := g.replacedBy[version]
+		if !ok {
+			add(version)
+			continue
+		}
+		remaining[squashed]--
+		if remaining[squashed] == 0 {
+			add(squashed)
+		}
+	}
+	return resolved
+}
+
+// state returns the dependency graph for the applied migrations and the applied versions
+// in apply order, with replaced versions resolved to their squashed migration
+func (r *Runner) state() (*graph, []string, error) {
+	records, err := r.versioner.GetAppliedVersionsInApplyOrder()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	appliedMap := make(map[string]bool)
+	for _, v := range records {
+		appliedMap[v] = true
+	}
+
+	g, err := r.registry.buildGraphFor(appliedMap)
+	if err != nil {
+		return nil, nil, err
+	}
+	return g, g.resolveApplied(records), nil
+}
+
+// SquashRange returns the migrations from one version to another in dependency order,
+// and the versions outside the range they depend on
+// The range must not contain squashed migrations.
+func (r *Registry) SquashRange(from, to string) ([]Migration, []string, error) {
+	for _, version := range []string{from, to} {
+		if _, ok := r.migrations[version]; !ok {
+			return nil, nil, fmt.Errorf("migration %s not found in registry", version)
+		}
+	}
+
+	g, err := r.buildGraph()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	start, end := -1, -1
+	for i, m := range g.order {
+		switch m.Version() {
+		case from:
+			start = i
+		case to:
+			end = i
+		}
+	}
+	if start == -1 || end == -1 {
+		return nil, nil, fmt.Errorf("cannot squash migrations that are replaced by a squashed migration")
+	}
+	if start > end {
+		return nil, nil, fmt.Errorf("migration %s runs after %s", from, to)
+	}
+
+	migrations := g.order[start : end+1]
+	inRange := make(map[string]bool)
+	for _, m := range migrations {
+		if len(replacesOf(m)) > 0 {
+			return nil, nil, fmt.Errorf("migration %s is already a squashed migration", m.Version())
+		}
+		inRange[m.Version()] = true
+	}
+
+	depSet := make(map[string]bool)
+	for _, m := range migrations {
+		for _, parent := range g.parents[m.Version()] {
+			if !inRange[parent] {
+				depSet[parent] = true
+			}
+		}
+	}
+	deps := make([]string, 0, len(depSet))
+	for version := range depSet {
+		deps = append(deps, version)
+	}
+	sort.Strings(deps)
+
+	return migrations, deps, nil
+}
@@ -0,0 +1,219 @@
+package runner
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pankajredekar/goosegorm/internal/versioner"
+	"gorm.io/gorm"
+)
+
+// SquashedTestMigration implements ReplacingMigration
+type SquashedTestMigration struct {
+	TestMigration
+	replaces []string
+}
+
+func (m SquashedTestMigration) Replaces() []string { return m.replaces }
+
+// squashTestRunner registers 001 and 002, a squash 003 replacing them, and 004 depending on 002
+func squashTestRunner(t *testing.T, ran *[]string) (*Runner, *versioner.Versioner) {
+	db := setupTestDB(t)
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	record := func(version string) func(*gorm.DB) error {
+		return func(*gorm.DB) error {
+			*ran = append(*ran, version)
+			return nil
+		}
+	}
+
+	registry := NewRegistry()
+	registry.RegisterMigration(TestMigration{version: "001", name: "first", upFunc: record("001")})
+	registry.RegisterMigration(TestMigration{version: "002", name: "second", upFunc: record("002")})
+	registry.RegisterMigration(SquashedTestMigration{
+		TestMigration: TestMigration{version: "003", name: "squashed", upFunc: record("003")},
+		replaces:      []string{"001", "002"},
+	})
+	registry.RegisterMigration(DependentTestMigration{
+		TestMigration: TestMigration{version: "004", name: "fourth", upFunc: record("004")},
+		dependencies:  []string{"002"},
+	})
+
+	return NewRunner(db, registry, ver), ver
+}
+
+func TestMigrateUsesSquashedMigration(t *testing.T) {
+	var ran []string
+	run, ver := squashTestRunner(t, &ran)
+
+	if err := run.Migrate(); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if !reflect.DeepEqual(ran, []string{"003", "004"}) {
+		t.Errorf("Expected the squashed migration to run in place of 001 and 002, got %v", ran)
+	}
+
+	applied, err := ver.GetAppliedVersions()
+	if err != nil {
+		t.Fatalf("GetAppliedVersions failed: %v", err)
+	}
+	if !reflect.DeepEqual(applied, []string{"003", "004"}) {
+		t.Errorf("Expected 003 and 004 recorded, got %v", applied)
+	}
+}
+
+func TestSquashedMigrationAppliedWhenReplacedApplied(t *testing.T) {
+	var ran []string
+	run, ver := squashTestRunner(t, &ran)
+	for _, version := range []string{"001", "002"} {
+		if err := ver.RecordApplied(version, "m"+version); err != nil {
+			t.Fatalf("RecordApplied failed: %v", err)
+		}
+	}
+
+	applied, err := run.GetAppliedMigrations()
+	if err != nil {
+		t.Fatalf("GetAppliedMigrations failed: %v", err)
+	}
+	if got := versionsOf(applied); !reflect.DeepEqual(got, []string{"003"}) {
+		t.Errorf("Expected the squashed migration to count as applied, got %v", got)
+	}
+
+	if err := run.Migrate(); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if !reflect.DeepEqual(ran, []string{"004"}) {
+		t.Errorf("Expected only 004 to run, got %v", ran)
+	}
+
+	// Rolling back the squashed migration removes the records of the migrations it replaces
+	if err := run.Rollback(2); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	count, err := ver.GetAppliedCount()
+	if err != nil {
+		t.Fatalf("GetAppliedCount failed: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected no applied migrations after rollback, got %d", count)
+	}
+}
+
+func TestSquashedMigrationPartlyApplied(t *testing.T) {
+	var ran []string
+	run, ver := squashTestRunner(t, &ran)
+	if err := ver.RecordApplied("001", "first"); err != nil {
+		t.Fatalf("RecordApplied failed: %v", err)
+	}
+
+	// The replaced migrations finish applying instead of the squashed one
+	if err := run.Migrate(); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if !reflect.DeepEqual(ran, []string{"002", "004"}) {
+		t.Errorf("Expected 002 and 004 to run, got %v", ran)
+	}
+
+	pending, err := run.GetPendingMigrations()
+	if err != nil {
+		t.Fatalf("GetPendingMigrations failed: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("Expected no pending migrations, got %v", versionsOf(pending))
+	}
+}
+
+func TestSquashedMigrationPartlyAppliedMissing(t *testing.T) {
+	db := setupTestDB(t)
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if err := ver.RecordApplied("001", "first"); err != nil {
+		t.Fatalf("RecordApplied failed: %v", err)
+	}
+
+	// The replaced migrations have been deleted
+	registry := NewRegistry()
+	registry.RegisterMigration(SquashedTestMigration{
+		TestMigration: TestMigration{version: "003", name: "squashed"},
+		replaces:      []string{"001", "002"},
+	})
+	run := NewRunner(db, registry, ver)
+
+	err := run.Migrate()
+	var partial *PartialSquashError
+	if !errors.As(err, &partial) {
+		t.Fatalf("Expected a PartialSquashError, got %v", err)
+	}
+	if partial.Version != "003" || !reflect.DeepEqual(partial.Missing, []string{"001", "002"}) {
+		t.Errorf("Unexpected error details: %+v", partial)
+	}
+}
+
+func TestVerifyIgnoresDeletedReplacedMigrations(t *testing.T) {
+	db := setupTestDB(t)
+	ver := versioner.NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	for _, version := range []string{"001", "002", "005"} {
+		if err := ver.RecordApplied(version, "m"+version); err != nil {
+			t.Fatalf("RecordApplied failed: %v", err)
+		}
+	}
+
+	registry := NewRegistry()
+	registry.RegisterMigration(SquashedTestMigration{
+		TestMigration: TestMigration{version: "003", name: "squashed"},
+		replaces:      []string{"001", "002"},
+	})
+	run := NewRunner(db, registry, ver)
+
+	issues, err := run.Verify()
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if len(issues) != 1 || issues[0].Version != "005" || issues[0].Problem != ChecksumMissing {
+		t.Errorf("Expected only 005 to be reported missing, got %+v", issues)
+	}
+}
+
+func TestSquashRange(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterMigration(TestMigration{version: "001", name: "first"})
+	registry.RegisterMigration(dependent("002", "001"))
+	registry.RegisterMigration(dependent("003", "002"))
+	registry.RegisterMigration(dependent("004", "001", "003"))
+
+	migrations, deps, err := registry.SquashRange("002", "003")
+	if err != nil {
+		t.Fatalf("SquashRange failed: %v", err)
+	}
+	if got := versionsOf(migrations); !reflect.DeepEqual(got, []string{"002", "003"}) {
+		t.Errorf("Expected [002 003], got %v", got)
+	}
+	if !reflect.DeepEqual(deps, []string{"001"}) {
+		t.Errorf("Expected dependencies [001], got %v", deps)
+	}
+
+	if _, _, err := registry.SquashRange("003", "002"); err == nil {
+		t.Error("Expected an error for a reversed range")
+	}
+	if _, _, err := registry.SquashRange("001", "009"); err == nil {
+		t.Error("Expected an error for an unknown migration")
+	}
+
+	registry.RegisterMigration(SquashedTestMigration{
+		TestMigration: TestMigration{version: "005", name: "squashed"},
+		replaces:      []string{"002", "003"},
+	})
+	if _, _, err := registry.SquashRange("001", "004"); err == nil {
+		t.Error("Expected an error for a range containing replaced migrations")
+	}
+}
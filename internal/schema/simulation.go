@@ -0,0 +1,28 @@
+package schema
+
+import (
+	"sync"
+	"unsafe"
+)
+
+// simulations holds the builders currently passed to migrations in place of a *gorm.DB
+var simulations sync.Map
+
+// BeginSimulation registers b as standing in for a *gorm.DB while migrations are simulated
+func BeginSimulation(b *SchemaBuilder) {
+	simulations.Store(unsafe.Pointer(b), b)
+}
+
+// EndSimulation unregisters a builder registered with BeginSimulation
+func EndSimulation(b *SchemaBuilder) {
+	simulations.Delete(unsafe.Pointer(b))
+}
+
+// SimulationFor returns the builder a database handle stands in for, if it is being simulated
+// ptr is the pointer a migration received as its *gorm.DB.
+func SimulationFor(ptr unsafe.Pointer) (*SchemaBuilder, bool) {
+	if b, ok := simulations.Load(ptr); ok {
+		return b.(*SchemaBuilder), true
+	}
+	return nil, false
+}
@@ -0,0 +1,143 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultConcurrency is how many tenants are migrated at once when no concurrency is configured
+const DefaultConcurrency = 1
+
+// schemaName matches the schema names tenants may use, so they can be put in SQL and URLs unquoted
+var schemaName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateSchema returns an error if name is not a plain PostgreSQL identifier
+func ValidateSchema(name string) error {
+	if !schemaName.MatchString(name) {
+		return fmt.Errorf("invalid tenant schema %q: must be a letter or underscore followed by letters, digits or underscores", name)
+	}
+	return nil
+}
+
+// Schemas returns the tenant schemas: the static list, or the first column of the rows the query returns
+func Schemas(db *gorm.DB, static []string, query string) ([]string, error) {
+	schemas := static
+	if query != "" {
+		schemas = nil
+		if err := db.Raw(query).Scan(&schemas).Error; err != nil {
+			return nil, fmt.Errorf("failed to query tenant schemas: %w", err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var unique []string
+	for _, schema := range schemas {
+		if err := ValidateSchema(schema); err != nil {
+			return nil, err
+		}
+		if !seen[schema] {
+			seen[schema] = true
+			unique = append(unique, schema)
+		}
+	}
+	return unique, nil
+}
+
+// EnsureSchema creates the schema of a tenant if it doesn't exist yet
+func EnsureSchema(db *gorm.DB, schema string) error {
+	if err := ValidateSchema(schema); err != nil {
+		return err
+	}
+	if err := db.Exec(fmt.Sprintf(`CREATE SCHEMA IF NOT EXISTS "%s"`, schema)).Error; err != nil {
+		return fmt.Errorf("failed to create schema %s: %w", schema, err)
+	}
+	return nil
+}
+
+// URL returns the PostgreSQL database URL whose connections use the tenant schema as their search_path
+// Each tenant gets its own connections, so the search_path can't leak between tenants through the pool.
+func URL(databaseURL, schema string) (string, error) {
+	if err := ValidateSchema(schema); err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(databaseURL, "postgres://") && !strings.HasPrefix(databaseURL, "postgresql://") {
+		return "", fmt.Errorf("tenant schemas require a postgres:// database_url")
+	}
+
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse database_url: %w", err)
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Result is the outcome of running the migrations of one tenant
+type Result struct {
+	Schema   string
+	Applied  int // Migrations applied
+	Err      error
+	Skipped  bool // Not started because an earlier tenant failed or the run was cancelled
+	Duration time.Duration
+}
+
+// Run calls fn for each schema, with at most concurrency calls at once, and returns the results in schema order
+// fn returns the number of migrations it applied. Unless continueOnError is set, no tenant is
+// started after one fails; tenants already running finish. Tenants are not started once ctx is done.
+func Run(ctx context.Context, schemas []string, concurrency int, continueOnError bool, fn func(ctx context.Context, schema string) (int, error)) []Result {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	results := make([]Result, len(schemas))
+	var (
+		mu     sync.Mutex
+		failed bool
+		wg     sync.WaitGroup
+	)
+	slots := make(chan struct{}, concurrency)
+
+	for i, schema := range schemas {
+		results[i].Schema = schema
+		slots <- struct{}{}
+
+		mu.Lock()
+		stop := (failed && !continueOnError) || ctx.Err() != nil
+		mu.Unlock()
+		if stop {
+			<-slots
+			results[i].Skipped = true
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, schema string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			start := time.Now()
+			applied, err := fn(ctx, schema)
+			results[i].Applied = applied
+			results[i].Err = err
+			results[i].Duration = time.Since(start)
+
+			if err != nil {
+				mu.Lock()
+				failed = true
+				mu.Unlock()
+			}
+		}(i, schema)
+	}
+
+	wg.Wait()
+	return results
+}
@@ -2,6 +2,9 @@ package versioner
 
 import (
 	"fmt"
+	"os"
+	"os/user"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -12,6 +15,17 @@ type MigrationRecord struct {
 	Version   string    `gorm:"primaryKey;column:version;size:255"`
 	Name      string    `gorm:"column:name;size:255"`
 	AppliedAt time.Time `gorm:"column:applied_at;autoCreateTime"`
+	Checksum  string    `gorm:"column:checksum;size:64"`
+
+	// Dirty is set while a migration runs, and stays set if it fails part way through
+	Dirty bool `gorm:"column:dirty;not null;default:false"`
+
+	// Execution details, empty on records written before they were tracked
+	DurationMs  int64  `gorm:"column:duration_ms"`
+	ToolVersion string `gorm:"column:tool_version;size:64"`
+	Hostname    string `gorm:"column:hostname;size:255"`
+	ExecutedBy  string `gorm:"column:executed_by;size:255"`
+	BuildLabel  string `gorm:"column:build_label;size:255"`
 }
 
 // TableName returns the table name for the migration record
@@ -19,10 +33,70 @@ func (MigrationRecord) TableName() string {
 	return "_goosegorm_migrations"
 }
 
+// Details describes when, where and how a migration was applied, leaving out unknown fields
+// Dirty records are described by when their migration started.
+func (r MigrationRecord) Details() string {
+	verb := "applied "
+	if r.Dirty {
+		verb = "started "
+	}
+	parts := []string{verb + r.AppliedAt.Format("2006-01-02 15:04:05")}
+	if r.ExecutedBy != "" && r.Hostname != "" {
+		parts = append(parts, fmt.Sprintf("by %s@%s", r.ExecutedBy, r.Hostname))
+	} else if r.ExecutedBy != "" {
+		parts = append(parts, "by "+r.ExecutedBy)
+	} else if r.Hostname != "" {
+		parts = append(parts, "on "+r.Hostname)
+	}
+	if r.DurationMs > 0 {
+		parts = append(parts, fmt.Sprintf("in %dms", r.DurationMs))
+	}
+	details := strings.Join(parts, " ")
+
+	var tool []string
+	if r.ToolVersion != "" {
+		tool = append(tool, "goosegorm "+r.ToolVersion)
+	}
+	if r.BuildLabel != "" {
+		tool = append(tool, "build "+r.BuildLabel)
+	}
+	if len(tool) > 0 {
+		details += " (" + strings.Join(tool, ", ") + ")"
+	}
+	return details
+}
+
+// ExecutionInfo is recorded with every migration the versioner marks as applied
+type ExecutionInfo struct {
+	ToolVersion string
+	Hostname    string
+	ExecutedBy  string
+	BuildLabel  string
+}
+
+// CurrentExecutionInfo returns the execution info of this process: its host and OS user
+// along with the given goosegorm version and build label
+func CurrentExecutionInfo(toolVersion, buildLabel string) ExecutionInfo {
+	info := ExecutionInfo{
+		ToolVersion: toolVersion,
+		BuildLabel:  buildLabel,
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		info.Hostname = hostname
+	}
+	if u, err := user.Current(); err == nil {
+		info.ExecutedBy = u.Username
+	} else {
+		info.ExecutedBy = os.Getenv("USER")
+	}
+	return info
+}
+
 // Versioner manages migration version tracking
 type Versioner struct {
 	db    *gorm.DB
 	table string
+	info  ExecutionInfo
 }
 
 // NewVersioner creates a new versioner
@@ -33,7 +107,23 @@ func NewVersioner(db *gorm.DB, tableName string) *Versioner {
 	}
 }
 
+// WithDB returns a copy of the versioner that uses the given database handle.
+// This is used to record migrations inside the same transaction as the migration itself.
+func (v *Versioner) WithDB(db *gorm.DB) *Versioner {
+	return &Versioner{
+		db:    db,
+		table: v.table,
+		info:  v.info,
+	}
+}
+
+// SetExecutionInfo sets the execution info recorded with applied migrations
+func (v *Versioner) SetExecutionInfo(info ExecutionInfo) {
+	v.info = info
+}
+
 // Initialize creates the migration tracking table if it doesn't exist
+// Tables created by older versions are upgraded with the columns they lack.
 func (v *Versioner) Initialize() error {
 	// Use GORM's AutoMigrate to create the table - this is database-agnostic
 	// GORM will handle the appropriate SQL syntax for the chosen database
@@ -52,10 +142,20 @@ func (v *Versioner) Initialize() error {
 	return nil
 }
 
+// Exists reports whether the migration tracking table exists, without creating it
+func (v *Versioner) Exists() bool {
+	return v.db.Migrator().HasTable(v.table)
+}
+
+// applied scopes a query to the records of migrations that completed
+func (v *Versioner) applied() *gorm.DB {
+	return v.db.Table(v.table).Where("dirty = ?", false)
+}
+
 // GetAppliedVersions returns all applied migration versions
 func (v *Versioner) GetAppliedVersions() ([]string, error) {
 	var records []MigrationRecord
-	if err := v.db.Table(v.table).Order("version ASC").Find(&records).Error; err != nil {
+	if err := v.applied().Order("version ASC").Find(&records).Error; err != nil {
 		return nil, fmt.Errorf("failed to query applied migrations: %w", err)
 	}
 
@@ -66,10 +166,42 @@ func (v *Versioner) GetAppliedVersions() ([]string, error) {
 	return versions, nil
 }
 
+// GetAppliedVersionsInApplyOrder returns all applied migration versions in the order they were applied
+// Migrations applied at the same time are ordered by version.
+func (v *Versioner) GetAppliedVersionsInApplyOrder() ([]string, error) {
+	var records []MigrationRecord
+	if err := v.applied().Order("applied_at ASC, version ASC").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+
+	versions := make([]string, len(records))
+	for i, r := range records {
+		versions[i] = r.Version
+	}
+	return versions, nil
+}
+
+// GetAppliedRecords returns all applied migration records ordered by version
+func (v *Versioner) GetAppliedRecords() ([]MigrationRecord, error) {
+	var records []MigrationRecord
+	if err := v.applied().Order("version ASC").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+	return records, nil
+}
+
+// SetChecksum updates the stored checksum of an applied migration
+func (v *Versioner) SetChecksum(version, checksum string) error {
+	if err := v.db.Table(v.table).Where("version = ?", version).Update("checksum", checksum).Error; err != nil {
+		return fmt.Errorf("failed to update checksum: %w", err)
+	}
+	return nil
+}
+
 // IsApplied checks if a migration version is already applied
 func (v *Versioner) IsApplied(version string) (bool, error) {
 	var count int64
-	if err := v.db.Table(v.table).Where("version = ?", version).Count(&count).Error; err != nil {
+	if err := v.applied().Where("version = ?", version).Count(&count).Error; err != nil {
 		return false, fmt.Errorf("failed to check migration status: %w", err)
 	}
 	return count > 0, nil
@@ -77,10 +209,30 @@ func (v *Versioner) IsApplied(version string) (bool, error) {
 
 // RecordApplied records a migration as applied
 func (v *Versioner) RecordApplied(version, name string) error {
+	return v.RecordAppliedWithChecksum(version, name, "")
+}
+
+// RecordAppliedWithChecksum records a migration as applied along with the checksum of its source
+func (v *Versioner) RecordAppliedWithChecksum(version, name, checksum string) error {
+	return v.RecordAppliedWithDuration(version, name, checksum, 0)
+}
+
+// RecordAppliedWithDuration records a migration as applied along with how long it took to run
+func (v *Versioner) RecordAppliedWithDuration(version, name, checksum string, duration time.Duration) error {
 	record := MigrationRecord{
-		Version:   version,
-		Name:      name,
-		AppliedAt: time.Now(),
+		Version:     version,
+		Name:        name,
+		AppliedAt:   time.Now(),
+		Checksum:    checksum,
+		DurationMs:  duration.Milliseconds(),
+		ToolVersion: v.info.ToolVersion,
+		Hostname:    v.info.Hostname,
+		ExecutedBy:  v.info.ExecutedBy,
+		BuildLabel:  v.info.BuildLabel,
+	}
+	// Replace the in-progress record written by MarkDirty
+	if err := v.db.Table(v.table).Where("version = ? AND dirty = ?", version, true).Delete(&MigrationRecord{}).Error; err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
 	}
 	if err := v.db.Table(v.table).Create(&record).Error; err != nil {
 		return fmt.Errorf("failed to record migration: %w", err)
@@ -88,6 +240,51 @@ func (v *Versioner) RecordApplied(version, name string) error {
 	return nil
 }
 
+// MarkDirty records that a migration is about to run
+// An applied migration's record is flagged; otherwise an in-progress record is written, which
+// doesn't count as applied. Either way the record stays dirty if the migration fails part way through.
+func (v *Versioner) MarkDirty(version, name string) error {
+	result := v.db.Table(v.table).Where("version = ?", version).Update("dirty", true)
+	if result.Error != nil {
+		return fmt.Errorf("failed to mark migration dirty: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	record := MigrationRecord{
+		Version:     version,
+		Name:        name,
+		AppliedAt:   time.Now(),
+		Dirty:       true,
+		ToolVersion: v.info.ToolVersion,
+		Hostname:    v.info.Hostname,
+		ExecutedBy:  v.info.ExecutedBy,
+		BuildLabel:  v.info.BuildLabel,
+	}
+	if err := v.db.Table(v.table).Create(&record).Error; err != nil {
+		return fmt.Errorf("failed to mark migration dirty: %w", err)
+	}
+	return nil
+}
+
+// MarkClean clears the dirty flag of an applied migration's record
+func (v *Versioner) MarkClean(version string) error {
+	if err := v.db.Table(v.table).Where("version = ?", version).Update("dirty", false).Error; err != nil {
+		return fmt.Errorf("failed to mark migration clean: %w", err)
+	}
+	return nil
+}
+
+// GetDirtyRecords returns the records of migrations that failed part way through, ordered by version
+func (v *Versioner) GetDirtyRecords() ([]MigrationRecord, error) {
+	var records []MigrationRecord
+	if err := v.db.Table(v.table).Where("dirty = ?", true).Order("version ASC").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to query dirty migrations: %w", err)
+	}
+	return records, nil
+}
+
 // RemoveApplied removes a migration record (for rollback)
 func (v *Versioner) RemoveApplied(version string) error {
 	if err := v.db.Table(v.table).Where("version = ?", version).Delete(&MigrationRecord{}).Error; err != nil {
@@ -99,7 +296,7 @@ func (v *Versioner) RemoveApplied(version string) error {
 // GetLatestVersion returns the latest applied migration version
 func (v *Versioner) GetLatestVersion() (string, error) {
 	var record MigrationRecord
-	if err := v.db.Table(v.table).Order("version DESC").First(&record).Error; err != nil {
+	if err := v.applied().Order("version DESC").First(&record).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return "", nil
 		}
@@ -111,7 +308,7 @@ func (v *Versioner) GetLatestVersion() (string, error) {
 // GetAppliedCount returns the number of applied migrations
 func (v *Versioner) GetAppliedCount() (int64, error) {
 	var count int64
-	if err := v.db.Table(v.table).Count(&count).Error; err != nil {
+	if err := v.applied().Count(&count).Error; err != nil {
 		return 0, fmt.Errorf("failed to count applied migrations: %w", err)
 	}
 	return count, nil
@@ -2,6 +2,7 @@ package versioner
 
 import (
 	"testing"
+	"time"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -41,6 +42,25 @@ func TestInitialize(t *testing.T) {
 	}
 }
 
+func TestExists(t *testing.T) {
+	db := setupTestDB(t)
+	ver := NewVersioner(db, "_test_migrations")
+
+	if ver.Exists() {
+		t.Fatal("Table should not exist before Initialize")
+	}
+	if db.Migrator().HasTable("_test_migrations") {
+		t.Fatal("Exists should not create the table")
+	}
+
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if !ver.Exists() {
+		t.Error("Table should exist after Initialize")
+	}
+}
+
 func TestRecordApplied(t *testing.T) {
 	db := setupTestDB(t)
 	ver := NewVersioner(db, "_test_migrations")
@@ -218,3 +238,190 @@ func TestGetAppliedCount(t *testing.T) {
 		t.Errorf("Expected count 3, got %d", count)
 	}
 }
+
+func TestRecordAppliedWithChecksum(t *testing.T) {
+	db := setupTestDB(t)
+	ver := NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	if err := ver.RecordAppliedWithChecksum("20250101000000", "first", "abc"); err != nil {
+		t.Fatalf("RecordAppliedWithChecksum failed: %v", err)
+	}
+	if err := ver.SetChecksum("20250101000000", "def"); err != nil {
+		t.Fatalf("SetChecksum failed: %v", err)
+	}
+
+	records, err := ver.GetAppliedRecords()
+	if err != nil {
+		t.Fatalf("GetAppliedRecords failed: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 record, got %d", len(records))
+	}
+	if records[0].Checksum != "def" {
+		t.Errorf("Expected checksum 'def', got '%s'", records[0].Checksum)
+	}
+}
+
+func TestGetAppliedVersionsInApplyOrder(t *testing.T) {
+	db := setupTestDB(t)
+	ver := NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	now := time.Now()
+	records := []MigrationRecord{
+		{Version: "20250101000000", Name: "first", AppliedAt: now},
+		{Version: "20250103000000", Name: "third", AppliedAt: now.Add(time.Minute)},
+		{Version: "20250102000000", Name: "second", AppliedAt: now.Add(2 * time.Minute)},
+	}
+	if err := db.Table("_test_migrations").Create(&records).Error; err != nil {
+		t.Fatalf("Failed to insert records: %v", err)
+	}
+
+	versions, err := ver.GetAppliedVersionsInApplyOrder()
+	if err != nil {
+		t.Fatalf("GetAppliedVersionsInApplyOrder failed: %v", err)
+	}
+
+	expected := []string{"20250101000000", "20250103000000", "20250102000000"}
+	for i, v := range expected {
+		if versions[i] != v {
+			t.Errorf("Expected %s at position %d, got %s", v, i, versions[i])
+		}
+	}
+}
+
+func TestRecordAppliedWithDuration(t *testing.T) {
+	db := setupTestDB(t)
+	ver := NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	ver.SetExecutionInfo(ExecutionInfo{ToolVersion: "0.6.0", Hostname: "web-1", ExecutedBy: "deploy", BuildLabel: "abc1234"})
+
+	// Transactions record through a copy of the versioner, which keeps the execution info
+	if err := ver.WithDB(db).RecordAppliedWithDuration("20250101000000", "first", "abc", 1500*time.Millisecond); err != nil {
+		t.Fatalf("RecordAppliedWithDuration failed: %v", err)
+	}
+
+	records, err := ver.GetAppliedRecords()
+	if err != nil {
+		t.Fatalf("GetAppliedRecords failed: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 record, got %d", len(records))
+	}
+	r := records[0]
+	if r.DurationMs != 1500 || r.ToolVersion != "0.6.0" || r.Hostname != "web-1" || r.ExecutedBy != "deploy" || r.BuildLabel != "abc1234" {
+		t.Errorf("Unexpected execution details: %+v", r)
+	}
+}
+
+func TestInitializeUpgradesOldTable(t *testing.T) {
+	db := setupTestDB(t)
+	// The tracking table as created before execution details were recorded
+	statements := []string{
+		"CREATE TABLE `_test_migrations` (`version` text,`name` text,`applied_at` datetime,`checksum` text,PRIMARY KEY (`version`))",
+		"INSERT INTO `_test_migrations` (`version`,`name`,`applied_at`,`checksum`) VALUES ('20250101000000','first','2025-01-01 00:00:00','abc')",
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("Failed to create old table: %v", err)
+		}
+	}
+
+	ver := NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	for _, column := range []string{"duration_ms", "tool_version", "hostname", "executed_by", "build_label"} {
+		if !db.Table("_test_migrations").Migrator().HasColumn(&MigrationRecord{}, column) {
+			t.Errorf("Expected column %s to be added", column)
+		}
+	}
+
+	if err := ver.RecordAppliedWithDuration("20250102000000", "second", "def", time.Second); err != nil {
+		t.Fatalf("RecordAppliedWithDuration failed: %v", err)
+	}
+	records, err := ver.GetAppliedRecords()
+	if err != nil {
+		t.Fatalf("GetAppliedRecords failed: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	if records[0].Checksum != "abc" || records[0].DurationMs != 0 || records[0].Hostname != "" {
+		t.Errorf("Expected the old record to be kept without details, got %+v", records[0])
+	}
+	if records[1].DurationMs != 1000 {
+		t.Errorf("Expected the new record to have a duration, got %+v", records[1])
+	}
+}
+
+func TestMigrationRecordDetails(t *testing.T) {
+	appliedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	full := MigrationRecord{AppliedAt: appliedAt, DurationMs: 42, ToolVersion: "0.6.0", Hostname: "web-1", ExecutedBy: "deploy", BuildLabel: "abc1234"}
+	expected := "applied 2025-01-02 03:04:05 by deploy@web-1 in 42ms (goosegorm 0.6.0, build abc1234)"
+	if full.Details() != expected {
+		t.Errorf("Expected %q, got %q", expected, full.Details())
+	}
+
+	old := MigrationRecord{AppliedAt: appliedAt}
+	if old.Details() != "applied 2025-01-02 03:04:05" {
+		t.Errorf("Expected only the apply time for an old record, got %q", old.Details())
+	}
+}
+
+func TestMarkDirty(t *testing.T) {
+	db := setupTestDB(t)
+	ver := NewVersioner(db, "_test_migrations")
+	if err := ver.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	if err := ver.RecordApplied("20250101000000", "first"); err != nil {
+		t.Fatalf("RecordApplied failed: %v", err)
+	}
+	// An in-progress record for a new migration, and a flag on an applied one
+	if err := ver.MarkDirty("20250102000000", "second"); err != nil {
+		t.Fatalf("MarkDirty failed: %v", err)
+	}
+	if err := ver.MarkDirty("20250101000000", "first"); err != nil {
+		t.Fatalf("MarkDirty failed: %v", err)
+	}
+
+	dirty, err := ver.GetDirtyRecords()
+	if err != nil {
+		t.Fatalf("GetDirtyRecords failed: %v", err)
+	}
+	if len(dirty) != 2 || dirty[0].Version != "20250101000000" || dirty[1].Version != "20250102000000" {
+		t.Fatalf("Expected both migrations dirty, got %+v", dirty)
+	}
+	if count, _ := ver.GetAppliedCount(); count != 0 {
+		t.Errorf("Expected dirty migrations not to count as applied, got %d", count)
+	}
+
+	if err := ver.MarkClean("20250101000000"); err != nil {
+		t.Fatalf("MarkClean failed: %v", err)
+	}
+	// Completing the migration replaces its in-progress record
+	if err := ver.RecordAppliedWithChecksum("20250102000000", "second", "abc"); err != nil {
+		t.Fatalf("RecordAppliedWithChecksum failed: %v", err)
+	}
+
+	versions, err := ver.GetAppliedVersions()
+	if err != nil {
+		t.Fatalf("GetAppliedVersions failed: %v", err)
+	}
+	if len(versions) != 2 {
+		t.Errorf("Expected 2 applied migrations, got %v", versions)
+	}
+	if dirty, _ := ver.GetDirtyRecords(); len(dirty) != 0 {
+		t.Errorf("Expected no dirty migrations, got %+v", dirty)
+	}
+}